@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultPresetBackups is the number of earlier versions kept of each
+// preset unless settings say otherwise.
+const DefaultPresetBackups = 5
+
+// PresetBackup is an earlier version of a preset, kept as name.toml.N with
+// 1 the most recent.
+type PresetBackup struct {
+	N        int
+	Modified time.Time
+}
+
+// backupPath returns the path of a preset file's Nth backup.
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// rotateBackups moves the file at path into its first backup slot, shifting
+// older backups up and dropping any beyond keep. Nothing happens when the
+// file doesn't exist or already holds data, so saving an unchanged preset
+// doesn't push real history out.
+func rotateBackups(path string, data []byte, keep int) error {
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) || keep <= 0 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if data != nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	// Drop backups past the limit, including any left by a larger one
+	for n := keep; ; n++ {
+		if err := os.Remove(backupPath(path, n)); err != nil {
+			break
+		}
+	}
+	for n := keep - 1; n >= 1; n-- {
+		if err := os.Rename(backupPath(path, n), backupPath(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return writeFileAtomic(backupPath(path, 1), current, 0644)
+}
+
+// presetPath returns the file path of a named preset.
+func presetPath(name string) (string, error) {
+	dir, err := presetsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)+".toml"), nil
+}
+
+// PresetBackups lists the backups kept of a preset, most recent first.
+func PresetBackups(name string) ([]PresetBackup, error) {
+	path, err := presetPath(name)
+	if err != nil {
+		return nil, err
+	}
+	var backups []PresetBackup
+	for n := 1; ; n++ {
+		info, err := os.Stat(backupPath(path, n))
+		if err != nil {
+			break
+		}
+		backups = append(backups, PresetBackup{N: n, Modified: info.ModTime()})
+	}
+	return backups, nil
+}
+
+// RestorePreset brings back backup n of a preset. The version it replaces
+// becomes the newest backup, so a restore can itself be undone.
+func RestorePreset(name string, n int) error {
+	if name == "" || !validPresetName(name) {
+		return fmt.Errorf("invalid preset name")
+	}
+	path, err := presetPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(backupPath(path, n))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("preset '%s' has no backup %d", name, n)
+	}
+	if err != nil {
+		return err
+	}
+	if err := rotateBackups(path, data, presetBackups()); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// presetBackups returns the number of backups to keep from the settings.
+func presetBackups() int {
+	keep := LoadSettings().PresetBackups
+	if keep < 0 {
+		return 0
+	}
+	return keep
+}
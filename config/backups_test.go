@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func presetWithGain(gain float32) Preset {
+	p := DefaultPreset()
+	p.Gain = gain
+	return p
+}
+
+func TestSavePreset_RollingBackups(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := SaveSettings(Settings{PresetBackups: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for _, gain := range []float32{0.1, 0.2, 0.2, 0.3, 0.4} {
+		if err := SavePreset(presetWithGain(gain), "wash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The unchanged save of 0.2 made no backup, and 0.1 was dropped
+	backups, err := PresetBackups("wash")
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %v (%v)", backups, err)
+	}
+	for n, want := range map[int]string{1: "gain = 0.3", 2: "gain = 0.2"} {
+		path, _ := presetPath("wash")
+		data, _ := os.ReadFile(backupPath(path, n))
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected backup %d to hold %q, got:\n%s", n, want, data)
+		}
+	}
+	if names, _ := ListPresets(); len(names) != 1 {
+		t.Errorf("expected backups not to be listed as presets, got %v", names)
+	}
+}
+
+func TestRestorePreset(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	SavePreset(presetWithGain(0.1), "wash")
+	SavePreset(presetWithGain(0.2), "wash")
+
+	if err := RestorePreset("wash", 1); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := LoadPreset("wash"); p.Gain != 0.1 {
+		t.Errorf("expected the backup restored, got gain %v", p.Gain)
+	}
+	// The replaced version can be restored in turn
+	if err := RestorePreset("wash", 1); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := LoadPreset("wash"); p.Gain != 0.2 {
+		t.Errorf("expected the restore undone, got gain %v", p.Gain)
+	}
+	if err := RestorePreset("wash", 9); err == nil {
+		t.Error("expected an error for a missing backup")
+	}
+}
+
+func TestDeletePreset_KeepsBackup(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	SavePreset(presetWithGain(0.7), "drone")
+	if err := DeletePreset("drone"); err != nil {
+		t.Fatal(err)
+	}
+	if PresetExists("drone") {
+		t.Fatal("expected the preset to be deleted")
+	}
+	if err := RestorePreset("drone", 1); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := LoadPreset("drone"); p.Gain != 0.7 {
+		t.Errorf("expected the deleted preset restored, got gain %v", p.Gain)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	os.WriteFile(path, []byte("old"), 0644)
+	if err := writeFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("expected the new contents, got %q", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left, got %d entries", len(entries))
+	}
+
+	// Temporary files go next to the target, so its directory must exist
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "x.toml"), []byte("x"), 0644); err == nil {
+		t.Error("expected an error writing into a missing directory")
+	}
+}
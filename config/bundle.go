@@ -0,0 +1,323 @@
+package config
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PresetBundleFormat is the version of the bundle layout written by
+// ExportPresets.
+const PresetBundleFormat = 1
+
+// bundleManifest is the name of the manifest in a zip bundle.
+const bundleManifest = "bundle.json"
+
+// PresetBundle is a set of presets exported for another machine. A JSON
+// bundle holds everything in one file; a zip bundle holds the manifest,
+// without preset data, as bundle.json and each preset file under presets/.
+type PresetBundle struct {
+	Format   int             `json:"format"`
+	Exported time.Time       `json:"exported"`
+	Source   string          `json:"source,omitempty"` // What was exported, "" for all presets
+	Presets  []BundledPreset `json:"presets"`
+	Checksum string          `json:"checksum"` // See PresetBundle.Sum
+}
+
+// BundledPreset is one preset in a bundle, as it was saved.
+type BundledPreset struct {
+	Name string `json:"name"`
+	Data string `json:"data,omitempty"` // The preset file
+}
+
+// Sum returns the checksum of the bundled presets, over their names and
+// file contents in order.
+func (b PresetBundle) Sum() string {
+	h := sha256.New()
+	for _, p := range b.Presets {
+		fmt.Fprintf(h, "%s\n%d\n%s", p.Name, len(p.Data), p.Data)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// ExportPresets writes presets to a bundle at path, as zip when the path
+// ends in .zip and JSON otherwise. Each name is a preset or a folder, whose
+// presets are exported with those of its subfolders; no names exports every
+// preset. It returns the names exported.
+func ExportPresets(path string, names ...string) ([]string, error) {
+	bundle := PresetBundle{
+		Format:   PresetBundleFormat,
+		Exported: time.Now().UTC().Truncate(time.Second),
+		Source:   strings.Join(names, ", "),
+	}
+	if len(names) == 0 {
+		names = []string{""}
+	}
+
+	var exported []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		presets, err := presetsUnder(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, preset := range presets {
+			if seen[preset] {
+				continue
+			}
+			seen[preset] = true
+			file, err := presetPath(preset)
+			if err != nil {
+				return nil, err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			bundle.Presets = append(bundle.Presets, BundledPreset{Name: preset, Data: string(data)})
+			exported = append(exported, preset)
+		}
+	}
+	if len(exported) == 0 {
+		return nil, fmt.Errorf("no presets to export")
+	}
+	bundle.Checksum = bundle.Sum()
+
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		data, err = encodeZipBundle(bundle)
+	} else {
+		data, err = json.MarshalIndent(bundle, "", "  ")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return exported, writeFileAtomic(path, data, 0644)
+}
+
+// presetsUnder returns the presets a name refers to: all presets for "",
+// those in a folder and its subfolders, or the preset itself.
+func presetsUnder(name string) ([]string, error) {
+	if name == "" {
+		return ListPresets()
+	}
+	if dir, err := presetFolderPath(name); err == nil {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return listPresetsIn(name)
+		}
+	}
+	if !presetFileExists(name) {
+		return nil, fmt.Errorf("no preset or folder named '%s'", name)
+	}
+	return []string{name}, nil
+}
+
+// presetFileExists reports whether a preset is saved, without loading it.
+func presetFileExists(name string) bool {
+	if !validPresetName(name) {
+		return false
+	}
+	path, err := presetPath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+func encodeZipBundle(bundle PresetBundle) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	manifest := bundle
+	manifest.Presets = nil
+	for _, p := range bundle.Presets {
+		f, err := w.Create("presets/" + p.Name + ".toml")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.Data); err != nil {
+			return nil, err
+		}
+		manifest.Presets = append(manifest.Presets, BundledPreset{Name: p.Name})
+	}
+
+	f, err := w.Create(bundleManifest)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadPresetBundle reads a bundle written by ExportPresets, as zip or JSON
+// by its contents, and verifies its checksum.
+func ReadPresetBundle(path string) (PresetBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PresetBundle{}, err
+	}
+
+	var bundle PresetBundle
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		bundle, err = decodeZipBundle(data)
+	} else {
+		err = json.Unmarshal(data, &bundle)
+	}
+	if err != nil {
+		return PresetBundle{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	if bundle.Format < 1 || bundle.Format > PresetBundleFormat {
+		return PresetBundle{}, fmt.Errorf("%s: bundle format %d is not supported by this release (%d)", filepath.Base(path), bundle.Format, PresetBundleFormat)
+	}
+	if bundle.Checksum != bundle.Sum() {
+		return PresetBundle{}, fmt.Errorf("%s: checksum mismatch, the bundle is damaged or was edited", filepath.Base(path))
+	}
+	return bundle, nil
+}
+
+func decodeZipBundle(data []byte) (PresetBundle, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return PresetBundle{}, err
+	}
+	read := func(name string) ([]byte, error) {
+		f, err := r.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	}
+
+	manifest, err := read(bundleManifest)
+	if err != nil {
+		return PresetBundle{}, fmt.Errorf("missing %s", bundleManifest)
+	}
+	var bundle PresetBundle
+	if err := json.Unmarshal(manifest, &bundle); err != nil {
+		return PresetBundle{}, err
+	}
+	for i, p := range bundle.Presets {
+		if !validPresetName(p.Name) {
+			return PresetBundle{}, fmt.Errorf("invalid preset name %q", p.Name)
+		}
+		file, err := read("presets/" + p.Name + ".toml")
+		if err != nil {
+			return PresetBundle{}, fmt.Errorf("missing preset '%s'", p.Name)
+		}
+		bundle.Presets[i].Data = string(file)
+	}
+	return bundle, nil
+}
+
+// ImportMode says what importing does with a preset whose name is taken.
+type ImportMode int
+
+const (
+	ImportRename    ImportMode = iota // Save it under a free name, "name-2" and so on
+	ImportOverwrite                   // Replace the saved preset, which is kept as a backup
+	ImportSkip                        // Keep the saved preset
+)
+
+// ImportModeNames lists the names ParseImportMode accepts, in ImportMode
+// order.
+var ImportModeNames = []string{"rename", "overwrite", "skip"}
+
+// ParseImportMode parses an import mode name.
+func ParseImportMode(s string) (ImportMode, error) {
+	for i, name := range ImportModeNames {
+		if strings.EqualFold(s, name) {
+			return ImportMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown import mode %q (want %s)", s, strings.Join(ImportModeNames, ", "))
+}
+
+// ImportResult is what became of one bundled preset.
+type ImportResult struct {
+	Name    string   // Name in the bundle
+	SavedAs string   // Name it was saved under, "" when not saved
+	Changes []string // Changes made upgrading it, see MigratePreset
+	Err     error
+}
+
+func (r ImportResult) String() string {
+	var s string
+	switch {
+	case r.Err != nil:
+		s = fmt.Sprintf("%s: failed: %v", r.Name, r.Err)
+	case r.SavedAs == "":
+		s = fmt.Sprintf("%s: skipped, already exists", r.Name)
+	case r.SavedAs != r.Name:
+		s = fmt.Sprintf("%s: imported as %s", r.Name, r.SavedAs)
+	default:
+		s = fmt.Sprintf("%s: imported", r.Name)
+	}
+	if len(r.Changes) > 0 {
+		s += " (" + strings.Join(r.Changes, "; ") + ")"
+	}
+	return s
+}
+
+// ImportPresets saves the presets in a bundle. Each goes through the same
+// upgrade and checks as loading a preset file, and one that fails doesn't
+// stop the others; only an unreadable bundle returns an error.
+func ImportPresets(path string, mode ImportMode) ([]ImportResult, error) {
+	bundle, err := ReadPresetBundle(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImportResult, 0, len(bundle.Presets))
+	for _, p := range bundle.Presets {
+		result := ImportResult{Name: p.Name}
+		result.SavedAs, result.Changes, result.Err = importPreset(p, mode)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func importPreset(p BundledPreset, mode ImportMode) (string, []string, error) {
+	if !validPresetName(p.Name) {
+		return "", nil, fmt.Errorf("invalid preset name")
+	}
+	preset, _, changes, err := decodePreset([]byte(p.Data))
+	if err != nil {
+		return "", nil, err
+	}
+	changes = append(changes, preset.Warnings...)
+
+	name := p.Name
+	if presetFileExists(name) {
+		switch mode {
+		case ImportSkip:
+			return "", nil, nil
+		case ImportRename:
+			for n := 2; presetFileExists(name); n++ {
+				name = fmt.Sprintf("%s-%d", p.Name, n)
+			}
+		}
+	}
+	if err := SavePreset(preset, name); err != nil {
+		return "", changes, err
+	}
+	return name, changes, nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportImportPresets(t *testing.T) {
+	for _, ext := range []string{".json", ".zip"} {
+		t.Run(ext, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			SavePreset(presetWithGain(0.1), "show1/intro")
+			SavePreset(presetWithGain(0.2), "show1/act2/storm")
+			SavePreset(presetWithGain(0.3), "wash")
+
+			path := filepath.Join(t.TempDir(), "show1"+ext)
+			names, err := ExportPresets(path, "show1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"show1/act2/storm", "show1/intro"}; !reflect.DeepEqual(names, want) {
+				t.Errorf("expected %v exported, got %v", want, names)
+			}
+
+			// On another machine
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			SavePreset(presetWithGain(0.9), "show1/intro")
+			results, err := ImportPresets(path, ImportRename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(results))
+			for i, r := range results {
+				got[i] = r.String()
+			}
+			want := []string{"show1/act2/storm: imported", "show1/intro: imported as show1/intro-2"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+			if p, _ := LoadPreset("show1/intro-2"); p.Gain != 0.1 {
+				t.Errorf("expected the bundled values, got gain %v", p.Gain)
+			}
+			if p, _ := LoadPreset("show1/intro"); p.Gain != 0.9 {
+				t.Errorf("expected the existing preset kept, got gain %v", p.Gain)
+			}
+		})
+	}
+}
+
+func TestImportPresets_Collisions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	SavePreset(presetWithGain(0.1), "wash")
+	path := filepath.Join(t.TempDir(), "wash.json")
+	if _, err := ExportPresets(path, "wash"); err != nil {
+		t.Fatal(err)
+	}
+	SavePreset(presetWithGain(0.5), "wash")
+
+	results, _ := ImportPresets(path, ImportSkip)
+	if results[0].SavedAs != "" || !strings.Contains(results[0].String(), "skipped") {
+		t.Errorf("expected the preset skipped, got %v", results[0])
+	}
+	if p, _ := LoadPreset("wash"); p.Gain != 0.5 {
+		t.Errorf("expected skip to keep the saved preset, got gain %v", p.Gain)
+	}
+
+	results, _ = ImportPresets(path, ImportOverwrite)
+	if results[0].SavedAs != "wash" || results[0].Err != nil {
+		t.Errorf("expected the preset overwritten, got %v", results[0])
+	}
+	if p, _ := LoadPreset("wash"); p.Gain != 0.1 {
+		t.Errorf("expected the bundled values, got gain %v", p.Gain)
+	}
+	if backups, _ := PresetBackups("wash"); len(backups) == 0 {
+		t.Error("expected the overwritten preset kept as a backup")
+	}
+
+	if _, err := ParseImportMode("merge"); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
+
+func TestImportPresets_UpgradesAndChecks(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	bundle := PresetBundle{
+		Format: PresetBundleFormat,
+		Presets: []BundledPreset{
+			{Name: "old", Data: "gain = 1.5\ninput_freeze_length = 0.25\n"},
+			{Name: "../escape", Data: "gain = 1.0\n"},
+			{Name: "future", Data: "schema_version = 99\n"},
+		},
+	}
+	bundle.Checksum = bundle.Sum()
+	data, _ := json.Marshal(bundle)
+	path := filepath.Join(t.TempDir(), "mixed.json")
+	os.WriteFile(path, data, 0644)
+
+	results, err := ImportPresets(path, ImportRename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := LoadPreset("old"); p.InputFreezeLength != 0.25 || len(results[0].Changes) == 0 {
+		t.Errorf("expected the old preset upgraded on import, got %v (%v)", p.InputFreezeLength, results[0])
+	}
+	for _, r := range results[1:] {
+		if r.Err == nil {
+			t.Errorf("expected %s to fail", r.Name)
+		}
+	}
+
+	// A damaged bundle is refused as a whole
+	os.WriteFile(path, []byte(strings.Replace(string(data), "1.5", "2.5", 1)), 0644)
+	if _, err := ImportPresets(path, ImportRename); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("expected a checksum error, got %v", err)
+	}
+}
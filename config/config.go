@@ -1,72 +1,405 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 type Config struct {
-	CC           map[string]int `toml:"cc"`
-	Notes        map[string]int `toml:"notes"`
-	EffectsOrder []string       `toml:"effects_order,omitempty"`
+	Profile      string                    `toml:"profile,omitempty"` // Built-in controller profile the mappings layer over
+	CC           map[string]int            `toml:"cc"`
+	Notes        map[string]int            `toml:"notes"`
+	CC14         map[string]int            `toml:"cc14,omitempty"`          // 14-bit CC pairs by MSB controller (0-31)
+	NRPN         map[string]int            `toml:"nrpn,omitempty"`          // NRPN parameter numbers (0-16383)
+	RPN          map[string]int            `toml:"rpn,omitempty"`           // RPN parameter numbers (0-16383)
+	PolyPressure map[string]int            `toml:"poly_pressure,omitempty"` // Polyphonic aftertouch by note
+	PitchBend    []string                  `toml:"pitch_bend,omitempty"`    // Parameters driven by pitch bend
+	Pressure     []string                  `toml:"pressure,omitempty"`      // Parameters driven by channel pressure
+	ModWheel     []string                  `toml:"mod_wheel,omitempty"`     // Parameters driven by the mod wheel (CC 1)
+	Options      map[string]MappingOptions `toml:"options,omitempty"`
+	Takeover     string                    `toml:"takeover,omitempty"`      // Default CC takeover mode, see Takeover*
+	InputPort    string                    `toml:"input_port,omitempty"`    // Input port name, empty for the first port
+	FeedbackPort string                    `toml:"feedback_port,omitempty"` // Output port name for controller feedback
+	EffectsOrder []string                  `toml:"effects_order,omitempty"`
+
+	// Input devices by name, each with its own port and mappings. When set,
+	// the top-level mappings are not used.
+	Devices map[string]Config `toml:"devices,omitempty"`
+
+	// Program changes recall presets, see ProgramPreset
+	ProgramChanges     map[string]string `toml:"program_changes,omitempty"`      // "program" or "bank:program" to preset name
+	ProgramChangeMode  string            `toml:"program_change_mode,omitempty"`  // See ProgramChange*
+	ProgramChangeOrder []string          `toml:"program_change_order,omitempty"` // Preset order for index mode
+}
+
+// Program change modes control how a program change picks a preset.
+const (
+	ProgramChangeMap   = "map"   // Look the program up in ProgramChanges
+	ProgramChangeIndex = "index" // Program N loads the Nth preset
+)
+
+// Note modes control how a mapped note drives its parameter.
+const (
+	NoteModeToggle    = "toggle"    // Note on flips the current state
+	NoteModeMomentary = "momentary" // On at note on, off at note off
+	NoteModeTrigger   = "trigger"   // Note on fires once, note off is ignored
+)
+
+// Takeover modes control what a CC does when its position disagrees with
+// the parameter's current value, e.g. after a preset load.
+const (
+	TakeoverJump   = "jump"   // The parameter jumps to the control
+	TakeoverPickup = "pickup" // The control is ignored until it crosses the value
+	TakeoverScale  = "scale"  // The parameter converges gradually with the control
+)
+
+// TakeoverModes lists the takeover modes in display order.
+var TakeoverModes = []string{TakeoverJump, TakeoverPickup, TakeoverScale}
+
+// MappingOptions holds per-parameter mapping settings, keyed by parameter
+// name under [options.<name>].
+type MappingOptions struct {
+	Mode     string `toml:"mode,omitempty"`     // Note mode, see NoteMode*
+	Velocity bool   `toml:"velocity,omitempty"` // Note velocity sets the value
+	Takeover string `toml:"takeover,omitempty"` // CC takeover mode, see Takeover*
+
+	// Continuous sources (CCs, pitch bend, pressure) are shaped by Curve and
+	// then scaled across Range, in parameter units
+	Curve string    `toml:"curve,omitempty"` // See Curve*
+	Range []float32 `toml:"range,omitempty"` // Low and high value, high first to invert
+
+	// ReturnToCenter makes pitch bend move the parameter either side of its
+	// value and restore it when the wheel springs back
+	ReturnToCenter bool `toml:"return_to_center,omitempty"`
+
+	Channel int `toml:"channel,omitempty"` // MIDI channel 1-16 the sources respond on, 0 for any
+}
+
+// NoteMode returns the note mode for a parameter, defaulting to toggle for
+// on/off parameters and trigger for everything else.
+func (c Config) NoteMode(name string) string {
+	if mode := c.MappingOptions(name).Mode; mode != "" {
+		return mode
+	}
+	if param, ok := LookupParam(name); ok && param.Kind == ParamToggle {
+		return NoteModeToggle
+	}
+	return NoteModeTrigger
+}
+
+// TakeoverMode returns the CC takeover mode for a parameter, falling back to
+// the config default and then to jump.
+func (c Config) TakeoverMode(name string) string {
+	if mode := c.MappingOptions(name).Takeover; mode != "" {
+		return mode
+	}
+	if c.Takeover != "" {
+		return c.Takeover
+	}
+	return TakeoverJump
+}
+
+// Profiles returns the mapping profile for each input device by name.
+// Without [devices] the config itself is the single, unnamed profile.
+func (c Config) Profiles() map[string]Config {
+	if len(c.Devices) == 0 {
+		return map[string]Config{"": c}
+	}
+	return c.Devices
+}
+
+// ProfileNames returns the profile names in sorted order.
+func ProfileNames(profiles map[string]Config) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProgramPreset returns the preset to load for a program change, given the
+// available preset names. Programs and banks are the 0-based numbers sent on
+// the wire. In map mode a "bank:program" entry wins over a plain "program"
+// entry. In index mode bank*128+program indexes ProgramChangeOrder, or the
+// sorted presets if no order is set.
+func (c Config) ProgramPreset(bank, program int, presets []string) (string, bool) {
+	if c.ProgramChangeMode == ProgramChangeIndex {
+		order := c.ProgramChangeOrder
+		if len(order) == 0 {
+			order = append([]string(nil), presets...)
+			sort.Strings(order)
+		}
+		idx := bank*128 + program
+		if idx < len(order) {
+			return order[idx], true
+		}
+		return "", false
+	}
+
+	if name, ok := c.ProgramChanges[fmt.Sprintf("%d:%d", bank, program)]; ok {
+		return name, true
+	}
+	name, ok := c.ProgramChanges[strconv.Itoa(program)]
+	return name, ok
+}
+
+// parseProgramKey parses a [program_changes] key, "program" or "bank:program".
+func parseProgramKey(key string) (bank, program int, err error) {
+	programStr := key
+	if b, p, found := strings.Cut(key, ":"); found {
+		if bank, err = strconv.Atoi(b); err != nil || bank < 0 || bank > 16383 {
+			return 0, 0, fmt.Errorf("bank %q out of range 0-16383", b)
+		}
+		programStr = p
+	}
+	if program, err = strconv.Atoi(programStr); err != nil || program < 0 || program > 127 {
+		return 0, 0, fmt.Errorf("program %q out of range 0-127", programStr)
+	}
+	return bank, program, nil
+}
+
+// MappingOptions returns the options for a parameter, resolving legacy names.
+func (c Config) MappingOptions(name string) MappingOptions {
+	param, ok := LookupParam(name)
+	if !ok {
+		return MappingOptions{}
+	}
+	for key, opts := range c.Options {
+		if p, ok := LookupParam(key); ok && p.Name == param.Name {
+			return opts
+		}
+	}
+	return MappingOptions{}
 }
 
 func DefaultConfig() Config {
 	return Config{
 		CC: map[string]int{
-			"gain":               1,
-			"input_freeze_len":   2,
-			"filter_amount":      3,
-			"filter_cutoff":      4,
-			"filter_resonance":   5,
-			"granular_density":   6,
-			"granular_size":      7,
-			"granular_mix":       8,
-			"reverb_delay_blend": 9,
-			"decay_time":         10,
-			"dry_wet":            11,
+			"gain":                1,
+			"input_freeze_length": 2,
+			"filter_amount":       3,
+			"filter_cutoff":       4,
+			"filter_resonance":    5,
+			"granular_density":    6,
+			"granular_size":       7,
+			"granular_mix":        8,
+			"reverb_mix":          9,
+			"reverb_decay_time":   10,
+			"dry_wet":             11,
+			"delay_mix":           12,
 		},
 		Notes: map[string]int{
-			"input_freeze":    60,
-			"granular_freeze": 62,
-			"mode_mirror":     64,
-			"mode_complement": 65,
-			"mode_transform":  67,
+			"input_frozen":          60,
+			"granular_frozen":       62,
+			"blend_mode_mirror":     64,
+			"blend_mode_complement": 65,
+			"blend_mode_transform":  67,
 		},
 		EffectsOrder: []string{"filter", "overdrive", "bitcrush", "granular", "reverb", "delay"},
 	}
 }
 
-func Load() Config {
-	cfg := DefaultConfig()
+// Load loads the MIDI config from the config directory. If the file can't
+// be read or doesn't validate, it returns the error with the default config.
+func Load() (Config, error) {
+	return LoadWithProfile("")
+}
 
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return cfg
-	}
+// LoadWithProfile loads the MIDI config from the config directory. A
+// non-empty profile replaces the controller profile named in the file, and
+// the file's mappings still layer over it.
+func LoadWithProfile(profile string) (Config, error) {
+	var user Config
 
-	configPath := filepath.Join(configDir, "chroma", "midi.toml")
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return cfg
+	if configPath, err := ConfigPath(); err == nil {
+		if _, err := toml.DecodeFile(configPath, &user); err != nil && !os.IsNotExist(err) {
+			return DefaultConfig(), err
+		}
 	}
 
-	if _, err := toml.DecodeFile(configPath, &cfg); err != nil {
-		return DefaultConfig()
+	if profile != "" {
+		user.Profile = profile
 	}
+	return resolve(user)
+}
 
-	return cfg
+// ConfigPath returns the path of the MIDI config file.
+func ConfigPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "chroma", "midi.toml"), nil
 }
 
 func LoadPath(path string) (Config, error) {
-	cfg := DefaultConfig()
+	var user Config
 
-	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+	if _, err := toml.DecodeFile(path, &user); err != nil {
 		return DefaultConfig(), err
 	}
 
-	return cfg, nil
+	return resolve(user)
+}
+
+// Validate checks that every mapping names a known parameter and uses a
+// valid MIDI number.
+func (c Config) Validate() error {
+	if c.Profile != "" {
+		if _, err := parseControllerProfile(c.Profile); err != nil {
+			return err
+		}
+	}
+	ports := make(map[string]string)
+	for _, name := range ProfileNames(c.Devices) {
+		dev := c.Devices[name]
+		if len(dev.Devices) > 0 {
+			return fmt.Errorf("devices: %s: devices cannot be nested", name)
+		}
+		if dev.InputPort == "" {
+			return fmt.Errorf("devices: %s: input_port is required", name)
+		}
+		if other, ok := ports[dev.InputPort]; ok {
+			return fmt.Errorf("devices: %s: input_port %q is already used by %s", name, dev.InputPort, other)
+		}
+		ports[dev.InputPort] = name
+		if err := dev.Validate(); err != nil {
+			return fmt.Errorf("devices: %s: %w", name, err)
+		}
+	}
+	if len(c.Devices) > 0 {
+		// Each device is a whole profile, so these are only read per device
+		for _, s := range []struct {
+			key string
+			set bool
+		}{
+			{"takeover", c.Takeover != ""},
+			{"options", len(c.Options) > 0},
+			{"input_port", c.InputPort != ""},
+			{"feedback_port", c.FeedbackPort != ""},
+			{"program_changes", len(c.ProgramChanges) > 0},
+			{"program_change_mode", c.ProgramChangeMode != ""},
+			{"program_change_order", len(c.ProgramChangeOrder) > 0},
+		} {
+			if s.set {
+				return fmt.Errorf("%s has no effect with [devices], set it under each device instead", s.key)
+			}
+		}
+	}
+	if err := validateTakeover(c.Takeover); err != nil {
+		return err
+	}
+	for name, cc := range c.CC {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("cc: %w", err)
+		}
+		if cc < 0 || cc > 127 {
+			return fmt.Errorf("cc: %s: controller %d out of range 0-127", name, cc)
+		}
+	}
+	for name, note := range c.Notes {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("notes: %w", err)
+		}
+		if note < 0 || note > 127 {
+			return fmt.Errorf("notes: %s: note %d out of range 0-127", name, note)
+		}
+	}
+	for name, cc := range c.CC14 {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("cc14: %w", err)
+		}
+		if cc < 0 || cc > 31 {
+			return fmt.Errorf("cc14: %s: controller %d out of range 0-31", name, cc)
+		}
+	}
+	for name, number := range c.NRPN {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("nrpn: %w", err)
+		}
+		if number < 0 || number > 16383 {
+			return fmt.Errorf("nrpn: %s: parameter %d out of range 0-16383", name, number)
+		}
+	}
+	for name, number := range c.RPN {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("rpn: %w", err)
+		}
+		if number < 0 || number > 16382 {
+			return fmt.Errorf("rpn: %s: parameter %d out of range 0-16382", name, number)
+		}
+	}
+	for name, note := range c.PolyPressure {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("poly_pressure: %w", err)
+		}
+		if note < 0 || note > 127 {
+			return fmt.Errorf("poly_pressure: %s: note %d out of range 0-127", name, note)
+		}
+	}
+	for _, source := range []struct {
+		key   string
+		names []string
+	}{
+		{"pitch_bend", c.PitchBend},
+		{"pressure", c.Pressure},
+		{"mod_wheel", c.ModWheel},
+	} {
+		for _, name := range source.names {
+			if err := validateParamName(name); err != nil {
+				return fmt.Errorf("%s: %w", source.key, err)
+			}
+		}
+	}
+	switch c.ProgramChangeMode {
+	case "", ProgramChangeMap, ProgramChangeIndex:
+	default:
+		return fmt.Errorf("unknown program change mode %q", c.ProgramChangeMode)
+	}
+	for key, name := range c.ProgramChanges {
+		if _, _, err := parseProgramKey(key); err != nil {
+			return fmt.Errorf("program_changes: %s: %w", key, err)
+		}
+		if name == "" {
+			return fmt.Errorf("program_changes: %s: empty preset name", key)
+		}
+	}
+	for name, opts := range c.Options {
+		if err := validateParamName(name); err != nil {
+			return fmt.Errorf("options: %w", err)
+		}
+		switch opts.Mode {
+		case "", NoteModeToggle, NoteModeMomentary, NoteModeTrigger:
+		default:
+			return fmt.Errorf("options: %s: unknown note mode %q", name, opts.Mode)
+		}
+		if err := validateTakeover(opts.Takeover); err != nil {
+			return fmt.Errorf("options: %s: %w", name, err)
+		}
+		if opts.Channel < 0 || opts.Channel > 16 {
+			return fmt.Errorf("options: %s: channel %d out of range 1-16", name, opts.Channel)
+		}
+		param, _ := LookupParam(name)
+		if err := validateShape(param, opts); err != nil {
+			return fmt.Errorf("options: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validateTakeover(mode string) error {
+	switch mode {
+	case "", TakeoverJump, TakeoverPickup, TakeoverScale:
+		return nil
+	}
+	return fmt.Errorf("unknown takeover mode %q", mode)
 }
 
 func Save(cfg Config, path string) error {
@@ -76,20 +409,16 @@ func Save(cfg Config, path string) error {
 		return err
 	}
 
-	file, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	return toml.NewEncoder(file).Encode(cfg)
+	return writeTOMLAtomic(path, cfg)
 }
 
 // Settings holds UI-only preferences (not effect parameters)
 type Settings struct {
-	ShowStatus     bool `toml:"show_status"`
-	ShowPagination bool `toml:"show_pagination"`
-	ShowTitle      bool `toml:"show_title"`
+	ShowStatus     bool    `toml:"show_status"`
+	ShowPagination bool    `toml:"show_pagination"`
+	ShowTitle      bool    `toml:"show_title"`
+	PresetBackups  int     `toml:"preset_backups"`  // Earlier versions kept of each preset, 0 for none
+	MorphThreshold float32 `toml:"morph_threshold"` // Morph position where switched values change, see MorphPresets
 }
 
 // DefaultSettings returns default TUI settings.
@@ -98,6 +427,8 @@ func DefaultSettings() Settings {
 		ShowStatus:     true,
 		ShowPagination: true,
 		ShowTitle:      true,
+		PresetBackups:  DefaultPresetBackups,
+		MorphThreshold: DefaultMorphThreshold,
 	}
 }
 
@@ -134,12 +465,5 @@ func SaveSettings(settings Settings) error {
 		return err
 	}
 
-	settingsPath := filepath.Join(dir, "settings.toml")
-	file, err := os.Create(settingsPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	return toml.NewEncoder(file).Encode(settings)
+	return writeTOMLAtomic(filepath.Join(dir, "settings.toml"), settings)
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -39,3 +40,244 @@ func TestEffectsOrderPersistence(t *testing.T) {
 		t.Errorf("Expected effects order [granular, filter, delay], got %v", loaded.EffectsOrder)
 	}
 }
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("expected default config to be valid, got %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.CC["reverb_time"] = 9
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown CC parameter name")
+	}
+
+	cfg = DefaultConfig()
+	cfg.Notes["filter_enabled"] = 128
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for out of range note number")
+	}
+
+	cfg = DefaultConfig()
+	cfg.CC14 = map[string]int{"filter_cutoff": 32}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for 14-bit CC outside 0-31")
+	}
+
+	cfg = DefaultConfig()
+	cfg.NRPN = map[string]int{"filter_cutoff": 16384}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for out of range NRPN number")
+	}
+
+	// Legacy names from older configs are still accepted
+	cfg = DefaultConfig()
+	cfg.CC["input_freeze_len"] = 2
+	cfg.Notes["mode_mirror"] = 64
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected legacy names to be valid, got %v", err)
+	}
+}
+
+func TestConfigNoteMode(t *testing.T) {
+	cfg := DefaultConfig()
+	if mode := cfg.NoteMode("input_frozen"); mode != NoteModeToggle {
+		t.Errorf("expected toggle mode for input_frozen, got %s", mode)
+	}
+	if mode := cfg.NoteMode("blend_mode_mirror"); mode != NoteModeTrigger {
+		t.Errorf("expected trigger mode for blend_mode_mirror, got %s", mode)
+	}
+
+	// Options keyed by a legacy name apply to the current name
+	cfg.Options = map[string]MappingOptions{"input_freeze": {Mode: NoteModeMomentary}}
+	if mode := cfg.NoteMode("input_frozen"); mode != NoteModeMomentary {
+		t.Errorf("expected momentary mode for input_frozen, got %s", mode)
+	}
+
+	cfg.Options = map[string]MappingOptions{"input_frozen": {Mode: "latch"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown note mode")
+	}
+}
+
+func TestConfigTakeoverMode(t *testing.T) {
+	cfg := DefaultConfig()
+	if mode := cfg.TakeoverMode("gain"); mode != TakeoverJump {
+		t.Errorf("expected jump takeover by default, got %s", mode)
+	}
+
+	cfg.Takeover = TakeoverPickup
+	cfg.Options = map[string]MappingOptions{"gain": {Takeover: TakeoverScale}}
+	if mode := cfg.TakeoverMode("gain"); mode != TakeoverScale {
+		t.Errorf("expected scale takeover for gain, got %s", mode)
+	}
+	if mode := cfg.TakeoverMode("dry_wet"); mode != TakeoverPickup {
+		t.Errorf("expected pickup takeover for dry_wet, got %s", mode)
+	}
+
+	cfg.Takeover = "catch"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown takeover mode")
+	}
+}
+
+func TestConfigProgramPreset(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProgramChanges = map[string]string{"0": "clean", "1:0": "wash"}
+
+	if name, ok := cfg.ProgramPreset(0, 0, nil); !ok || name != "clean" {
+		t.Errorf("expected program 0 to load clean, got %q", name)
+	}
+	if name, ok := cfg.ProgramPreset(1, 0, nil); !ok || name != "wash" {
+		t.Errorf("expected bank 1 program 0 to load wash, got %q", name)
+	}
+	// Plain entries apply in every bank
+	if name, ok := cfg.ProgramPreset(2, 0, nil); !ok || name != "clean" {
+		t.Errorf("expected bank 2 program 0 to fall back to clean, got %q", name)
+	}
+	if _, ok := cfg.ProgramPreset(0, 5, nil); ok {
+		t.Error("expected unmapped program to load nothing")
+	}
+
+	cfg.ProgramChangeMode = ProgramChangeIndex
+	presets := []string{"wash", "clean", "drone"}
+	if name, ok := cfg.ProgramPreset(0, 1, presets); !ok || name != "drone" {
+		t.Errorf("expected program 1 to load the second sorted preset, got %q", name)
+	}
+	if _, ok := cfg.ProgramPreset(0, 3, presets); ok {
+		t.Error("expected program past the last preset to load nothing")
+	}
+	cfg.ProgramChangeOrder = []string{"wash", "clean"}
+	if name, ok := cfg.ProgramPreset(0, 0, presets); !ok || name != "wash" {
+		t.Errorf("expected program 0 to follow the configured order, got %q", name)
+	}
+
+	cfg = DefaultConfig()
+	cfg.ProgramChanges = map[string]string{"128": "clean"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for program out of range")
+	}
+	cfg.ProgramChanges = map[string]string{"x:1": "clean"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for malformed bank")
+	}
+	cfg.ProgramChanges = nil
+	cfg.ProgramChangeMode = "random"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown program change mode")
+	}
+}
+
+func TestConfigDevices(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "midi.toml")
+
+	data := `[devices.faders]
+input_port = "nanoKONTROL2"
+[devices.faders.cc]
+dry_wet = 0
+
+[devices.pads]
+input_port = "MPD218"
+[devices.pads.notes]
+input_frozen = 36
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadPath(configPath)
+	if err != nil {
+		t.Fatalf("expected devices config to load, got %v", err)
+	}
+	profiles := cfg.Profiles()
+	if names := ProfileNames(profiles); len(names) != 2 || names[0] != "faders" || names[1] != "pads" {
+		t.Fatalf("expected faders and pads profiles, got %v", names)
+	}
+	if profiles["faders"].CC["dry_wet"] != 0 || len(profiles["faders"].Notes) != 0 {
+		t.Errorf("expected faders to only have its own mappings, got %+v", profiles["faders"])
+	}
+
+	// Without devices the config is the only profile
+	if profiles := DefaultConfig().Profiles(); len(profiles) != 1 || profiles[""].CC["gain"] != 1 {
+		t.Errorf("expected a single default profile, got %v", profiles)
+	}
+
+	cfg.Devices["pads"] = Config{InputPort: "nanoKONTROL2"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for two devices on one port")
+	}
+	cfg.Devices["pads"] = Config{}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for device without input_port")
+	}
+	cfg.Devices["pads"] = Config{InputPort: "MPD218", CC: map[string]int{"volume": 1}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown name in a device profile")
+	}
+	cfg.Devices["pads"] = Config{InputPort: "MPD218"}
+
+	// Top-level settings are not read once devices are set
+	for key, set := range map[string]func(*Config){
+		"takeover":            func(c *Config) { c.Takeover = TakeoverPickup },
+		"options":             func(c *Config) { c.Options = map[string]MappingOptions{"dry_wet": {Curve: CurveExponential}} },
+		"feedback_port":       func(c *Config) { c.FeedbackPort = "nanoKONTROL2" },
+		"program_changes":     func(c *Config) { c.ProgramChanges = map[string]string{"0": "intro"} },
+		"program_change_mode": func(c *Config) { c.ProgramChangeMode = ProgramChangeIndex },
+	} {
+		withSetting := cfg
+		set(&withSetting)
+		if err := withSetting.Validate(); err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("expected top-level %s to be rejected with devices, got %v", key, err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected devices config to stay valid, got %v", err)
+	}
+}
+
+func TestLoadPathRejectsUnknownNames(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "midi.toml")
+
+	data := "[cc]\nreverb_time = 10\n"
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := LoadPath(configPath); err == nil {
+		t.Error("expected error loading config with unknown parameter name")
+	}
+}
+
+func TestLoad_ReportsErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	configPath, err := ConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The keys the first default config wrote still load
+	data := "[cc]\ngain = 1\nreverb_delay_blend = 9\ndecay_time = 10\n"
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected the original default keys to load, got %v", err)
+	}
+	if conflicts := cfg.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("expected the original keys to match the defaults, got conflicts %v", conflicts)
+	}
+
+	if err := os.WriteFile(configPath, []byte("[cc]\nreverb_time = 10\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "reverb_time") {
+		t.Errorf("expected an error naming the unknown key, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// Curves shape a control's travel before it is scaled to the parameter.
+const (
+	CurveLinear      = "linear"
+	CurveExponential = "exponential" // Fine control at the low end
+	CurveLogarithmic = "logarithmic" // Fine control at the high end
+	CurveS           = "s-curve"     // Fine control at both ends
+)
+
+// Curves lists the curves in display order.
+var Curves = []string{CurveLinear, CurveExponential, CurveLogarithmic, CurveS}
+
+// ApplyCurve shapes a normalized 0-1 control value. Unknown curves are
+// linear.
+func ApplyCurve(curve string, v float32) float32 {
+	v = clamp01(v)
+	switch curve {
+	case CurveExponential:
+		return v * v
+	case CurveLogarithmic:
+		return float32(math.Sqrt(float64(v)))
+	case CurveS:
+		return v * v * (3 - 2*v)
+	}
+	return v
+}
+
+// InvertCurve is the inverse of ApplyCurve.
+func InvertCurve(curve string, v float32) float32 {
+	v = clamp01(v)
+	switch curve {
+	case CurveExponential:
+		return float32(math.Sqrt(float64(v)))
+	case CurveLogarithmic:
+		return v * v
+	case CurveS:
+		return float32(0.5 - math.Sin(math.Asin(1-2*float64(v))/3))
+	}
+	return v
+}
+
+// Span returns the normalized parameter values a control's travel covers,
+// from the option's range or the whole parameter. lo is above hi for an
+// inverted range.
+func (o MappingOptions) Span(p Param) (lo, hi float32) {
+	if len(o.Range) != 2 {
+		return 0, 1
+	}
+	return p.Normalize(o.Range[0]), p.Normalize(o.Range[1])
+}
+
+// Shape converts a normalized control value to a normalized parameter value
+// through the option's curve and range.
+func (o MappingOptions) Shape(p Param, v float32) float32 {
+	lo, hi := o.Span(p)
+	return lo + ApplyCurve(o.Curve, v)*(hi-lo)
+}
+
+// Unshape is the inverse of Shape, used to send a parameter value back to the
+// control as feedback. Values outside the range are clamped.
+func (o MappingOptions) Unshape(p Param, value float32) float32 {
+	lo, hi := o.Span(p)
+	if lo == hi {
+		return 0
+	}
+	return InvertCurve(o.Curve, (value-lo)/(hi-lo))
+}
+
+// validateShape checks a parameter's curve and range options.
+func validateShape(p Param, opts MappingOptions) error {
+	switch opts.Curve {
+	case "", CurveLinear, CurveExponential, CurveLogarithmic, CurveS:
+	default:
+		return fmt.Errorf("unknown curve %q", opts.Curve)
+	}
+	if opts.Range == nil {
+		return nil
+	}
+	if p.Kind != ParamContinuous {
+		return fmt.Errorf("range needs a continuous parameter")
+	}
+	if len(opts.Range) != 2 {
+		return fmt.Errorf("range needs a low and high value")
+	}
+	for _, v := range opts.Range {
+		if v < p.Min || v > p.Max {
+			return fmt.Errorf("range value %g out of range %g-%g", v, p.Min, p.Max)
+		}
+	}
+	return nil
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
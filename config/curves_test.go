@@ -0,0 +1,74 @@
+package config
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCurvesInvert(t *testing.T) {
+	for _, curve := range []string{CurveLinear, CurveExponential, CurveLogarithmic, CurveS} {
+		for _, v := range []float32{0, 0.1, 0.25, 0.5, 0.9, 1} {
+			got := InvertCurve(curve, ApplyCurve(curve, v))
+			if math.Abs(float64(got-v)) > 1e-5 {
+				t.Errorf("%s: expected %f back, got %f", curve, v, got)
+			}
+		}
+	}
+	if ApplyCurve(CurveExponential, 0.5) >= 0.5 || ApplyCurve(CurveLogarithmic, 0.5) <= 0.5 {
+		t.Error("expected exponential below and logarithmic above linear at the midpoint")
+	}
+}
+
+func TestMappingOptionsShape(t *testing.T) {
+	gain, _ := LookupParam("gain")
+	opts := MappingOptions{Range: []float32{0.5, 1.5}}
+	if got := opts.Shape(gain, 0.5); got != 0.5 {
+		t.Errorf("expected midpoint of 0.5-1.5 to be gain 1.0 (0.5 normalized), got %f", got)
+	}
+	if got := opts.Shape(gain, 0); got != 0.25 {
+		t.Errorf("expected bottom of range at 0.25 normalized, got %f", got)
+	}
+	if got := opts.Unshape(gain, 0.75); got != 1 {
+		t.Errorf("expected top of range to unshape to 1, got %f", got)
+	}
+	if got := opts.Unshape(gain, 0); got != 0 {
+		t.Errorf("expected values below the range to clamp, got %f", got)
+	}
+
+	// A high-to-low range inverts the control
+	inverted := MappingOptions{Range: []float32{2, 0}}
+	if got := inverted.Shape(gain, 0.25); got != 0.75 {
+		t.Errorf("expected inverted range, got %f", got)
+	}
+}
+
+func TestConfigValidateShape(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Options = map[string]MappingOptions{"gain": {Curve: CurveS, Range: []float32{0, 1}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected curve and range to be valid, got %v", err)
+	}
+
+	for name, opts := range map[string]MappingOptions{
+		"gain":         {Curve: "cubic"},
+		"dry_wet":      {Range: []float32{0, 2}},
+		"reverb_mix":   {Range: []float32{0.5}},
+		"input_frozen": {Range: []float32{0, 1}},
+	} {
+		cfg.Options = map[string]MappingOptions{name: opts}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for %s options %+v", name, opts)
+		}
+	}
+
+	cfg = DefaultConfig()
+	cfg.PitchBend = []string{"pitch_scatter"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown pitch bend parameter")
+	}
+	cfg = DefaultConfig()
+	cfg.PolyPressure = map[string]int{"granular_mix": 128}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for out of range poly pressure note")
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PresetChange is one value that differs between two presets.
+type PresetChange struct {
+	Group string // "master", "effects order" or an effect name
+	Key   string // Preset key, see Params
+	Old   string
+	New   string
+}
+
+// DiffGroupOrder lists the groups of PresetChange in display order.
+var DiffGroupOrder = append([]string{"master", "effects order"}, Effects...)
+
+// DiffPresets compares two presets key by key and returns the values that
+// differ, grouped by effect in DiffGroupOrder and in preset key order within
+// a group. Metadata and the schema version are not compared.
+func DiffPresets(a, b Preset) []PresetChange {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+	byGroup := make(map[string][]PresetChange)
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if key == "" || key == "-" || key == "schema_version" || key == "meta" {
+			continue
+		}
+		from, to := formatPresetValue(key, va.Field(i)), formatPresetValue(key, vb.Field(i))
+		if from == to {
+			continue
+		}
+		group := presetKeyGroup(key)
+		byGroup[group] = append(byGroup[group], PresetChange{Group: group, Key: key, Old: from, New: to})
+	}
+
+	var changes []PresetChange
+	for _, group := range DiffGroupOrder {
+		changes = append(changes, byGroup[group]...)
+	}
+	return changes
+}
+
+// presetKeyGroup returns the effect a preset key belongs to.
+func presetKeyGroup(key string) string {
+	switch {
+	case key == "effects_order":
+		return "effects order"
+	case strings.HasPrefix(key, "bit"):
+		return "bitcrush"
+	case strings.HasPrefix(key, "grain"):
+		return "granular"
+	case strings.HasPrefix(key, "mod_"):
+		return "delay"
+	}
+	for _, effect := range Effects {
+		if strings.HasPrefix(key, effect+"_") {
+			return effect
+		}
+	}
+	return "master"
+}
+
+// formatPresetValue formats a preset value for a diff, with enum options by
+// name.
+func formatPresetValue(key string, v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32)
+	case reflect.Int:
+		if p, ok := LookupParam(key); ok && p.Kind == ParamEnum && int(v.Int()) >= 0 && int(v.Int()) < len(p.Options) {
+			return p.Options[v.Int()]
+		}
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		if v.Bool() {
+			return "on"
+		}
+		return "off"
+	case reflect.String:
+		if v.String() == "" {
+			return "none"
+		}
+		return v.String()
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return "none"
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// FormatPresetDiff formats changes as text, a heading per group and a line
+// per change.
+func FormatPresetDiff(changes []PresetChange) string {
+	var b strings.Builder
+	group := ""
+	for _, c := range changes {
+		if c.Group != group {
+			group = c.Group
+			fmt.Fprintf(&b, "%s\n", group)
+		}
+		fmt.Fprintf(&b, "  %s: %s → %s\n", c.Key, c.Old, c.New)
+	}
+	return b.String()
+}
+
+// ReadPreset decodes a saved preset without changing anything on disk. An
+// older file is upgraded in memory only, so it can be compared or inspected
+// before it is loaded.
+func ReadPreset(name string) (Preset, error) {
+	if !validPresetName(name) {
+		return DefaultPreset(), fmt.Errorf("invalid preset name")
+	}
+	path, err := presetPath(name)
+	if err != nil {
+		return DefaultPreset(), err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return DefaultPreset(), fmt.Errorf("preset '%s' not found", name)
+	}
+	preset, err := ReadPresetFile(path)
+	if err != nil {
+		return DefaultPreset(), fmt.Errorf("preset '%s': %w", name, err)
+	}
+	preset.Name = name
+	return preset, nil
+}
+
+// ReadPresetFile decodes a preset file at any path, like ReadPreset.
+func ReadPresetFile(path string) (Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DefaultPreset(), err
+	}
+	preset, _, changes, err := decodePreset(data)
+	preset.Migrated = changes
+	return preset, err
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffPresets(t *testing.T) {
+	a := DefaultPreset()
+	b := DefaultPreset()
+	b.Gain = 1.5
+	b.BlendMode = 2
+	b.DelayEnabled = true
+	b.ModRate = 3
+	b.BitDepth = 8
+	b.GrainIntensity = "extreme"
+	b.EffectsOrder = []string{"delay", "filter", "overdrive", "bitcrush", "granular", "reverb"}
+	b.Meta.Description = "not a sound change"
+
+	want := "" +
+		"master\n" +
+		"  gain: 0 → 1.5\n" +
+		"  blend_mode: mirror → transform\n" +
+		"effects order\n" +
+		"  effects_order: filter, overdrive, bitcrush, granular, reverb, delay → delay, filter, overdrive, bitcrush, granular, reverb\n" +
+		"bitcrush\n" +
+		"  bit_depth: 4 → 8\n" +
+		"granular\n" +
+		"  grain_intensity: subtle → extreme\n" +
+		"delay\n" +
+		"  delay_enabled: off → on\n" +
+		"  mod_rate: 0.1 → 3\n"
+	if got := FormatPresetDiff(DiffPresets(a, b)); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if changes := DiffPresets(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes comparing a preset with itself, got %v", changes)
+	}
+}
+
+func TestReadPreset_LeavesFileAlone(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	dir := filepath.Join(home, "chroma-control", "presets")
+	os.MkdirAll(dir, 0755)
+	old := []byte("gain = 1.5\ninput_freeze_length = 0.25\n")
+	os.WriteFile(filepath.Join(dir, "old.toml"), old, 0644)
+
+	p, err := ReadPreset("old")
+	if err != nil || p.InputFreezeLength != 0.25 || len(p.Migrated) == 0 {
+		t.Fatalf("expected the preset upgraded in memory, got %+v (%v)", p, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "old.toml")); string(data) != string(old) {
+		t.Errorf("expected the file untouched, got:\n%s", data)
+	}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Presets can be organized into folders, or banks, under the presets
+// directory. A preset in a folder is addressed by its path with forward
+// slashes, such as "show1/intro" or "show1/act2/storm".
+
+// presetFolderPath returns the directory of a preset folder, the presets
+// directory itself for "".
+func presetFolderPath(folder string) (string, error) {
+	dir, err := presetsDir()
+	if err != nil {
+		return "", err
+	}
+	if folder == "" {
+		return dir, nil
+	}
+	if !validPresetName(folder) {
+		return "", fmt.Errorf("invalid folder name %q", folder)
+	}
+	return filepath.Join(dir, filepath.FromSlash(folder)), nil
+}
+
+// ListPresetFolder returns the folders and presets directly inside a preset
+// folder, "" for the top level, sorted by name. Both are returned as full
+// paths, ready to load or list in turn.
+func ListPresetFolder(folder string) (folders, presets []string, err error) {
+	dir, err := presetFolderPath(folder)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) && folder == "" {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		// Skip internal files starting with _, and hidden ones
+		if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			folders = append(folders, path.Join(folder, name))
+		} else if strings.HasSuffix(name, ".toml") {
+			presets = append(presets, path.Join(folder, strings.TrimSuffix(name, ".toml")))
+		}
+	}
+	sort.Strings(folders)
+	sort.Strings(presets)
+	return folders, presets, nil
+}
+
+// CreatePresetFolder creates a preset folder, along with any parents.
+func CreatePresetFolder(folder string) error {
+	dir, err := presetFolderPath(folder)
+	if err != nil {
+		return err
+	}
+	if folder == "" {
+		return fmt.Errorf("folder name cannot be empty")
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("folder '%s' already exists", folder)
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// MovePresetFolder moves or renames a preset folder with everything in it.
+func MovePresetFolder(from, to string) error {
+	if from == "" || to == "" {
+		return fmt.Errorf("folder name cannot be empty")
+	}
+	if to == from || strings.HasPrefix(to, from+"/") {
+		return fmt.Errorf("cannot move '%s' into itself", from)
+	}
+	src, err := presetFolderPath(from)
+	if err != nil {
+		return err
+	}
+	dst, err := presetFolderPath(to)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return fmt.Errorf("folder '%s' not found", from)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("'%s' already exists", to)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// DeletePresetFolder deletes a preset folder that holds no presets or
+// folders. Backups left behind by deleted presets go with it.
+func DeletePresetFolder(folder string) error {
+	if folder == "" {
+		return fmt.Errorf("folder name cannot be empty")
+	}
+	dir, err := presetFolderPath(folder)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("folder '%s' not found", folder)
+	}
+	folders, presets, err := ListPresetFolder(folder)
+	if err != nil {
+		return err
+	}
+	if len(folders)+len(presets) > 0 {
+		return fmt.Errorf("folder '%s' is not empty", folder)
+	}
+	return os.RemoveAll(dir)
+}
+
+// MovePreset moves or renames a preset, taking its backups with it.
+func MovePreset(from, to string) error {
+	if !validPresetName(from) || !validPresetName(to) {
+		return fmt.Errorf("invalid preset name")
+	}
+	src, err := presetPath(from)
+	if err != nil {
+		return err
+	}
+	dst, err := presetPath(to)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("preset '%s' not found", from)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("preset '%s' already exists", to)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	// Backups and pre-upgrade copies share the preset file's name
+	entries, err := os.ReadDir(filepath.Dir(src))
+	if err != nil {
+		return err
+	}
+	prefix := filepath.Base(src) + "."
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		suffix := strings.TrimPrefix(entry.Name(), filepath.Base(src))
+		if err := os.Rename(src+suffix, dst+suffix); err != nil {
+			return fmt.Errorf("moving backup: %w", err)
+		}
+	}
+	return nil
+}
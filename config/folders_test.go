@@ -0,0 +1,97 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPresetFolders(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{"wash", "show1/intro", "show1/act2/storm"} {
+		if err := SavePreset(presetWithGain(0.5), name); err != nil {
+			t.Fatalf("save %s: %v", name, err)
+		}
+	}
+	if err := CreatePresetFolder("empty"); err != nil {
+		t.Fatal(err)
+	}
+
+	names, _ := ListPresets()
+	if want := []string{"show1/act2/storm", "show1/intro", "wash"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+	folders, presets, err := ListPresetFolder("show1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(folders, []string{"show1/act2"}) || !reflect.DeepEqual(presets, []string{"show1/intro"}) {
+		t.Errorf("unexpected listing of show1: %v %v", folders, presets)
+	}
+	if p, err := LoadPreset("show1/act2/storm"); err != nil || p.Name != "show1/act2/storm" {
+		t.Errorf("expected the nested preset to load, got %q (%v)", p.Name, err)
+	}
+}
+
+func TestPresetFolders_InvalidNames(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{"../escape", "a//b", "show/", "/abs", "show/_hidden", "show/.x", "a:b"} {
+		if err := SavePreset(DefaultPreset(), name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestMovePreset_TakesBackups(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	SavePreset(presetWithGain(0.1), "wash")
+	SavePreset(presetWithGain(0.2), "wash")
+
+	if err := MovePreset("wash", "show1/wash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPreset("wash"); err == nil {
+		t.Error("expected the old name to be gone")
+	}
+	if backups, _ := PresetBackups("show1/wash"); len(backups) != 1 {
+		t.Errorf("expected the backup to move along, got %v", backups)
+	}
+	SavePreset(DefaultPreset(), "other")
+	if err := MovePreset("other", "show1/wash"); err == nil {
+		t.Error("expected moving onto an existing preset to fail")
+	}
+
+	// Names with glob characters take their backups too
+	SavePreset(presetWithGain(0.1), "pad [live]")
+	SavePreset(presetWithGain(0.2), "pad [live]")
+	if err := MovePreset("pad [live]", "show1/pad [live]"); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := PresetBackups("show1/pad [live]"); len(backups) != 1 {
+		t.Errorf("expected the backup of a bracketed name to move along, got %v", backups)
+	}
+}
+
+func TestMovePresetFolder(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	SavePreset(DefaultPreset(), "show1/intro")
+
+	if err := MovePresetFolder("show1", "show1/inner"); err == nil {
+		t.Error("expected moving a folder into itself to fail")
+	}
+	if err := MovePresetFolder("show1", "tours/show1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPreset("tours/show1/intro"); err != nil {
+		t.Errorf("expected the preset to move with its folder: %v", err)
+	}
+	if err := DeletePresetFolder("tours"); err == nil {
+		t.Error("expected deleting a non-empty folder to fail")
+	}
+	DeletePreset("tours/show1/intro")
+	if err := DeletePresetFolder("tours/show1"); err != nil {
+		t.Errorf("expected a folder holding only backups to be deletable: %v", err)
+	}
+	if folders, _, _ := ListPresetFolder("tours"); len(folders) != 0 {
+		t.Errorf("expected the folder gone, got %v", folders)
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// PresetMeta describes a preset without affecting its sound. It is saved as
+// the [meta] table of a preset file and left out of Preset.Hash, so editing
+// it doesn't mark a preset dirty.
+type PresetMeta struct {
+	Description string    `toml:"description,omitempty"`
+	Tags        []string  `toml:"tags,omitempty"`
+	Author      string    `toml:"author,omitempty"`
+	Created     time.Time `toml:"created,omitempty"`
+	Modified    time.Time `toml:"modified,omitempty"`
+	Notes       string    `toml:"notes,omitempty"`
+}
+
+// HasTag reports whether the preset is tagged tag, ignoring case.
+func (m PresetMeta) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags splits a comma-separated list of tags, dropping blanks and
+// duplicates.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !(PresetMeta{Tags: tags}).HasTag(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// LoadPresetMeta reads only the metadata of a preset, for listing many
+// presets without loading or upgrading them.
+func LoadPresetMeta(name string) (PresetMeta, error) {
+	path, err := presetPath(name)
+	if err != nil {
+		return PresetMeta{}, err
+	}
+	var file struct {
+		Meta PresetMeta `toml:"meta"`
+	}
+	_, err = toml.DecodeFile(path, &file)
+	return file.Meta, err
+}
+
+// UpdatePresetMeta changes the metadata of a saved preset in place, leaving
+// its values alone. The values are unchanged, so unlike SavePreset no
+// backup is kept.
+func UpdatePresetMeta(name string, update func(*PresetMeta)) (PresetMeta, error) {
+	preset, err := LoadPreset(name)
+	if err != nil {
+		return PresetMeta{}, err
+	}
+	path, err := presetPath(name)
+	if err != nil {
+		return PresetMeta{}, err
+	}
+	update(&preset.Meta)
+	if err := stampPresetMeta(path, &preset); err != nil {
+		return PresetMeta{}, err
+	}
+	if err := writePreset(path, preset); err != nil {
+		return PresetMeta{}, err
+	}
+	return LoadPresetMeta(name)
+}
+
+// stampPresetMeta sets the created and modified times of a preset about to
+// be saved to path. Created carries over from the file being replaced, and
+// modified only moves when the saved contents change, so saving an
+// unchanged preset stays a no-op.
+func stampPresetMeta(path string, preset *Preset) error {
+	var previous Preset
+	data, err := os.ReadFile(path)
+	if err == nil {
+		_, err = toml.Decode(string(data), &previous)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if preset.Meta.Created.IsZero() {
+		preset.Meta.Created = previous.Meta.Created
+		if preset.Meta.Created.IsZero() {
+			preset.Meta.Created = now
+		}
+	}
+	preset.Meta.Modified = previous.Meta.Modified
+	if encoded, err := encodePreset(*preset); err == nil && string(encoded) == string(data) {
+		return nil
+	}
+	preset.Meta.Modified = now
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresetHash_IgnoresMeta(t *testing.T) {
+	p := DefaultPreset()
+	before := p.Hash()
+	p.Meta = PresetMeta{Description: "Warm pad", Tags: []string{"ambient"}, Created: time.Now()}
+	if p.Hash() != before {
+		t.Error("expected metadata not to change the hash")
+	}
+	p.Gain = 0.5
+	if p.Hash() == before {
+		t.Error("expected values to change the hash")
+	}
+}
+
+func TestSavePreset_Meta(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	p := DefaultPreset()
+	if err := SavePreset(p, "plain"); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := presetPath("plain")
+	saved, _ := LoadPreset("plain")
+	if saved.Meta.Created.IsZero() || !saved.Meta.Modified.Equal(saved.Meta.Created) {
+		t.Fatalf("expected created and modified stamped, got %+v", saved.Meta)
+	}
+
+	// Saving again unchanged neither moves modified nor makes a backup
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	saved.Meta.Created, saved.Meta.Modified = old, old
+	SavePreset(saved, "plain")
+	SavePreset(saved, "plain")
+	if backups, _ := PresetBackups("plain"); len(backups) != 1 {
+		t.Errorf("expected one backup, got %d", len(backups))
+	}
+
+	meta, err := UpdatePresetMeta("plain", func(m *PresetMeta) {
+		m.Description = "Clean DI"
+		m.Tags = ParseTags("live, clean,,Live")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Description != "Clean DI" || !reflect.DeepEqual(meta.Tags, []string{"live", "clean"}) {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+	if !meta.Created.Equal(old) || !meta.Modified.After(old) {
+		t.Errorf("expected created kept and modified moved, got %v %v", meta.Created, meta.Modified)
+	}
+	// Only the values are worth a backup
+	if backups, _ := PresetBackups("plain"); len(backups) != 1 {
+		t.Errorf("expected a metadata edit not to add a backup, got %d", len(backups))
+	}
+	if !meta.HasTag("LIVE") || meta.HasTag("pad") {
+		t.Error("expected case-insensitive tag matching")
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "[meta]") || !strings.Contains(string(data), `description = "Clean DI"`) {
+		t.Errorf("expected a meta table in the file:\n%s", data)
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// PresetSchemaVersion is the schema version presets are saved with. Files
+// without a schema_version key are version 0.
+const PresetSchemaVersion = 1
+
+// PresetMigration upgrades a preset file, decoded as raw TOML, to version To
+// from the version before it. It returns a description of each change made.
+type PresetMigration struct {
+	To          int
+	Description string
+	Migrate     func(data map[string]any) []string
+}
+
+// presetMigrations upgrade preset files one version at a time, in version
+// order. Add an entry here, and bump PresetSchemaVersion, whenever a preset
+// key is renamed or its meaning changes. Version 1 only added
+// schema_version: version 0 files use the same keys.
+var presetMigrations = []PresetMigration{
+	{To: 1, Description: "add schema_version", Migrate: func(map[string]any) []string { return nil }},
+}
+
+// MigratePreset upgrades a decoded preset file to PresetSchemaVersion in
+// place. It returns the version the file had and the changes made, which are
+// empty when it was already current.
+func MigratePreset(data map[string]any) (from int, changes []string, err error) {
+	if v, ok := data["schema_version"]; ok {
+		n, ok := v.(int64)
+		if !ok || n < 0 {
+			return 0, nil, fmt.Errorf("invalid schema_version %v", v)
+		}
+		from = int(n)
+	}
+	if from > PresetSchemaVersion {
+		return from, nil, fmt.Errorf("schema version %d is newer than this release supports (%d)", from, PresetSchemaVersion)
+	}
+	for _, m := range presetMigrations {
+		if m.To <= from {
+			continue
+		}
+		for _, change := range m.Migrate(data) {
+			changes = append(changes, fmt.Sprintf("v%d: %s", m.To, change))
+		}
+	}
+	if from < PresetSchemaVersion {
+		data["schema_version"] = int64(PresetSchemaVersion)
+		changes = append(changes, fmt.Sprintf("upgraded from schema version %d to %d", from, PresetSchemaVersion))
+	}
+	return from, changes, nil
+}
+
+// decodePreset decodes the contents of a preset file, upgrading it if it
+// uses an older schema and checking its values, see validatePresetValues.
+// It returns the version the file had and the changes made upgrading it;
+// problems found checking it are in the preset's Warnings.
+func decodePreset(original []byte) (preset Preset, from int, changes []string, err error) {
+	data := make(map[string]any)
+	if _, err := toml.Decode(string(original), &data); err != nil {
+		return DefaultPreset(), 0, nil, err
+	}
+	from, changes, err = MigratePreset(data)
+	if err != nil {
+		return DefaultPreset(), from, nil, err
+	}
+
+	// Values are checked once upgraded, and decoded over the defaults so
+	// missing keys take their default rather than zero
+	var warnings []string
+	if missing := missingPresetKeys(data); len(missing) > 0 {
+		warnings = append(warnings, "missing "+strings.Join(missing, ", ")+", using defaults")
+	}
+	warnings = append(validatePresetValues(data), warnings...)
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(data); err != nil {
+		return DefaultPreset(), from, nil, err
+	}
+	preset = DefaultPreset()
+	if _, err := toml.Decode(buf.String(), &preset); err != nil {
+		return DefaultPreset(), from, nil, err
+	}
+	preset.Warnings = warnings
+	return preset, from, changes, nil
+}
+
+// decodePresetFile reads a preset file, upgrading it if it uses an older
+// schema. An upgraded file is rewritten after the original is copied to a
+// backup named after its old version, and the changes are returned.
+func decodePresetFile(path string) (Preset, []string, error) {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return DefaultPreset(), nil, err
+	}
+	preset, from, changes, err := decodePreset(original)
+	if err != nil || len(changes) == 0 {
+		return preset, nil, err
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := writeFileAtomic(backup, original, 0644); err != nil {
+		return DefaultPreset(), nil, fmt.Errorf("backing up before upgrade: %w", err)
+	}
+	if err := writePreset(path, preset); err != nil {
+		return DefaultPreset(), nil, err
+	}
+	changes = append(changes, "original saved as "+filepath.Base(backup))
+	return preset, changes, nil
+}
+
+// presetKeys returns the TOML keys of a preset file.
+func presetKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Preset{})
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if key != "" && key != "-" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+// baselinePreset is a preset as saved before schema versions, by the first
+// release.
+const baselinePreset = `master_enabled = false
+gain = 1.5
+input_frozen = false
+input_freeze_length = 0.25
+dry_wet = 0.0
+blend_mode = 0
+effects_order = ["filter", "overdrive", "bitcrush", "granular", "reverb", "delay"]
+filter_enabled = false
+filter_amount = 0.0
+filter_cutoff = 200.0
+filter_resonance = 0.0
+overdrive_enabled = false
+overdrive_drive = 0.0
+overdrive_tone = 0.0
+overdrive_bias = 0.0
+overdrive_mix = 0.0
+bitcrush_enabled = false
+bit_depth = 4.0
+bitcrush_sample_rate = 1000.0
+bitcrush_drive = 0.0
+bitcrush_mix = 0.0
+granular_enabled = false
+granular_density = 1.0
+granular_size = 0.01
+granular_pitch_scatter = 0.0
+granular_pos_scatter = 0.0
+granular_mix = 0.0
+granular_frozen = false
+grain_intensity = "subtle"
+reverb_enabled = false
+reverb_decay_time = 0.5
+reverb_mix = 0.0
+delay_enabled = false
+delay_time = 0.1
+delay_decay_time = 0.1
+mod_rate = 0.1
+mod_depth = 0.0
+delay_mix = 0.0
+`
+
+func TestMigratePreset_AddsVersion(t *testing.T) {
+	data := map[string]any{"gain": 1.0, "input_freeze_length": 0.2}
+	from, changes, err := MigratePreset(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 0 {
+		t.Errorf("expected an unversioned file to be version 0, got %d", from)
+	}
+	if len(data) != 3 || data["gain"] != 1.0 || data["input_freeze_length"] != 0.2 {
+		t.Errorf("expected the values left alone, got %v", data)
+	}
+	if data["schema_version"] != int64(PresetSchemaVersion) {
+		t.Errorf("expected schema_version %d, got %v", PresetSchemaVersion, data["schema_version"])
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0], "upgraded from schema version 0") {
+		t.Errorf("expected only the upgrade, got %q", changes)
+	}
+}
+
+func TestDecodePreset_BaselineRoundTrips(t *testing.T) {
+	preset, from, _, err := decodePreset([]byte(baselinePreset))
+	if err != nil || from != 0 {
+		t.Fatalf("expected a version 0 preset to load, got version %d (%v)", from, err)
+	}
+	if len(preset.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %q", preset.Warnings)
+	}
+
+	// Every value in the file loads as saved
+	var want map[string]any
+	if _, err := toml.Decode(baselinePreset, &want); err != nil {
+		t.Fatal(err)
+	}
+	data, err := encodePreset(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if _, err := toml.Decode(string(data), &got); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range want {
+		if !reflect.DeepEqual(got[key], value) {
+			t.Errorf("%s: expected %v, got %v", key, value, got[key])
+		}
+	}
+}
+
+func TestMigratePreset_Versions(t *testing.T) {
+	current := map[string]any{"schema_version": int64(PresetSchemaVersion), "input_freeze": true}
+	if _, changes, err := MigratePreset(current); err != nil || len(changes) != 0 {
+		t.Errorf("expected a current file to be left alone, got %q (%v)", changes, err)
+	}
+	if _, ok := current["input_freeze"]; !ok {
+		t.Error("expected migrations not to run on a current file")
+	}
+
+	newer := map[string]any{"schema_version": int64(PresetSchemaVersion + 1)}
+	if _, _, err := MigratePreset(newer); err == nil {
+		t.Error("expected an error for a newer schema version")
+	}
+	if _, _, err := MigratePreset(map[string]any{"schema_version": "one"}); err == nil {
+		t.Error("expected an error for an invalid schema version")
+	}
+}
+
+func TestPresetMigrations_Ordered(t *testing.T) {
+	for i, m := range presetMigrations {
+		if m.To != i+1 {
+			t.Errorf("migration %d upgrades to version %d, want %d", i, m.To, i+1)
+		}
+	}
+	if last := presetMigrations[len(presetMigrations)-1].To; last != PresetSchemaVersion {
+		t.Errorf("expected the last migration to reach version %d, got %d", PresetSchemaVersion, last)
+	}
+}
+
+func TestLoadPreset_UpgradesOldFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir, err := presetsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(dir, 0755)
+	path := filepath.Join(dir, "old.toml")
+	original := "gain = 1.5\ninput_freeze_length = 0.25\n"
+	os.WriteFile(path, []byte(original), 0644)
+
+	preset, err := LoadPreset("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preset.Gain != 1.5 || preset.InputFreezeLength != 0.25 || preset.SchemaVersion != PresetSchemaVersion {
+		t.Errorf("expected the upgraded values, got %+v", preset)
+	}
+	if len(preset.Migrated) == 0 || !strings.Contains(preset.Migrated[len(preset.Migrated)-1], "old.toml.v0.bak") {
+		t.Errorf("expected the report to name the backup, got %q", preset.Migrated)
+	}
+	if backup, err := os.ReadFile(path + ".v0.bak"); err != nil || string(backup) != original {
+		t.Errorf("expected the original backed up, got %q (%v)", backup, err)
+	}
+
+	// The file is upgraded once
+	again, err := LoadPreset("old")
+	if err != nil || len(again.Migrated) != 0 || again.InputFreezeLength != 0.25 {
+		t.Errorf("expected the rewritten file to load without changes, got %+v (%v)", again, err)
+	}
+	if names, _ := ListPresets(); len(names) != 1 {
+		t.Errorf("expected the backup not to be listed, got %v", names)
+	}
+}
+
+func TestLoadPreset_RejectsNewerSchema(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir, _ := presetsDir()
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "future.toml"), []byte("schema_version = 99\n"), 0644)
+	if _, err := LoadPreset("future"); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected a newer schema error, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"strings"
+)
+
+// DefaultMorphThreshold is the morph position at which values that can't be
+// interpolated switch to the second preset.
+const DefaultMorphThreshold = 0.5
+
+// MorphPresets crossfades from preset a to preset b, t from 0 (a) to 1 (b).
+// Continuous values are interpolated, logarithmic ones geometrically so the
+// sweep sounds even. Toggles, enums, tempo sync and the effects order can
+// only switch, which they do once t reaches threshold. The result has no
+// name or metadata.
+func MorphPresets(a, b Preset, t, threshold float32) Preset {
+	t = clampUnit(t)
+	switchToB := t >= threshold
+
+	out := DefaultPreset()
+	va, vb, vo := reflect.ValueOf(a), reflect.ValueOf(b), reflect.ValueOf(&out).Elem()
+	typ := va.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		key, _, _ := strings.Cut(typ.Field(i).Tag.Get("toml"), ",")
+		if key == "" || key == "-" || key == "schema_version" || key == "meta" {
+			continue
+		}
+		fa, fb := va.Field(i), vb.Field(i)
+		if fa.Kind() == reflect.Float32 {
+			vo.Field(i).SetFloat(float64(morphValue(key, float32(fa.Float()), float32(fb.Float()), t)))
+			continue
+		}
+		from := fa
+		if switchToB {
+			from = fb
+		}
+		if from.Kind() == reflect.Slice {
+			// Don't share the order with either preset
+			vo.Field(i).Set(reflect.AppendSlice(reflect.MakeSlice(from.Type(), 0, from.Len()), from))
+		} else {
+			vo.Field(i).Set(from)
+		}
+	}
+	return out
+}
+
+// morphValue interpolates one continuous value.
+func morphValue(key string, a, b, t float32) float32 {
+	if p, ok := LookupParam(key); ok && p.Log && a > 0 && b > 0 {
+		return float32(float64(a) * math.Pow(float64(b)/float64(a), float64(t)))
+	}
+	return a + (b-a)*t
+}
+
+func clampUnit(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMorphPresets(t *testing.T) {
+	a := DefaultPreset()
+	a.Gain = 0.5
+	a.GranularDensity = 1
+	a.ReverbEnabled = false
+	a.GrainIntensity = "subtle"
+	b := DefaultPreset()
+	b.Gain = 1.5
+	b.GranularDensity = 50
+	b.ReverbEnabled = true
+	b.GrainIntensity = "extreme"
+	b.EffectsOrder = []string{"delay", "reverb", "granular", "bitcrush", "overdrive", "filter"}
+
+	mid := MorphPresets(a, b, 0.25, 0.5)
+	if mid.Gain != 0.75 {
+		t.Errorf("expected gain interpolated to 0.75, got %v", mid.Gain)
+	}
+	// Logarithmic parameters sweep geometrically: 1 * 50^0.25
+	if want := float32(math.Pow(50, 0.25)); math.Abs(float64(mid.GranularDensity-want)) > 1e-4 {
+		t.Errorf("expected density %v, got %v", want, mid.GranularDensity)
+	}
+	if mid.ReverbEnabled || mid.GrainIntensity != "subtle" || !reflect.DeepEqual(mid.EffectsOrder, a.EffectsOrder) {
+		t.Errorf("expected switched values from a below the threshold, got %+v", mid)
+	}
+
+	late := MorphPresets(a, b, 0.5, 0.5)
+	if !late.ReverbEnabled || late.GrainIntensity != "extreme" || !reflect.DeepEqual(late.EffectsOrder, b.EffectsOrder) {
+		t.Errorf("expected switched values from b at the threshold, got %+v", late)
+	}
+	late.EffectsOrder[0] = "filter"
+	if b.EffectsOrder[0] != "delay" {
+		t.Error("expected the morph not to share the effects order with b")
+	}
+
+	if end := MorphPresets(a, b, 1, 0.5); end.Hash() != b.Hash() {
+		t.Errorf("expected the morph to end on b")
+	}
+	if start := MorphPresets(a, b, -1, 0.5); start.Hash() != a.Hash() {
+		t.Errorf("expected the morph to start on a")
+	}
+}
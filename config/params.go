@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// ParamKind describes how a parameter responds to a control value.
+type ParamKind int
+
+const (
+	// ParamContinuous is a float parameter scaled across Min..Max.
+	ParamContinuous ParamKind = iota
+	// ParamToggle is an on/off parameter.
+	ParamToggle
+	// ParamEnum selects one of Options.
+	ParamEnum
+	// ParamOption selects a single option (Index) of the enum named by Target.
+	ParamOption
+	// ParamMove moves the effect named by Target one slot (Index is -1 or 1)
+	// in the effects order.
+	ParamMove
+)
+
+// Param describes a controllable parameter. Names match the preset TOML keys
+// and are shared by presets, MIDI mappings and the TUI. The one exception is
+// morph, which crossfades between two presets instead of setting a value.
+type Param struct {
+	Name    string
+	Kind    ParamKind
+	Min     float32
+	Max     float32
+	Log     bool     // Logarithmic scaling between Min and Max
+	Options []string // ParamEnum option names
+	Target  string   // ParamOption enum name, ParamMove effect name
+	Index   int      // ParamOption option index, ParamMove direction
+}
+
+// BlendModes lists the blend mode names in index order.
+var BlendModes = []string{"mirror", "complement", "transform"}
+
+// GrainIntensities lists the grain intensity names in cycle order.
+var GrainIntensities = []string{"subtle", "pronounced", "extreme"}
+
+// Effects lists the reorderable effect names in default order.
+var Effects = []string{"filter", "overdrive", "bitcrush", "granular", "reverb", "delay"}
+
+// legacyParamNames maps names used by older MIDI configs to current names.
+var legacyParamNames = map[string]string{
+	"input_freeze_len": "input_freeze_length",
+	"input_freeze":     "input_frozen",
+	"granular_freeze":  "granular_frozen",
+	"mode_mirror":      "blend_mode_mirror",
+	"mode_complement":  "blend_mode_complement",
+	"mode_transform":   "blend_mode_transform",
+
+	// From the first default config, which documented CC 9 as reverb and
+	// delay mix and CC 10 as reverb and delay decay
+	"reverb_delay_blend": "reverb_mix",
+	"decay_time":         "reverb_decay_time",
+}
+
+var params = buildParams()
+var paramIndex = indexParams(params)
+
+func buildParams() []Param {
+	ps := []Param{
+		// Master
+		{Name: "master_enabled", Kind: ParamToggle},
+		{Name: "gain", Kind: ParamContinuous, Min: 0, Max: 2},
+		{Name: "input_frozen", Kind: ParamToggle},
+		{Name: "input_freeze_length", Kind: ParamContinuous, Min: 0.05, Max: 0.5},
+		{Name: "dry_wet", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "blend_mode", Kind: ParamEnum, Options: BlendModes},
+
+		// Filter
+		{Name: "filter_enabled", Kind: ParamToggle},
+		{Name: "filter_amount", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "filter_cutoff", Kind: ParamContinuous, Min: 200, Max: 8000},
+		{Name: "filter_resonance", Kind: ParamContinuous, Min: 0, Max: 1},
+
+		// Overdrive
+		{Name: "overdrive_enabled", Kind: ParamToggle},
+		{Name: "overdrive_drive", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "overdrive_tone", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "overdrive_bias", Kind: ParamContinuous, Min: -1, Max: 1},
+		{Name: "overdrive_mix", Kind: ParamContinuous, Min: 0, Max: 1},
+
+		// Bitcrush
+		{Name: "bitcrush_enabled", Kind: ParamToggle},
+		{Name: "bit_depth", Kind: ParamContinuous, Min: 4, Max: 16},
+		{Name: "bitcrush_sample_rate", Kind: ParamContinuous, Min: 1000, Max: 44100},
+		{Name: "bitcrush_drive", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "bitcrush_mix", Kind: ParamContinuous, Min: 0, Max: 1},
+
+		// Granular
+		{Name: "granular_enabled", Kind: ParamToggle},
+		{Name: "granular_density", Kind: ParamContinuous, Min: 1, Max: 50, Log: true},
+		{Name: "granular_size", Kind: ParamContinuous, Min: 0.01, Max: 0.5, Log: true},
+		{Name: "granular_pitch_scatter", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "granular_pos_scatter", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "granular_mix", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "granular_frozen", Kind: ParamToggle},
+		{Name: "grain_intensity", Kind: ParamEnum, Options: GrainIntensities},
+
+		// Reverb
+		{Name: "reverb_enabled", Kind: ParamToggle},
+		{Name: "reverb_decay_time", Kind: ParamContinuous, Min: 0.5, Max: 10},
+		{Name: "reverb_mix", Kind: ParamContinuous, Min: 0, Max: 1},
+
+		// Delay
+		{Name: "delay_enabled", Kind: ParamToggle},
+		{Name: "delay_time", Kind: ParamContinuous, Min: 0.01, Max: 2.0},
+		{Name: "delay_decay_time", Kind: ParamContinuous, Min: 0.1, Max: 5.0},
+		{Name: "mod_rate", Kind: ParamContinuous, Min: 0.1, Max: 10.0},
+		{Name: "mod_depth", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "delay_mix", Kind: ParamContinuous, Min: 0, Max: 1},
+		{Name: "delay_sync", Kind: ParamEnum, Options: SyncOptions},
+		{Name: "mod_sync", Kind: ParamEnum, Options: SyncOptions},
+
+		// Performance
+		{Name: "morph", Kind: ParamContinuous, Min: 0, Max: 1},
+	}
+
+	// Single-option selectors, e.g. "blend_mode_complement"
+	for _, enum := range []string{"blend_mode", "grain_intensity"} {
+		for _, p := range ps {
+			if p.Name != enum {
+				continue
+			}
+			for i, opt := range p.Options {
+				ps = append(ps, Param{Name: enum + "_" + opt, Kind: ParamOption, Target: enum, Index: i})
+			}
+		}
+	}
+
+	// Effects order moves, e.g. "effects_order_delay_up"
+	for _, effect := range Effects {
+		ps = append(ps,
+			Param{Name: "effects_order_" + effect + "_up", Kind: ParamMove, Target: effect, Index: -1},
+			Param{Name: "effects_order_" + effect + "_down", Kind: ParamMove, Target: effect, Index: 1},
+		)
+	}
+
+	return ps
+}
+
+func indexParams(ps []Param) map[string]Param {
+	index := make(map[string]Param, len(ps))
+	for _, p := range ps {
+		index[p.Name] = p
+	}
+	return index
+}
+
+// Params returns every mappable parameter in display order.
+func Params() []Param {
+	out := make([]Param, len(params))
+	copy(out, params)
+	return out
+}
+
+// LookupParam returns the parameter with the given name. Legacy names from
+// older MIDI configs are resolved to their current equivalent.
+func LookupParam(name string) (Param, bool) {
+	if current, ok := legacyParamNames[name]; ok {
+		name = current
+	}
+	p, ok := paramIndex[name]
+	return p, ok
+}
+
+// Scale converts a normalized 0-1 control value to the parameter range.
+func (p Param) Scale(v float32) float32 {
+	if v < 0 {
+		v = 0
+	}
+	if v > 1 {
+		v = 1
+	}
+	if p.Log && p.Min > 0 {
+		ratio := float64(p.Max) / float64(p.Min)
+		return float32(float64(p.Min) * math.Pow(ratio, float64(v)))
+	}
+	return p.Min + v*(p.Max-p.Min)
+}
+
+// Normalize converts a value in the parameter range to 0-1. It is the
+// inverse of Scale.
+func (p Param) Normalize(value float32) float32 {
+	if p.Max <= p.Min {
+		return 0
+	}
+	var v float32
+	if p.Log && p.Min > 0 && value > 0 {
+		v = float32(math.Log(float64(value)/float64(p.Min)) / math.Log(float64(p.Max)/float64(p.Min)))
+	} else {
+		v = (value - p.Min) / (p.Max - p.Min)
+	}
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// validateParamName returns an error if name is not a known parameter.
+func validateParamName(name string) error {
+	if _, ok := LookupParam(name); !ok {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParamsCoverPreset(t *testing.T) {
+	// Every preset key except effects_order must be a mappable parameter
+	keys := []string{
+		"master_enabled", "gain", "input_frozen", "input_freeze_length", "dry_wet", "blend_mode",
+		"filter_enabled", "filter_amount", "filter_cutoff", "filter_resonance",
+		"overdrive_enabled", "overdrive_drive", "overdrive_tone", "overdrive_bias", "overdrive_mix",
+		"bitcrush_enabled", "bit_depth", "bitcrush_sample_rate", "bitcrush_drive", "bitcrush_mix",
+		"granular_enabled", "granular_density", "granular_size", "granular_pitch_scatter",
+		"granular_pos_scatter", "granular_mix", "granular_frozen", "grain_intensity",
+		"reverb_enabled", "reverb_decay_time", "reverb_mix",
+		"delay_enabled", "delay_time", "delay_decay_time", "mod_rate", "mod_depth", "delay_mix",
+	}
+	for _, key := range keys {
+		if _, ok := LookupParam(key); !ok {
+			t.Errorf("expected parameter %q to be mappable", key)
+		}
+	}
+
+	for _, effect := range Effects {
+		for _, dir := range []string{"up", "down"} {
+			name := "effects_order_" + effect + "_" + dir
+			if p, ok := LookupParam(name); !ok || p.Kind != ParamMove {
+				t.Errorf("expected move parameter %q", name)
+			}
+		}
+	}
+}
+
+func TestLookupParamLegacyNames(t *testing.T) {
+	p, ok := LookupParam("granular_freeze")
+	if !ok || p.Name != "granular_frozen" {
+		t.Errorf("expected granular_freeze to resolve to granular_frozen, got %q", p.Name)
+	}
+
+	p, ok = LookupParam("mode_transform")
+	if !ok || p.Kind != ParamOption || p.Target != "blend_mode" || p.Index != 2 {
+		t.Errorf("expected mode_transform to select blend mode 2, got %+v", p)
+	}
+
+	p, ok = LookupParam("decay_time")
+	if !ok || p.Name != "reverb_decay_time" {
+		t.Errorf("expected decay_time to resolve to reverb_decay_time, got %q", p.Name)
+	}
+
+	if _, ok := LookupParam("reverb_delay_mix"); ok {
+		t.Error("expected reverb_delay_mix to be unknown")
+	}
+}
+
+func TestParamScaleNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float32
+		want  float32
+	}{
+		{"gain", 0, 0},
+		{"gain", 1, 2},
+		{"filter_cutoff", 0.5, 4100},
+		{"overdrive_bias", 0.5, 0},
+		{"granular_density", 0, 1},
+		{"granular_density", 1, 50},
+	}
+
+	for _, tt := range tests {
+		p, _ := LookupParam(tt.name)
+		got := p.Scale(tt.value)
+		if math.Abs(float64(got-tt.want)) > 0.001 {
+			t.Errorf("%s.Scale(%v) = %v, want %v", tt.name, tt.value, got, tt.want)
+		}
+		back := p.Normalize(got)
+		if math.Abs(float64(back-tt.value)) > 0.001 {
+			t.Errorf("%s.Normalize(%v) = %v, want %v", tt.name, got, back, tt.value)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -13,7 +15,13 @@ import (
 
 // Preset holds all TUI state for a named preset
 type Preset struct {
-	Name string `toml:"-"` // Not serialized, used internally
+	Name     string   `toml:"-"` // Not serialized, used internally
+	Migrated []string `toml:"-"` // Changes made upgrading an older file on load
+	Warnings []string `toml:"-"` // Problems fixed checking the file on load, see validatePresetValues
+
+	SchemaVersion int `toml:"schema_version"` // See PresetSchemaVersion
+
+	Meta PresetMeta `toml:"meta,omitempty"` // Not part of Hash
 
 	// Master
 	MasterEnabled     bool     `toml:"master_enabled"`
@@ -66,12 +74,17 @@ type Preset struct {
 	ModRate        float32 `toml:"mod_rate"`
 	ModDepth       float32 `toml:"mod_depth"`
 	DelayMix       float32 `toml:"delay_mix"`
+	DelaySync      string  `toml:"delay_sync,omitempty"` // Note division delay time follows, see SyncOptions
+	ModSync        string  `toml:"mod_sync,omitempty"`   // Note division one mod cycle follows
 }
 
-// Hash returns a hash of the preset for dirty detection
+// Hash returns a hash of the preset for dirty detection. It covers only
+// values that affect the sound, not Meta.
 func (p *Preset) Hash() string {
 	// Simple serialization for hashing
-	data, _ := toml.Marshal(p)
+	values := *p
+	values.Meta = PresetMeta{}
+	data, _ := toml.Marshal(values)
 	hash := sha256.Sum256(data)
 	return hex.EncodeToString(hash[:])
 }
@@ -79,6 +92,8 @@ func (p *Preset) Hash() string {
 // DefaultPreset returns a preset with all effects disabled and values at minimum
 func DefaultPreset() Preset {
 	return Preset{
+		SchemaVersion: PresetSchemaVersion,
+
 		// Master - disabled, zeroed
 		MasterEnabled:     false,
 		Gain:              0,
@@ -145,32 +160,28 @@ func presetsDir() (string, error) {
 	return filepath.Join(configDir, "chroma-control", "presets"), nil
 }
 
-// ListPresets returns all available preset names (excludes internal _* files)
+// ListPresets returns all available preset names, including those in
+// folders as "folder/name" (excludes internal _* files)
 func ListPresets() ([]string, error) {
-	dir, err := presetsDir()
-	if err != nil {
-		return nil, err
-	}
+	return listPresetsIn("")
+}
 
-	entries, err := os.ReadDir(dir)
+func listPresetsIn(folder string) ([]string, error) {
+	folders, presets, err := ListPresetFolder(folder)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
-		}
 		return nil, err
 	}
-
-	var presets []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		name := entry.Name()
-		// Skip internal files starting with _
-		if strings.HasSuffix(name, ".toml") && !strings.HasPrefix(name, "_") {
-			presets = append(presets, strings.TrimSuffix(name, ".toml"))
+	for _, sub := range folders {
+		nested, err := listPresetsIn(sub)
+		if err != nil {
+			return nil, err
 		}
+		presets = append(presets, nested...)
 	}
+	if presets == nil {
+		presets = []string{}
+	}
+	sort.Strings(presets)
 	return presets, nil
 }
 
@@ -180,70 +191,112 @@ func LoadPreset(name string) (Preset, error) {
 		return DefaultPreset(), fmt.Errorf("preset name cannot be empty")
 	}
 
-	dir, err := presetsDir()
+	path, err := presetPath(name)
 	if err != nil {
 		return DefaultPreset(), err
 	}
 
-	path := filepath.Join(dir, name+".toml")
-
 	// Check if file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return DefaultPreset(), fmt.Errorf("preset '%s' not found", name)
 	}
 
-	var preset Preset
-	if _, err := toml.DecodeFile(path, &preset); err != nil {
-		return DefaultPreset(), err
+	// Older files are upgraded in place, keeping a backup
+	preset, changes, err := decodePresetFile(path)
+	if err != nil {
+		return DefaultPreset(), fmt.Errorf("preset '%s': %w", name, err)
 	}
 
 	preset.Name = name
+	preset.Migrated = changes
 	return preset, nil
 }
 
-// SavePreset saves a preset to disk
+// SavePreset saves a preset to disk. The version it replaces is kept as a
+// rolling backup, see RestorePreset.
 func SavePreset(preset Preset, name string) error {
 	if name == "" {
 		return fmt.Errorf("preset name cannot be empty")
 	}
 
 	// Validate name (no path traversal, no special chars)
-	if strings.ContainsAny(name, "/\\<>:\"|?*") || strings.HasPrefix(name, "_") {
+	if !validPresetName(name) {
 		return fmt.Errorf("invalid preset name")
 	}
 
-	dir, err := presetsDir()
+	path, err := presetPath(name)
 	if err != nil {
 		return err
 	}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	// Ensure directory exists, including the preset's folder
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return err
 	}
 
-	path := filepath.Join(dir, name+".toml")
-	file, err := os.Create(path)
+	if err := stampPresetMeta(path, &preset); err != nil {
+		return err
+	}
+	data, err := encodePreset(preset)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if err := rotateBackups(path, data, presetBackups()); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// validPresetName reports whether name is a usable preset or folder path:
+// slash-separated parts that are not empty, don't start with "_" or "."
+// (kept for internal files), and hold no characters reserved in file names.
+func validPresetName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || strings.HasPrefix(part, "_") || strings.HasPrefix(part, ".") ||
+			strings.ContainsAny(part, "\\<>:\"|?*") {
+			return false
+		}
+	}
+	return true
+}
+
+// encodePreset returns a preset file's contents, with the current schema
+// version.
+func encodePreset(preset Preset) ([]byte, error) {
+	preset.SchemaVersion = PresetSchemaVersion
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(preset); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	return toml.NewEncoder(file).Encode(preset)
+// writePreset writes a preset file atomically, without a backup.
+func writePreset(path string, preset Preset) error {
+	data, err := encodePreset(preset)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
 }
 
-// DeletePreset deletes a preset by name
+// DeletePreset deletes a preset by name. Its last version is kept as a
+// backup, so it can be restored.
 func DeletePreset(name string) error {
-	if name == "" || strings.HasPrefix(name, "_") {
+	if !validPresetName(name) {
 		return fmt.Errorf("invalid preset name")
 	}
 
-	dir, err := presetsDir()
+	path, err := presetPath(name)
 	if err != nil {
 		return err
 	}
-
-	path := filepath.Join(dir, name+".toml")
+	if err := rotateBackups(path, nil, presetBackups()); err != nil {
+		return err
+	}
 	return os.Remove(path)
 }
 
@@ -276,7 +329,7 @@ func SaveLastPresetName(name string) error {
 	}
 
 	path := filepath.Join(dir, "_last.toml")
-	return os.WriteFile(path, []byte(name), 0644)
+	return writeFileAtomic(path, []byte(name), 0644)
 }
 
 // LoadAutosave loads the auto-saved session state
@@ -289,8 +342,7 @@ func SaveAutosave(preset Preset) error {
 	return SavePreset(preset, "_autosave")
 }
 
-// PresetExists checks if a preset exists
+// PresetExists checks if a preset exists, without loading it
 func PresetExists(name string) bool {
-	_, err := LoadPreset(name)
-	return err == nil
+	return presetFileExists(name)
 }
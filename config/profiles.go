@@ -0,0 +1,253 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed profiles/*.toml
+var profileFiles embed.FS
+
+// ControllerProfile is a built-in set of mappings for a common controller.
+// Profiles are TOML files in the same format as midi.toml, with a
+// description and the port names that suggest them.
+type ControllerProfile struct {
+	Name        string
+	Description string   `toml:"description"`
+	Ports       []string `toml:"ports"` // Port name fragments, matched without case
+	Config      Config   `toml:"-"`
+}
+
+// builtinProfiles holds the built-in profiles, parsed on first use since
+// port suggestions look them up on every MIDI status poll.
+var builtinProfiles struct {
+	once     sync.Once
+	profiles []ControllerProfile // Sorted by name
+}
+
+// loadedProfiles returns the parsed built-in profiles, which callers must
+// not change.
+func loadedProfiles() []ControllerProfile {
+	builtinProfiles.once.Do(func() {
+		entries, err := profileFiles.ReadDir("profiles")
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".toml")
+			if profile, err := readControllerProfile(name); err == nil {
+				builtinProfiles.profiles = append(builtinProfiles.profiles, profile)
+			}
+		}
+		sort.Slice(builtinProfiles.profiles, func(i, j int) bool {
+			return builtinProfiles.profiles[i].Name < builtinProfiles.profiles[j].Name
+		})
+	})
+	return builtinProfiles.profiles
+}
+
+// ControllerProfiles returns the built-in controller profiles sorted by
+// name.
+func ControllerProfiles() []ControllerProfile {
+	var profiles []ControllerProfile
+	for _, profile := range loadedProfiles() {
+		profile.Config = profile.Config.Clone()
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+// LookupControllerProfile returns the built-in profile with the given name.
+func LookupControllerProfile(name string) (ControllerProfile, bool) {
+	profile, err := parseControllerProfile(name)
+	return profile, err == nil
+}
+
+// SuggestControllerProfile returns the built-in profile whose port names
+// match port, preferring the most specific match.
+func SuggestControllerProfile(port string) (string, bool) {
+	port = strings.ToLower(port)
+	best, bestLen := "", 0
+	for _, profile := range loadedProfiles() {
+		for _, want := range profile.Ports {
+			if len(want) > bestLen && strings.Contains(port, strings.ToLower(want)) {
+				best, bestLen = profile.Name, len(want)
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// ControllerProfileNames returns the names of the built-in profiles.
+func ControllerProfileNames() []string {
+	var names []string
+	for _, profile := range loadedProfiles() {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// parseControllerProfile returns a copy of the named built-in profile.
+func parseControllerProfile(name string) (ControllerProfile, error) {
+	for _, profile := range loadedProfiles() {
+		if profile.Name == name {
+			profile.Config = profile.Config.Clone()
+			return profile, nil
+		}
+	}
+	return ControllerProfile{}, fmt.Errorf("unknown controller profile %q (available: %s)",
+		name, strings.Join(ControllerProfileNames(), ", "))
+}
+
+func readControllerProfile(name string) (ControllerProfile, error) {
+	data, err := profileFiles.ReadFile(path.Join("profiles", name+".toml"))
+	if err != nil {
+		return ControllerProfile{}, err
+	}
+	profile := ControllerProfile{Name: name}
+	if err := toml.Unmarshal(data, &profile); err != nil {
+		return ControllerProfile{}, fmt.Errorf("profile %s: %w", name, err)
+	}
+	// Profiles replace the default mappings but keep everything else
+	profile.Config = Config{EffectsOrder: DefaultConfig().EffectsOrder}
+	if err := toml.Unmarshal(data, &profile.Config); err != nil {
+		return ControllerProfile{}, fmt.Errorf("profile %s: %w", name, err)
+	}
+	return profile, nil
+}
+
+// resolve layers a config read from a file over its controller profile, or
+// over the default mappings when it names none. Devices are layered over
+// their own profile, if any.
+func resolve(user Config) (Config, error) {
+	base, err := mappingBase(user.Profile, DefaultConfig())
+	if err != nil {
+		return DefaultConfig(), err
+	}
+	cfg := base.Overlay(user)
+
+	for name, dev := range cfg.Devices {
+		base, err := mappingBase(dev.Profile, Config{})
+		if err != nil {
+			return DefaultConfig(), fmt.Errorf("devices: %s: %w", name, err)
+		}
+		cfg.Devices[name] = base.Overlay(dev)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return DefaultConfig(), err
+	}
+	return cfg, nil
+}
+
+// mappingBase returns the mappings a config layers over: the named
+// controller profile, or fallback without one.
+func mappingBase(profile string, fallback Config) (Config, error) {
+	if profile == "" {
+		return fallback, nil
+	}
+	p, err := parseControllerProfile(profile)
+	if err != nil {
+		return Config{}, err
+	}
+	return p.Config, nil
+}
+
+// Overlay returns c with the mappings and settings of over layered on top.
+// Mappings are merged by parameter name, lists of parameters are combined,
+// and settings in over win when they are set.
+func (c Config) Overlay(over Config) Config {
+	out := c
+	out.CC = overlayNumbers(c.CC, over.CC)
+	out.Notes = overlayNumbers(c.Notes, over.Notes)
+	out.CC14 = overlayNumbers(c.CC14, over.CC14)
+	out.NRPN = overlayNumbers(c.NRPN, over.NRPN)
+	out.RPN = overlayNumbers(c.RPN, over.RPN)
+	out.PolyPressure = overlayNumbers(c.PolyPressure, over.PolyPressure)
+	out.PitchBend = overlayNames(c.PitchBend, over.PitchBend)
+	out.Pressure = overlayNames(c.Pressure, over.Pressure)
+	out.ModWheel = overlayNames(c.ModWheel, over.ModWheel)
+
+	if len(over.Options) > 0 {
+		out.Options = make(map[string]MappingOptions, len(c.Options)+len(over.Options))
+		for name, opts := range c.Options {
+			out.Options[name] = opts
+		}
+		for name, opts := range over.Options {
+			out.Options[name] = opts
+		}
+	}
+	if len(over.ProgramChanges) > 0 {
+		out.ProgramChanges = make(map[string]string, len(c.ProgramChanges)+len(over.ProgramChanges))
+		for key, name := range c.ProgramChanges {
+			out.ProgramChanges[key] = name
+		}
+		for key, name := range over.ProgramChanges {
+			out.ProgramChanges[key] = name
+		}
+	}
+
+	for _, s := range []struct{ dst, src *string }{
+		{&out.Profile, &over.Profile},
+		{&out.Takeover, &over.Takeover},
+		{&out.InputPort, &over.InputPort},
+		{&out.FeedbackPort, &over.FeedbackPort},
+		{&out.ProgramChangeMode, &over.ProgramChangeMode},
+	} {
+		if *s.src != "" {
+			*s.dst = *s.src
+		}
+	}
+	if len(over.EffectsOrder) > 0 {
+		out.EffectsOrder = over.EffectsOrder
+	}
+	if len(over.ProgramChangeOrder) > 0 {
+		out.ProgramChangeOrder = over.ProgramChangeOrder
+	}
+	if len(over.Devices) > 0 {
+		out.Devices = over.Devices
+	}
+	return out
+}
+
+func overlayNumbers(base, over map[string]int) map[string]int {
+	if base == nil && over == nil {
+		return nil
+	}
+	out := make(map[string]int, len(base)+len(over))
+	for name, number := range base {
+		out[name] = number
+	}
+	for name, number := range over {
+		// A negative number removes a mapping from the base
+		if number < 0 {
+			delete(out, name)
+			continue
+		}
+		out[name] = number
+	}
+	return out
+}
+
+func overlayNames(base, over []string) []string {
+	out := append([]string(nil), base...)
+	for _, name := range over {
+		found := false
+		for _, existing := range out {
+			if existing == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, name)
+		}
+	}
+	return out
+}
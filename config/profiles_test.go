@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestControllerProfiles_Valid(t *testing.T) {
+	profiles := ControllerProfiles()
+	want := []string{"generic-8", "launchcontrol", "midimix", "nanokontrol2"}
+	if len(profiles) != len(want) {
+		t.Fatalf("expected %d profiles, got %d", len(want), len(profiles))
+	}
+	for i, profile := range profiles {
+		if profile.Name != want[i] {
+			t.Errorf("expected profile %s, got %s", want[i], profile.Name)
+		}
+		if profile.Description == "" {
+			t.Errorf("%s: expected a description", profile.Name)
+		}
+		if err := profile.Config.Validate(); err != nil {
+			t.Errorf("%s: %v", profile.Name, err)
+		}
+		if len(profile.Config.CC) == 0 {
+			t.Errorf("%s: expected CC mappings", profile.Name)
+		}
+		// Profiles replace the default mappings
+		if _, ok := profile.Config.Notes["blend_mode_mirror"]; ok {
+			t.Errorf("%s: expected no default note mappings", profile.Name)
+		}
+	}
+
+	if _, ok := LookupControllerProfile("nanokontrol"); ok {
+		t.Error("expected unknown profile lookup to fail")
+	}
+}
+
+func TestLookupControllerProfile_ReturnsCopies(t *testing.T) {
+	profile, ok := LookupControllerProfile("nanokontrol2")
+	if !ok {
+		t.Fatal("expected nanokontrol2 to exist")
+	}
+	// Profiles are parsed once, so edits must not reach later lookups
+	profile.Config.CC["gain"] = 99
+	profile.Config.SetMappingOptions("gain", MappingOptions{Curve: CurveExponential})
+	again, _ := LookupControllerProfile("nanokontrol2")
+	if again.Config.CC["gain"] == 99 || len(again.Config.Options) != 0 {
+		t.Errorf("expected an unedited profile, got %+v", again.Config)
+	}
+}
+
+func TestSuggestControllerProfile(t *testing.T) {
+	cases := map[string]string{
+		"nanoKONTROL2:nanoKONTROL2 MIDI 1 20:0": "nanokontrol2",
+		"MIDI Mix MIDI 1":                       "midimix",
+		"Launch Control:Launch Control MIDI 1":  "launchcontrol",
+		"Midi Through Port-0":                   "",
+	}
+	for port, want := range cases {
+		if got, _ := SuggestControllerProfile(port); got != want {
+			t.Errorf("%q: expected %q, got %q", port, want, got)
+		}
+	}
+}
+
+func TestLoadPath_ProfileOverrides(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "midi.toml")
+	data := `profile = "nanokontrol2"
+takeover = "pickup"
+
+[cc]
+gain = 20
+granular_pitch_scatter = 23
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadPath(configPath)
+	if err != nil {
+		t.Fatalf("expected profile config to load, got %v", err)
+	}
+	if cfg.CC["gain"] != 20 || cfg.CC["dry_wet"] != 7 || cfg.CC["granular_pitch_scatter"] != 23 {
+		t.Errorf("expected user mappings over nanokontrol2, got %v", cfg.CC)
+	}
+	if _, ok := cfg.CC["filter_resonance"]; ok {
+		t.Error("expected the default CC mappings to be replaced by the profile")
+	}
+	if cfg.Takeover != TakeoverPickup || len(cfg.EffectsOrder) != 6 {
+		t.Errorf("expected settings to survive the profile, got %+v", cfg)
+	}
+
+	data = `[devices.pads]
+input_port = "Launch Control"
+profile = "launchcontrol"
+[devices.pads.notes]
+input_frozen = 40
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = LoadPath(configPath); err != nil {
+		t.Fatalf("expected device profile config to load, got %v", err)
+	}
+	pads := cfg.Devices["pads"]
+	if pads.Notes["input_frozen"] != 40 || pads.Notes["filter_enabled"] != 9 || pads.CC["dry_wet"] != 48 {
+		t.Errorf("expected device mappings over launchcontrol, got %+v", pads)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`profile = "nanokontrol"`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadPath(configPath); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestLoadWithProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+
+	// No config file is the defaults, or the named profile
+	cfg, err := LoadWithProfile("")
+	if err != nil || cfg.CC["gain"] != 1 {
+		t.Fatalf("expected defaults without a config file, got %v %v", cfg.CC, err)
+	}
+	cfg, err = LoadWithProfile("midimix")
+	if err != nil || cfg.Profile != "midimix" || cfg.CC["gain"] != 62 {
+		t.Errorf("expected the midimix profile, got %v %v", cfg.CC, err)
+	}
+	if _, err := LoadWithProfile("nope"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
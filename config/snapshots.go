@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Effect snapshots hold the parameters of a single effect, or of the master
+// section, such as "delay: dub echo". They are saved and loaded apart from
+// presets, so loading one leaves every other value alone. They live under
+// _effects/<effect>/ in the presets directory, hidden from preset listings.
+
+// effectSnapshotsFolder is the folder in the presets directory holding
+// effect snapshots.
+const effectSnapshotsFolder = "_effects"
+
+// EffectSnapshotKeys returns the preset keys an effect snapshot of effect
+// holds, in preset key order, or nil if effect is not "master" or one of
+// Effects. The effects order is never part of a snapshot.
+func EffectSnapshotKeys(effect string) []string {
+	if effect != "master" && !isEffect(effect) {
+		return nil
+	}
+	var keys []string
+	t := reflect.TypeOf(Preset{})
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if key == "" || key == "-" || key == "schema_version" || key == "meta" {
+			continue
+		}
+		if presetKeyGroup(key) == effect {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func isEffect(name string) bool {
+	for _, effect := range Effects {
+		if effect == name {
+			return true
+		}
+	}
+	return false
+}
+
+// effectSnapshotDir returns the directory holding the snapshots of effect.
+func effectSnapshotDir(effect string) (string, error) {
+	if EffectSnapshotKeys(effect) == nil {
+		return "", fmt.Errorf("unknown effect '%s'", effect)
+	}
+	dir, err := presetsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, effectSnapshotsFolder, effect), nil
+}
+
+// effectSnapshotPath returns the file of an effect snapshot. Snapshot names
+// follow the rules of preset names, without folders.
+func effectSnapshotPath(effect, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("snapshot name cannot be empty")
+	}
+	if !validPresetName(name) || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid snapshot name")
+	}
+	dir, err := effectSnapshotDir(effect)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".toml"), nil
+}
+
+// ListEffectSnapshots returns the names of the snapshots saved for effect,
+// sorted.
+func ListEffectSnapshots(effect string) ([]string, error) {
+	dir, err := effectSnapshotDir(effect)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".toml") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(name, ".toml"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SaveEffectSnapshot saves the values of effect's parameters in preset as a
+// snapshot, replacing any snapshot of that name.
+func SaveEffectSnapshot(effect, name string, preset Preset) error {
+	path, err := effectSnapshotPath(effect, name)
+	if err != nil {
+		return err
+	}
+
+	data, err := encodePreset(preset)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]any)
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return err
+	}
+	values = snapshotValues(effect, values)
+	values["schema_version"] = int64(PresetSchemaVersion)
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// LoadEffectSnapshot returns base with the values in an effect snapshot
+// applied over it. Values of other effects are kept from base, even if the
+// snapshot file holds them. The snapshot's values are checked like those of
+// a preset file, with any problems in the returned preset's Warnings.
+func LoadEffectSnapshot(effect, name string, base Preset) (Preset, error) {
+	path, err := effectSnapshotPath(effect, name)
+	if err != nil {
+		return base, err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return base, fmt.Errorf("%s snapshot '%s' not found", effect, name)
+		}
+		return base, err
+	}
+
+	values := make(map[string]any)
+	if _, err := toml.Decode(string(original), &values); err != nil {
+		return base, err
+	}
+	if _, _, err := MigratePreset(values); err != nil {
+		return base, err
+	}
+	// Values of other effects are dropped before checking, so only problems
+	// with this effect's values, or unknown keys, are reported
+	keys := presetKeys()
+	for key := range values {
+		if keys[key] && key != "schema_version" && presetKeyGroup(key) != effect {
+			delete(values, key)
+		}
+	}
+	warnings := validatePresetValues(values)
+	values = snapshotValues(effect, values)
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return base, err
+	}
+	preset := base
+	if _, err := toml.Decode(buf.String(), &preset); err != nil {
+		return base, err
+	}
+	preset.Warnings = warnings
+	return preset, nil
+}
+
+// DeleteEffectSnapshot deletes an effect snapshot.
+func DeleteEffectSnapshot(effect, name string) error {
+	path, err := effectSnapshotPath(effect, name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s snapshot '%s' not found", effect, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// snapshotValues returns the values in a decoded preset file that belong in
+// a snapshot of effect.
+func snapshotValues(effect string, values map[string]any) map[string]any {
+	kept := make(map[string]any)
+	for _, key := range EffectSnapshotKeys(effect) {
+		if v, ok := values[key]; ok {
+			kept[key] = v
+		}
+	}
+	return kept
+}
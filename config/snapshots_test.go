@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEffectSnapshotKeys(t *testing.T) {
+	want := []string{"delay_enabled", "delay_time", "delay_decay_time", "mod_rate", "mod_depth", "delay_mix", "delay_sync", "mod_sync"}
+	if got := EffectSnapshotKeys("delay"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected delay keys %v, got %v", want, got)
+	}
+	for _, key := range EffectSnapshotKeys("master") {
+		if key == "effects_order" || key == "schema_version" {
+			t.Errorf("expected %s not to be part of a master snapshot", key)
+		}
+	}
+	if EffectSnapshotKeys("chorus") != nil {
+		t.Error("expected no keys for an unknown effect")
+	}
+}
+
+func TestEffectSnapshots(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dub := DefaultPreset()
+	dub.DelayTime = 0.75
+	dub.DelayMix = 0.6
+	dub.ModDepth = 0.3
+	dub.Gain = 1.8
+	if err := SaveEffectSnapshot("delay", "dub echo", dub); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := ListEffectSnapshots("delay")
+	if err != nil || !reflect.DeepEqual(names, []string{"dub echo"}) {
+		t.Errorf("expected the snapshot listed, got %v %v", names, err)
+	}
+	if presets, _ := ListPresets(); len(presets) != 0 {
+		t.Errorf("expected snapshots hidden from presets, got %v", presets)
+	}
+
+	path, _ := effectSnapshotPath("delay", "dub echo")
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "gain") {
+		t.Errorf("expected only delay values saved, got:\n%s", data)
+	}
+
+	base := DefaultPreset()
+	base.Gain = 0.4
+	base.ReverbMix = 0.9
+	loaded, err := LoadEffectSnapshot("delay", "dub echo", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.DelayTime != 0.75 || loaded.DelayMix != 0.6 || loaded.ModDepth != 0.3 {
+		t.Errorf("expected delay values from the snapshot, got %+v", loaded)
+	}
+	if loaded.Gain != 0.4 || loaded.ReverbMix != 0.9 {
+		t.Errorf("expected other values kept, got gain %v reverb mix %v", loaded.Gain, loaded.ReverbMix)
+	}
+
+	if err := DeleteEffectSnapshot("delay", "dub echo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEffectSnapshot("delay", "dub echo", base); err == nil {
+		t.Error("expected an error loading a deleted snapshot")
+	}
+}
+
+func TestLoadEffectSnapshot_ForeignKeys(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir, _ := effectSnapshotDir("master")
+	os.MkdirAll(dir, 0755)
+	// Hand-written, unversioned and with a value from another effect
+	data := "gain = 1.5\ninput_freeze_length = 0.2\nreverb_mix = 0.1\n"
+	if err := os.WriteFile(filepath.Join(dir, "loud.toml"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	base := DefaultPreset()
+	base.ReverbMix = 0.9
+	loaded, err := LoadEffectSnapshot("master", "loud", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Gain != 1.5 || loaded.InputFreezeLength != 0.2 {
+		t.Errorf("expected master values from an unversioned file, got %+v", loaded)
+	}
+	if loaded.ReverbMix != 0.9 {
+		t.Errorf("expected the reverb value ignored, got %v", loaded.ReverbMix)
+	}
+}
+
+func TestEffectSnapshotNames(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{"", "a/b", "../x", "_hidden"} {
+		if err := SaveEffectSnapshot("delay", name, DefaultPreset()); err == nil {
+			t.Errorf("expected snapshot name %q to be rejected", name)
+		}
+	}
+	if err := SaveEffectSnapshot("chorus", "x", DefaultPreset()); err == nil {
+		t.Error("expected an unknown effect to be rejected")
+	}
+}
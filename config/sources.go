@@ -0,0 +1,442 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Source kinds name the mapping tables a parameter can be driven from.
+const (
+	SourceCC           = "cc"
+	SourceNote         = "note"
+	SourceCC14         = "cc14"
+	SourceNRPN         = "nrpn"
+	SourceRPN          = "rpn"
+	SourcePolyPressure = "poly_pressure"
+	SourcePitchBend    = "pitch_bend"
+	SourcePressure     = "pressure"
+	SourceModWheel     = "mod_wheel"
+)
+
+// sourceKinds lists the source kinds in display order, with their labels,
+// number limits and accepted spellings.
+var sourceKinds = []struct {
+	kind    string
+	label   string
+	max     int // Highest number, -1 for sources without one
+	aliases []string
+}{
+	{SourceCC, "CC", 127, []string{"cc"}},
+	{SourceNote, "Note", 127, []string{"note", "n"}},
+	{SourceCC14, "CC14", 31, []string{"cc14"}},
+	{SourceNRPN, "NRPN", 16383, []string{"nrpn"}},
+	{SourceRPN, "RPN", 16382, []string{"rpn"}},
+	{SourcePolyPressure, "Poly AT", 127, []string{"poly", "polyat", "poly_pressure"}},
+	{SourcePitchBend, "Pitch Bend", -1, []string{"pb", "bend", "pitch_bend"}},
+	{SourcePressure, "Pressure", -1, []string{"at", "pressure"}},
+	{SourceModWheel, "Mod Wheel", -1, []string{"mw", "modwheel", "mod_wheel"}},
+}
+
+// Source is one MIDI control a parameter is mapped to. Number is unused for
+// pitch bend, pressure and the mod wheel. Channel is 1-16, or 0 to respond
+// on every channel; it is the parameter's channel option, so all of a
+// parameter's sources share it.
+type Source struct {
+	Kind    string
+	Number  int
+	Channel int
+}
+
+func (s Source) String() string {
+	label := s.Kind
+	for _, k := range sourceKinds {
+		if k.kind != s.Kind {
+			continue
+		}
+		label = k.label
+		if k.max >= 0 {
+			label = fmt.Sprintf("%s %d", k.label, s.Number)
+		}
+	}
+	if s.Channel > 0 {
+		label += fmt.Sprintf(" ch %d", s.Channel)
+	}
+	return label
+}
+
+// Controllers claimed by sources built from control changes, as the MIDI
+// handler claims them: the mod wheel is CC 1, a 14-bit pair takes its MSB
+// and LSB, and (N)RPNs take the data entry and parameter number controllers.
+const (
+	ccModWheel  = 1
+	ccLSBOffset = 32
+)
+
+var paramNumberControllers = []int{6, 38, 96, 97, 98, 99, 100, 101}
+
+// controllers returns the control changes s is driven by, or nil for
+// sources that aren't control changes.
+func (s Source) controllers() []int {
+	switch s.Kind {
+	case SourceCC:
+		return []int{s.Number}
+	case SourceModWheel:
+		return []int{ccModWheel}
+	case SourceCC14:
+		return []int{s.Number, s.Number + ccLSBOffset}
+	case SourceNRPN, SourceRPN:
+		return paramNumberControllers
+	}
+	return nil
+}
+
+// plainCC reports whether s is a 7-bit control change, which the handler
+// drops when another source claims its controller.
+func (s Source) plainCC() bool {
+	return s.Kind == SourceCC || s.Kind == SourceModWheel
+}
+
+// overlaps reports whether s and other respond to the same messages, or
+// one claims a controller the other needs. Claims apply on every channel.
+func (s Source) overlaps(other Source) bool {
+	if s.Kind == other.Kind && s.Number == other.Number {
+		return s.Channel == 0 || other.Channel == 0 || s.Channel == other.Channel
+	}
+	if !s.plainCC() && !other.plainCC() {
+		return false
+	}
+	for _, a := range s.controllers() {
+		for _, b := range other.controllers() {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Source) validate() error {
+	for _, k := range sourceKinds {
+		if k.kind != s.Kind {
+			continue
+		}
+		if s.Number < 0 || s.Number > k.max && k.max >= 0 {
+			return fmt.Errorf("%s number %d out of range 0-%d", k.label, s.Number, k.max)
+		}
+		if s.Channel < 0 || s.Channel > 16 {
+			return fmt.Errorf("channel %d out of range 1-16", s.Channel)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown source %q", s.Kind)
+}
+
+// ParseSource parses a source written as kind and number, such as "cc 7",
+// "note 60" or "nrpn 300", or a kind alone for "pb", "at" and "mw". A
+// trailing channel, as in "cc 7 ch 2", limits it to that channel.
+func ParseSource(text string) (Source, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 {
+		return Source{}, fmt.Errorf("empty source")
+	}
+	channel := 0
+	if n := len(fields); n >= 3 && (fields[n-2] == "ch" || fields[n-2] == "channel") {
+		c, err := strconv.Atoi(fields[n-1])
+		if err != nil || c < 1 || c > 16 {
+			return Source{}, fmt.Errorf("channel %q out of range 1-16", fields[n-1])
+		}
+		channel = c
+		fields = fields[:n-2]
+	}
+	for _, k := range sourceKinds {
+		for _, alias := range k.aliases {
+			if fields[0] != alias {
+				continue
+			}
+			src := Source{Kind: k.kind, Channel: channel}
+			if k.max < 0 {
+				if len(fields) != 1 {
+					return Source{}, fmt.Errorf("%s takes no number", k.label)
+				}
+				return src, nil
+			}
+			if len(fields) != 2 {
+				return Source{}, fmt.Errorf("%s needs a number", k.label)
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 0 || n > k.max {
+				return Source{}, fmt.Errorf("%s number %q out of range 0-%d", k.label, fields[1], k.max)
+			}
+			src.Number = n
+			return src, nil
+		}
+	}
+	return Source{}, fmt.Errorf("unknown source %q", fields[0])
+}
+
+// numberTable returns the name to number table for a source kind.
+func (c *Config) numberTable(kind string) *map[string]int {
+	switch kind {
+	case SourceCC:
+		return &c.CC
+	case SourceNote:
+		return &c.Notes
+	case SourceCC14:
+		return &c.CC14
+	case SourceNRPN:
+		return &c.NRPN
+	case SourceRPN:
+		return &c.RPN
+	case SourcePolyPressure:
+		return &c.PolyPressure
+	}
+	return nil
+}
+
+// nameList returns the parameter list for a source kind without a number.
+func (c *Config) nameList(kind string) *[]string {
+	switch kind {
+	case SourcePitchBend:
+		return &c.PitchBend
+	case SourcePressure:
+		return &c.Pressure
+	case SourceModWheel:
+		return &c.ModWheel
+	}
+	return nil
+}
+
+// Sources returns every source mapped to a parameter, in display order.
+// Legacy names in the config count as the parameter they resolve to.
+func (c Config) Sources(name string) []Source {
+	param, ok := LookupParam(name)
+	if !ok {
+		return nil
+	}
+	channel := c.MappingOptions(param.Name).Channel
+	var sources []Source
+	for _, k := range sourceKinds {
+		if table := c.numberTable(k.kind); table != nil {
+			var numbers []int
+			for key, number := range *table {
+				if p, ok := LookupParam(key); ok && p.Name == param.Name {
+					numbers = append(numbers, number)
+				}
+			}
+			sort.Ints(numbers)
+			for i, number := range numbers {
+				if i > 0 && number == numbers[i-1] {
+					continue // Under a legacy name as well
+				}
+				sources = append(sources, Source{Kind: k.kind, Number: number, Channel: channel})
+			}
+			continue
+		}
+		for _, key := range *c.nameList(k.kind) {
+			if p, ok := LookupParam(key); ok && p.Name == param.Name {
+				sources = append(sources, Source{Kind: k.kind, Channel: channel})
+				break
+			}
+		}
+	}
+	return sources
+}
+
+// ClearSources removes every mapping for a parameter, including ones under
+// legacy names. Its options are kept.
+func (c *Config) ClearSources(name string) {
+	param, ok := LookupParam(name)
+	if !ok {
+		return
+	}
+	for _, k := range sourceKinds {
+		if table := c.numberTable(k.kind); table != nil {
+			for key := range *table {
+				if p, ok := LookupParam(key); ok && p.Name == param.Name {
+					delete(*table, key)
+				}
+			}
+			continue
+		}
+		list := c.nameList(k.kind)
+		kept := (*list)[:0:0]
+		for _, key := range *list {
+			if p, ok := LookupParam(key); !ok || p.Name != param.Name {
+				kept = append(kept, key)
+			}
+		}
+		*list = kept
+	}
+}
+
+// SetSource maps a parameter to src alone, replacing any mappings it had,
+// and sets its channel option to src's channel. Each table holds one number
+// per parameter, so a parameter has at most one source of each kind.
+func (c *Config) SetSource(name string, src Source) error {
+	param, ok := LookupParam(name)
+	if !ok {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	if err := src.validate(); err != nil {
+		return err
+	}
+	c.ClearSources(param.Name)
+	opts := c.MappingOptions(param.Name)
+	opts.Channel = src.Channel
+	c.SetMappingOptions(param.Name, opts)
+	if table := c.numberTable(src.Kind); table != nil {
+		if *table == nil {
+			*table = make(map[string]int)
+		}
+		(*table)[param.Name] = src.Number
+		return nil
+	}
+	list := c.nameList(src.Kind)
+	*list = append(*list, param.Name)
+	return nil
+}
+
+// Conflicts returns the sources that drive more than one parameter, with
+// the parameters on each in parameter order. Sources on different channels
+// don't conflict, but one on every channel conflicts with the same control
+// on any channel. A CC also conflicts with the mod wheel, a 14-bit pair or
+// an (N)RPN that claims its controller, as the CC would stop responding.
+func (c Config) Conflicts() map[Source][]string {
+	type mapping struct {
+		src   Source
+		param string
+	}
+	var mappings []mapping
+	for _, param := range Params() {
+		for _, src := range c.Sources(param.Name) {
+			mappings = append(mappings, mapping{src, param.Name})
+		}
+	}
+	conflicts := make(map[Source][]string)
+	for _, m := range mappings {
+		var names []string
+		for _, other := range mappings {
+			if m.src.overlaps(other.src) {
+				names = append(names, other.param)
+			}
+		}
+		if len(names) > 1 {
+			conflicts[m.src] = names
+		}
+	}
+	return conflicts
+}
+
+// Clone returns a copy of c that can be edited without changing c.
+func (c Config) Clone() Config {
+	out := Config{}.Overlay(c)
+	if c.Devices != nil {
+		out.Devices = make(map[string]Config, len(c.Devices))
+		for name, dev := range c.Devices {
+			out.Devices[name] = dev.Clone()
+		}
+	}
+	return out
+}
+
+// SaveMappings writes the mappings of cfg, as loaded and then edited, to the
+// MIDI config file at path. The file keeps its other settings and its
+// controller profile layering: only mappings that differ from the file's
+// profile or the defaults are written, and removed ones are written as -1.
+// A profile chosen for this run only, such as with --midi-profile, is not
+// written; mappings it set are written out in full instead.
+func SaveMappings(cfg Config, path string) error {
+	var user Config
+	if _, err := toml.DecodeFile(path, &user); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if len(cfg.Devices) == 0 {
+		base, err := mappingBase(user.Profile, DefaultConfig())
+		if err != nil {
+			return err
+		}
+		user.setMappings(mappingLayer(base, cfg))
+		return Save(user, path)
+	}
+
+	if user.Devices == nil {
+		user.Devices = make(map[string]Config)
+	}
+	for name, dev := range cfg.Devices {
+		base, err := mappingBase(dev.Profile, Config{})
+		if err != nil {
+			return fmt.Errorf("devices: %s: %w", name, err)
+		}
+		saved := user.Devices[name]
+		saved.setMappings(mappingLayer(base, dev))
+		user.Devices[name] = saved
+	}
+	return Save(user, path)
+}
+
+// mappingLayer returns the mappings that turn base into cfg when overlaid.
+func mappingLayer(base, cfg Config) Config {
+	var layer Config
+	for _, k := range sourceKinds {
+		if table := cfg.numberTable(k.kind); table != nil {
+			baseTable := *base.numberTable(k.kind)
+			diff := make(map[string]int)
+			for name, number := range *table {
+				if n, ok := baseTable[name]; !ok || n != number {
+					diff[name] = number
+				}
+			}
+			for name := range baseTable {
+				if _, ok := (*table)[name]; !ok {
+					diff[name] = -1
+				}
+			}
+			*layer.numberTable(k.kind) = diff
+			continue
+		}
+		// Lists only grow when overlaid, so write them whole
+		*layer.nameList(k.kind) = *cfg.nameList(k.kind)
+	}
+	layer.Options = cfg.Options
+	return layer
+}
+
+// setMappings replaces the mapping tables and options of c.
+func (c *Config) setMappings(layer Config) {
+	for _, k := range sourceKinds {
+		if table := c.numberTable(k.kind); table != nil {
+			*table = *layer.numberTable(k.kind)
+			continue
+		}
+		*c.nameList(k.kind) = *layer.nameList(k.kind)
+	}
+	c.Options = layer.Options
+}
+
+// SetMappingOptions replaces the options for a parameter, including any
+// stored under a legacy name. Empty options are removed.
+func (c *Config) SetMappingOptions(name string, opts MappingOptions) {
+	param, ok := LookupParam(name)
+	if !ok {
+		return
+	}
+	for key := range c.Options {
+		if p, ok := LookupParam(key); ok && p.Name == param.Name {
+			delete(c.Options, key)
+		}
+	}
+	if opts.Mode == "" && !opts.Velocity && opts.Takeover == "" && opts.Curve == "" &&
+		len(opts.Range) == 0 && !opts.ReturnToCenter && opts.Channel == 0 {
+		return
+	}
+	if c.Options == nil {
+		c.Options = make(map[string]MappingOptions)
+	}
+	c.Options[param.Name] = opts
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	cases := map[string]Source{
+		"cc 7":      {Kind: SourceCC, Number: 7},
+		"Note 60":   {Kind: SourceNote, Number: 60},
+		"nrpn 300":  {Kind: SourceNRPN, Number: 300},
+		"poly 61":   {Kind: SourcePolyPressure, Number: 61},
+		"pb":        {Kind: SourcePitchBend},
+		"mw":        {Kind: SourceModWheel},
+		"  at  ":    {Kind: SourcePressure},
+		"cc14 31":   {Kind: SourceCC14, Number: 31},
+		"rpn 16382": {Kind: SourceRPN, Number: 16382},
+		"cc 7 ch 2": {Kind: SourceCC, Number: 7, Channel: 2},
+		"pb ch 16":  {Kind: SourcePitchBend, Channel: 16},
+	}
+	for text, want := range cases {
+		got, err := ParseSource(text)
+		if err != nil || got != want {
+			t.Errorf("%q: expected %+v, got %+v (%v)", text, want, got, err)
+		}
+	}
+	for _, text := range []string{"", "cc", "cc 128", "cc14 32", "pb 3", "fader 1", "note x", "cc 7 ch 0", "cc 7 ch 17", "cc ch 2"} {
+		if _, err := ParseSource(text); err == nil {
+			t.Errorf("%q: expected error", text)
+		}
+	}
+	if s := (Source{Kind: SourcePolyPressure, Number: 60}).String(); s != "Poly AT 60" {
+		t.Errorf("unexpected source label %q", s)
+	}
+	if s := (Source{Kind: SourcePitchBend, Channel: 3}).String(); s != "Pitch Bend ch 3" {
+		t.Errorf("unexpected source label %q", s)
+	}
+}
+
+func TestConfigSources(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CC["input_freeze_len"] = 20 // Legacy name for input_freeze_length
+	cfg.PitchBend = []string{"gain"}
+
+	sources := cfg.Sources("input_freeze_length")
+	if len(sources) != 2 || sources[0] != (Source{Kind: SourceCC, Number: 2}) || sources[1] != (Source{Kind: SourceCC, Number: 20}) {
+		t.Errorf("expected CC 2 and 20, got %v", sources)
+	}
+	if sources := cfg.Sources("gain"); len(sources) != 2 || sources[1].Kind != SourcePitchBend {
+		t.Errorf("expected CC 1 and pitch bend, got %v", sources)
+	}
+
+	// Reassigning replaces every source, legacy names included
+	if err := cfg.SetSource("input_freeze_length", Source{Kind: SourceNote, Number: 40}); err != nil {
+		t.Fatal(err)
+	}
+	if sources := cfg.Sources("input_freeze_length"); len(sources) != 1 || sources[0] != (Source{Kind: SourceNote, Number: 40}) {
+		t.Errorf("expected note 40 only, got %v", sources)
+	}
+	if _, ok := cfg.CC["input_freeze_len"]; ok {
+		t.Error("expected the legacy mapping to be removed")
+	}
+	if err := cfg.SetSource("gain", Source{Kind: SourceCC, Number: 200}); err == nil {
+		t.Error("expected error for out of range CC")
+	}
+
+	cfg.ClearSources("gain")
+	if sources := cfg.Sources("gain"); len(sources) != 0 || len(cfg.PitchBend) != 0 {
+		t.Errorf("expected gain to be unmapped, got %v", sources)
+	}
+}
+
+func TestConfigConflicts(t *testing.T) {
+	cfg := DefaultConfig()
+	if conflicts := cfg.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts in the defaults, got %v", conflicts)
+	}
+	cfg.CC["reverb_decay_time"] = 9
+	conflicts := cfg.Conflicts()
+	names := conflicts[Source{Kind: SourceCC, Number: 9}]
+	if len(conflicts) != 1 || strings.Join(names, ",") != "reverb_decay_time,reverb_mix" {
+		t.Errorf("expected reverb decay and mix on CC 9, got %v", conflicts)
+	}
+
+	// The same CC on different channels is two controls
+	if err := cfg.SetSource("reverb_decay_time", Source{Kind: SourceCC, Number: 9, Channel: 2}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetMappingOptions("reverb_mix", MappingOptions{Channel: 1})
+	if conflicts := cfg.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts across channels, got %v", conflicts)
+	}
+	// but one on every channel overlaps both
+	cfg.CC["delay_mix"] = 9
+	conflicts = cfg.Conflicts()
+	if names := conflicts[Source{Kind: SourceCC, Number: 9}]; len(names) != 3 {
+		t.Errorf("expected every CC 9 mapping to conflict with the omni one, got %v", conflicts)
+	}
+	if names := conflicts[Source{Kind: SourceCC, Number: 9, Channel: 2}]; strings.Join(names, ",") != "reverb_decay_time,delay_mix" {
+		t.Errorf("expected decay to conflict with delay mix only, got %v", names)
+	}
+}
+
+func TestConfigConflicts_ClaimedControllers(t *testing.T) {
+	cases := []struct {
+		name  string
+		param string
+		src   Source
+		cc    int // Controller of a default mapping it silences
+		other string
+	}{
+		{"mod wheel", "reverb_mix", Source{Kind: SourceModWheel}, 1, "gain"},
+		{"14-bit MSB", "delay_mix", Source{Kind: SourceCC14, Number: 4}, 4, "filter_cutoff"},
+		{"14-bit LSB", "delay_mix", Source{Kind: SourceCC14, Number: 3}, 35, "mod_rate"},
+		{"NRPN", "delay_time", Source{Kind: SourceNRPN, Number: 300}, 6, "granular_density"},
+		{"RPN", "delay_time", Source{Kind: SourceRPN, Number: 0}, 6, "granular_density"},
+	}
+	for _, c := range cases {
+		cfg := DefaultConfig()
+		cfg.CC[c.other] = c.cc
+		if err := cfg.SetSource(c.param, c.src); err != nil {
+			t.Fatal(err)
+		}
+		// Claims apply whatever the channel
+		cfg.SetMappingOptions(c.param, MappingOptions{Channel: 2})
+		names := cfg.Conflicts()[Source{Kind: SourceCC, Number: c.cc}]
+		sort.Strings(names)
+		want := []string{c.param, c.other}
+		sort.Strings(want)
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: expected %v on CC %d to conflict, got %v", c.name, want, c.cc, cfg.Conflicts())
+		}
+	}
+
+	// (N)RPNs share their controllers without conflicting
+	cfg := DefaultConfig()
+	delete(cfg.CC, "granular_density")
+	cfg.NRPN = map[string]int{"delay_time": 300, "delay_mix": 301}
+	cfg.RPN = map[string]int{"mod_rate": 0}
+	if conflicts := cfg.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("expected parameter numbers not to conflict with each other, got %v", conflicts)
+	}
+}
+
+func TestSaveMappings_KeepsLayering(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "midi.toml")
+	data := `profile = "nanokontrol2"
+input_port = "nanoKONTROL2"
+
+[cc]
+granular_pitch_scatter = 23
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadPath(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.ClearSources("gain") // From the profile
+	if err := cfg.SetSource("reverb_mix", Source{Kind: SourceCC, Number: 30}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetMappingOptions("reverb_mix", MappingOptions{Curve: CurveS})
+	if err := SaveMappings(cfg, configPath); err != nil {
+		t.Fatalf("SaveMappings: %v", err)
+	}
+
+	loaded, err := LoadPath(configPath)
+	if err != nil {
+		t.Fatalf("expected saved mappings to load, got %v", err)
+	}
+	if loaded.Profile != "nanokontrol2" || loaded.InputPort != "nanoKONTROL2" {
+		t.Errorf("expected profile and port to be kept, got %q %q", loaded.Profile, loaded.InputPort)
+	}
+	if _, ok := loaded.CC["gain"]; ok {
+		t.Error("expected the cleared profile mapping to stay cleared")
+	}
+	if loaded.CC["reverb_mix"] != 30 || loaded.CC["granular_pitch_scatter"] != 23 || loaded.CC["dry_wet"] != 7 {
+		t.Errorf("expected edited, user and profile mappings, got %v", loaded.CC)
+	}
+	if loaded.MappingOptions("reverb_mix").Curve != CurveS {
+		t.Error("expected options to be saved")
+	}
+
+	// Only the changes are written
+	written, _ := os.ReadFile(configPath)
+	if strings.Contains(string(written), "dry_wet") {
+		t.Errorf("expected profile mappings not to be written:\n%s", written)
+	}
+}
+
+func TestSaveMappings_KeepsFileProfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "midi.toml")
+	if err := os.WriteFile(configPath, []byte("[cc]\ngranular_pitch_scatter = 23\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	// As loaded with --midi-profile
+	cfg, err := resolve(Config{Profile: "nanokontrol2", CC: map[string]int{"granular_pitch_scatter": 23}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetSource("reverb_mix", Source{Kind: SourceCC, Number: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveMappings(cfg, configPath); err != nil {
+		t.Fatalf("SaveMappings: %v", err)
+	}
+
+	loaded, err := LoadPath(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Profile != "" {
+		t.Errorf("expected the profile override not to be saved, got %q", loaded.Profile)
+	}
+	if loaded.CC["reverb_mix"] != 30 || loaded.CC["dry_wet"] != cfg.CC["dry_wet"] {
+		t.Errorf("expected the edited mappings to be saved in full, got %v", loaded.CC)
+	}
+}
+
+func TestSaveMappings_Devices(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "midi.toml")
+	data := `[devices.pads]
+input_port = "MPD218"
+[devices.pads.notes]
+input_frozen = 36
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadPath(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pads := cfg.Devices["pads"]
+	if err := pads.SetSource("granular_frozen", Source{Kind: SourceNote, Number: 37}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Devices["pads"] = pads
+	if err := SaveMappings(cfg, configPath); err != nil {
+		t.Fatalf("SaveMappings: %v", err)
+	}
+
+	loaded, err := LoadPath(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := loaded.Devices["pads"].Notes
+	if notes["input_frozen"] != 36 || notes["granular_frozen"] != 37 || loaded.Devices["pads"].InputPort != "MPD218" {
+		t.Errorf("expected device mappings to be saved, got %+v", loaded.Devices["pads"])
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SyncOff is the sync setting for a free-running delay time or mod rate.
+const SyncOff = "off"
+
+// Divisions lists the note divisions delay time and mod rate can follow, in
+// order from longest to shortest. A "." suffix is dotted, "t" is a triplet.
+var Divisions = []string{
+	"1/1", "1/2", "1/2.", "1/2t",
+	"1/4", "1/4.", "1/4t",
+	"1/8", "1/8.", "1/8t",
+	"1/16", "1/16.", "1/16t",
+	"1/32",
+}
+
+// SyncOptions lists the tempo sync settings, off followed by Divisions.
+var SyncOptions = append([]string{SyncOff}, Divisions...)
+
+// DivisionBeats returns the length of a note division in quarter-note beats.
+func DivisionBeats(division string) (float64, bool) {
+	factor := 1.0
+	switch {
+	case strings.HasSuffix(division, "."):
+		factor = 1.5
+		division = strings.TrimSuffix(division, ".")
+	case strings.HasSuffix(division, "t"):
+		factor = 2.0 / 3
+		division = strings.TrimSuffix(division, "t")
+	}
+
+	denom, ok := strings.CutPrefix(division, "1/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(denom)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return 4 / float64(n) * factor, true
+}
+
+// DivisionSeconds returns the length of a note division at bpm.
+func DivisionSeconds(division string, bpm float64) (float64, bool) {
+	beats, ok := DivisionBeats(division)
+	if !ok || bpm <= 0 {
+		return 0, false
+	}
+	return beats * 60 / bpm, true
+}
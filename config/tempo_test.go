@@ -0,0 +1,38 @@
+package config
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDivisionSeconds(t *testing.T) {
+	tests := []struct {
+		division string
+		want     float64
+	}{
+		{"1/4", 0.5},
+		{"1/8", 0.25},
+		{"1/8.", 0.375},
+		{"1/4t", 1.0 / 3},
+		{"1/1", 2},
+	}
+	for _, tt := range tests {
+		got, ok := DivisionSeconds(tt.division, 120)
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("DivisionSeconds(%q, 120) = %v, %v; want %v", tt.division, got, ok, tt.want)
+		}
+	}
+
+	for _, division := range Divisions {
+		if _, ok := DivisionBeats(division); !ok {
+			t.Errorf("expected division %q to parse", division)
+		}
+	}
+
+	if _, ok := DivisionSeconds(SyncOff, 120); ok {
+		t.Error("expected off to have no length")
+	}
+	if _, ok := DivisionSeconds("1/4", 0); ok {
+		t.Error("expected no length without a tempo")
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Preset files are checked on load against the parameter definitions in
+// Params, so a hand-edited file can't send the engine values it doesn't
+// expect. Each problem is fixed on its own and reported as a warning: numbers
+// out of range are clamped, values of the wrong type or not among a
+// parameter's options fall back to the default, unknown keys are ignored and
+// missing keys take their default.
+
+// validatePresetValues checks the values of a decoded preset file, already
+// upgraded to PresetSchemaVersion, and fixes them in place. It returns a
+// warning for each change, in preset key order with unknown keys last.
+func validatePresetValues(data map[string]any) []string {
+	defaults := reflect.ValueOf(DefaultPreset())
+	t := defaults.Type()
+	known := make(map[string]bool)
+	var warnings []string
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		known[key] = true
+		value, ok := data[key]
+		if !ok || key == "schema_version" {
+			continue
+		}
+
+		var warning string
+		switch key {
+		case "meta":
+			warning = validatePresetMeta(data, value)
+		case "effects_order":
+			warning = validateEffectsOrder(data, value)
+		default:
+			warning = validatePresetValue(data, key, value, defaults.Field(i))
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	var unknown []string
+	for key := range data {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		delete(data, key)
+		warnings = append(warnings, fmt.Sprintf("unknown key %s ignored", key))
+	}
+	return warnings
+}
+
+// missingPresetKeys returns the keys a preset file leaves out, in preset
+// key order. Optional keys, saved only when set, are not counted.
+func missingPresetKeys(data map[string]any) []string {
+	var missing []string
+	t := reflect.TypeOf(Preset{})
+	for i := 0; i < t.NumField(); i++ {
+		key, opts, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if key == "" || key == "-" || key == "schema_version" || strings.Contains(opts, "omitempty") {
+			continue
+		}
+		if _, ok := data[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// validatePresetValue checks the value of a parameter against its
+// definition, clamping it into range or dropping it so the default applies.
+func validatePresetValue(data map[string]any, key string, value any, def reflect.Value) string {
+	p, ok := paramIndex[key]
+	if !ok {
+		return ""
+	}
+	fallback := func(reason string) string {
+		delete(data, key)
+		return fmt.Sprintf("%s: %s, using %s", key, reason, formatPresetValue(key, def))
+	}
+
+	switch {
+	case p.Kind == ParamToggle:
+		if _, ok := value.(bool); !ok {
+			return fallback(fmt.Sprintf("expected true or false, got %s", formatRawValue(value)))
+		}
+
+	case p.Kind == ParamContinuous:
+		var v float64
+		switch n := value.(type) {
+		case int64:
+			v = float64(n)
+		case float64:
+			v = n
+		default:
+			return fallback(fmt.Sprintf("expected a number, got %s", formatRawValue(value)))
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fallback(fmt.Sprintf("%v is not a usable number", v))
+		}
+		// Compared as stored, so a file holding the minimum isn't clamped
+		// to its float32 rounding
+		clamped := min(max(float32(v), p.Min), p.Max)
+		if clamped != float32(v) {
+			data[key] = float64(clamped)
+			return fmt.Sprintf("%s: %v is outside %v to %v, clamped to %v", key, v, p.Min, p.Max, clamped)
+		}
+
+	case p.Kind == ParamEnum && def.Kind() == reflect.Int:
+		n, ok := value.(int64)
+		if !ok {
+			return fallback(fmt.Sprintf("expected an option number, got %s", formatRawValue(value)))
+		}
+		if n < 0 || int(n) >= len(p.Options) {
+			return fallback(fmt.Sprintf("%d is not an option, expected 0 to %d", n, len(p.Options)-1))
+		}
+
+	case p.Kind == ParamEnum:
+		s, ok := value.(string)
+		if !ok {
+			return fallback(fmt.Sprintf("expected one of %s, got %s", strings.Join(p.Options, ", "), formatRawValue(value)))
+		}
+		if s == def.String() {
+			return ""
+		}
+		for _, opt := range p.Options {
+			if s == opt {
+				return ""
+			}
+		}
+		return fallback(fmt.Sprintf("%q is not one of %s", s, strings.Join(p.Options, ", ")))
+	}
+	return ""
+}
+
+// validateEffectsOrder checks that the effects order lists every effect
+// once. Anything else falls back to the default order, as a partial order
+// would leave effects out of the chain.
+func validateEffectsOrder(data map[string]any, value any) string {
+	items, ok := value.([]any)
+	valid := ok && len(items) == len(Effects)
+	seen := make(map[string]bool)
+	for i := 0; valid && i < len(items); i++ {
+		name, ok := items[i].(string)
+		valid = ok && isEffect(name) && !seen[name]
+		seen[name] = true
+	}
+	if valid {
+		return ""
+	}
+	delete(data, "effects_order")
+	return fmt.Sprintf("effects_order: expected each of %s once, using the default order", strings.Join(Effects, ", "))
+}
+
+// validatePresetMeta checks the [meta] table, dropping it if it doesn't
+// decode and ignoring keys it doesn't know.
+func validatePresetMeta(data map[string]any, value any) string {
+	table, ok := value.(map[string]any)
+	if !ok {
+		delete(data, "meta")
+		return "meta: expected a table, ignored"
+	}
+
+	var buf bytes.Buffer
+	var meta PresetMeta
+	if err := toml.NewEncoder(&buf).Encode(table); err != nil {
+		delete(data, "meta")
+		return fmt.Sprintf("meta: %v, ignored", err)
+	}
+	md, err := toml.Decode(buf.String(), &meta)
+	if err != nil {
+		delete(data, "meta")
+		return fmt.Sprintf("meta: %v, ignored", err)
+	}
+
+	var unknown []string
+	for _, key := range md.Undecoded() {
+		unknown = append(unknown, key.String())
+		delete(table, key.String())
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Sprintf("meta: unknown key %s ignored", strings.Join(unknown, ", "))
+	}
+	return ""
+}
+
+// formatRawValue formats a value decoded from a preset file for a warning.
+func formatRawValue(value any) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadPreset_Validation(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path, _ := presetPath("edited")
+	os.MkdirAll(strings.TrimSuffix(path, "edited.toml"), 0755)
+	data := `schema_version = 1
+master_enabled = true
+gain = 50
+dry_wet = -1
+blend_mode = 7
+grain_intensity = "loud"
+granular_mix = "half"
+reverb_enabled = 1
+delay_time = 1
+delay_sync = "1/4"
+effects_order = ["delay", "delay"]
+chorus_rate = 0.5
+
+[meta]
+description = "edited by hand"
+mood = "dark"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	preset, err := LoadPreset("edited")
+	if err != nil {
+		t.Fatalf("expected a hand-edited preset to load, got %v", err)
+	}
+	defaults := DefaultPreset()
+	if preset.Gain != 2 || preset.DryWet != 0 {
+		t.Errorf("expected values clamped into range, got gain %v dry/wet %v", preset.Gain, preset.DryWet)
+	}
+	if preset.BlendMode != 0 || preset.GrainIntensity != "subtle" || preset.GranularMix != 0 || preset.ReverbEnabled {
+		t.Errorf("expected invalid values replaced by defaults, got %+v", preset)
+	}
+	if !reflect.DeepEqual(preset.EffectsOrder, defaults.EffectsOrder) {
+		t.Errorf("expected the default effects order, got %v", preset.EffectsOrder)
+	}
+	if !preset.MasterEnabled || preset.DelayTime != 1 || preset.DelaySync != "1/4" || preset.Meta.Description != "edited by hand" {
+		t.Errorf("expected valid values kept, got %+v", preset)
+	}
+	// Missing keys take their default, not zero
+	if preset.FilterCutoff != defaults.FilterCutoff || preset.BitDepth != defaults.BitDepth {
+		t.Errorf("expected defaults for missing keys, got cutoff %v bit depth %v", preset.FilterCutoff, preset.BitDepth)
+	}
+
+	report := strings.Join(preset.Warnings, "\n")
+	for _, want := range []string{
+		"gain: 50 is outside 0 to 2, clamped to 2",
+		"dry_wet: -1 is outside 0 to 1, clamped to 0",
+		"blend_mode: 7 is not an option",
+		`grain_intensity: "loud" is not one of subtle, pronounced, extreme, using subtle`,
+		`granular_mix: expected a number, got "half"`,
+		"reverb_enabled: expected true or false",
+		"effects_order: expected each of",
+		"meta: unknown key mood ignored",
+		"unknown key chorus_rate ignored",
+		"missing input_frozen, input_freeze_length, filter_enabled",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected a warning containing %q, got:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "delay_time") || strings.Contains(report, "delay_sync") {
+		t.Errorf("expected no warnings for valid values, got:\n%s", report)
+	}
+}
+
+func TestLoadPreset_NoWarnings(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	preset := DefaultPreset()
+	preset.DelaySync = "1/8."
+	if err := SavePreset(preset, "clean"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPreset("clean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Warnings) != 0 {
+		t.Errorf("expected a saved preset to load without warnings, got %v", loaded.Warnings)
+	}
+}
+
+func TestLoadEffectSnapshot_Validation(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir, _ := effectSnapshotDir("delay")
+	os.MkdirAll(dir, 0755)
+	data := "delay_mix = 3\ndelay_tyme = 0.5\ngain = 50\n"
+	if err := os.WriteFile(dir+"/wet.toml", []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadEffectSnapshot("delay", "wet", DefaultPreset())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.DelayMix != 1 || loaded.Gain != 0 {
+		t.Errorf("expected the delay mix clamped and gain ignored, got %v %v", loaded.DelayMix, loaded.Gain)
+	}
+	want := []string{"delay_mix: 3 is outside 0 to 1, clamped to 1", "unknown key delay_tyme ignored"}
+	if !reflect.DeepEqual(loaded.Warnings, want) {
+		t.Errorf("expected warnings %v, got %v", want, loaded.Warnings)
+	}
+}
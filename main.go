@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/renderorange/chroma/chroma-control/config"
@@ -18,7 +19,28 @@ func main() {
 	scHost := flag.String("host", "127.0.0.1", "SuperCollider host")
 	scPort := flag.Int("port", 57120, "SuperCollider OSC port")
 	noMidi := flag.Bool("no-midi", false, "Disable MIDI input")
-	flag.Parse()
+	midiProfile := flag.String("midi-profile", "", "Built-in controller profile for MIDI mappings ("+strings.Join(config.ControllerProfileNames(), ", ")+")")
+	midiReplay := flag.String("midi-replay", "", "Replay a MIDI file (.mid or text) as MIDI input instead of a controller")
+	loop := flag.Bool("loop", false, "Loop play-midi playback")
+	oscListen := flag.Int("osc-listen", 0, "Port to listen on for OSC control messages, such as the morph position (0 to disable)")
+	oscListenHost := flag.String("osc-listen-host", "127.0.0.1", "Address to listen on for OSC control messages (empty for all interfaces)")
+	morphAddress := flag.String("morph-address", "/chroma/morph", "OSC address that sets the morph position, 0-1")
+	onCollision := flag.String("on-collision", "rename", "What import does with a preset whose name is taken ("+strings.Join(config.ImportModeNames, ", ")+")")
+	flag.Usage = usage
+	command, args := parseArgs(os.Args[1:])
+
+	switch command {
+	case "export":
+		os.Exit(runExport(args))
+	case "import":
+		os.Exit(runImport(args, *onCollision))
+	case "diff":
+		os.Exit(runDiff(args))
+	}
+	var playFile string
+	if command == "play-midi" {
+		playFile = args[0]
+	}
 
 	// Create OSC client
 	client := osc.NewClient(*scHost, *scPort)
@@ -27,24 +49,191 @@ func main() {
 	model := tui.NewModel(client)
 	model.SetVersion(version)
 
+	// Create program
+	p := tea.NewProgram(&model, tea.WithAltScreen())
+
+	// The MIDI config is only needed for MIDI input and playback. One that
+	// doesn't load leaves the default mappings, so the TUI still starts.
+	cfg := config.DefaultConfig()
+	if !*noMidi || playFile != "" {
+		loaded, err := config.LoadWithProfile(*midiProfile)
+		if err != nil {
+			path, _ := config.ConfigPath()
+			fmt.Fprintf(os.Stderr, "MIDI warning: %s: %v, using the default mappings\n", path, err)
+		}
+		cfg = loaded
+	}
+	model.SetMIDIConfig(cfg, nil)
+	if playFile != "" {
+		if err := model.PlayMIDI(playFile, *loop); err != nil {
+			fmt.Fprintf(os.Stderr, "play-midi: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Start MIDI handler
 	var midiHandler *midi.Handler
 	if !*noMidi {
-		cfg := config.Load()
-		midiHandler = midi.NewHandler(client, cfg)
+		source := midi.DefaultSource()
+		if *midiReplay != "" {
+			replay, err := midi.NewReplaySource(*midiReplay)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "MIDI replay: %v\n", err)
+				os.Exit(1)
+			}
+			source = replay
+		}
+		midiHandler = midi.NewSourceHandler(client, cfg, source)
+		model.SetMIDIConfig(cfg, midiHandler)
+		midiHandler.SetSend(p.Send)
 		if err := midiHandler.Start(); err != nil {
 			fmt.Fprintf(os.Stderr, "MIDI warning: %v\n", err)
 		} else {
-			model.SetMidiPort(midiHandler.PortName())
+			// Devices missing now are picked up when plugged in
+			for _, status := range midiHandler.Status() {
+				model.SetMIDIStatus(status)
+			}
 			defer midiHandler.Stop()
+			for _, profile := range cfg.Profiles() {
+				if profile.FeedbackPort != "" {
+					model.SetMIDIFeedback(midiHandler)
+					break
+				}
+			}
 		}
 	}
 
-	// Create program
-	p := tea.NewProgram(&model, tea.WithAltScreen())
+	// Listen for OSC control messages
+	if *oscListen > 0 {
+		server := osc.NewServer(*oscListenHost, *oscListen)
+		err := server.HandleFloat(*morphAddress, func(v float32) { p.Send(tui.MorphMsg{Position: v}) })
+		if err == nil {
+			err = server.Start()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "OSC listen: %v\n", err)
+			os.Exit(1)
+		}
+		defer server.Close()
+	}
 
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %[1]s [flags]\n       %[1]s play-midi [flags] file.mid\n       %[1]s export [flags] file.zip|file.json [preset or folder...]\n       %[1]s import [flags] file\n       %[1]s diff preset|file.toml preset|file.toml\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// commands maps the commands taking the place of the TUI to the number of
+// arguments they need at least, and at most (-1 for any).
+var commands = map[string][2]int{
+	"play-midi": {1, 1},
+	"export":    {1, -1},
+	"import":    {1, 1},
+	"diff":      {2, 2},
+}
+
+// parseArgs parses the command line flags. For a command it returns its
+// name and arguments, which flags may come before or after.
+func parseArgs(args []string) (string, []string) {
+	if len(args) == 0 {
+		flag.CommandLine.Parse(args)
+		return "", nil
+	}
+	limits, ok := commands[args[0]]
+	if !ok {
+		flag.CommandLine.Parse(args)
+		if flag.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+			flag.Usage()
+			os.Exit(2)
+		}
+		return "", nil
+	}
+
+	command := args[0]
+	var positional []string
+	for rest := args[1:]; ; rest = flag.Args()[1:] {
+		flag.CommandLine.Parse(rest)
+		if flag.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flag.Arg(0))
+	}
+	if len(positional) < limits[0] {
+		fmt.Fprintf(os.Stderr, "%s: missing argument\n", command)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if limits[1] >= 0 && len(positional) > limits[1] {
+		fmt.Fprintf(os.Stderr, "%s: unexpected argument %q\n", command, positional[limits[1]])
+		os.Exit(2)
+	}
+	return command, positional
+}
+
+// runExport exports presets to a bundle, returning the exit code.
+func runExport(args []string) int {
+	exported, err := config.ExportPresets(args[0], args[1:]...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	for _, name := range exported {
+		fmt.Println(name)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d preset(s) to %s\n", len(exported), args[0])
+	return 0
+}
+
+// runImport imports a preset bundle, returning the exit code: 1 when the
+// bundle can't be read or any preset in it failed.
+func runImport(args []string, onCollision string) int {
+	mode, err := config.ParseImportMode(onCollision)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return 2
+	}
+	results, err := config.ImportPresets(args[0], mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return 1
+	}
+	code := 0
+	for _, result := range results {
+		fmt.Println(result)
+		if result.Err != nil {
+			code = 1
+		}
+	}
+	return code
+}
+
+// runDiff prints the differences between two presets, each a preset name or
+// a path to a preset file. Like diff(1) it exits 0 when they match, 1 when
+// they differ and 2 on error.
+func runDiff(args []string) int {
+	var presets [2]config.Preset
+	for i, arg := range args {
+		var err error
+		if strings.HasSuffix(arg, ".toml") {
+			presets[i], err = config.ReadPresetFile(arg)
+		} else {
+			presets[i], err = config.ReadPreset(arg)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "diff: %s: %v\n", arg, err)
+			return 2
+		}
+	}
+	changes := config.DiffPresets(presets[0], presets[1])
+	if len(changes) == 0 {
+		return 0
+	}
+	fmt.Print(config.FormatPresetDiff(changes))
+	return 1
+}
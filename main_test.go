@@ -103,7 +103,7 @@ func TestMain_MIDIHandlerCreationWithNoMidiFlag(t *testing.T) {
 	var midiHandler *midi.Handler
 
 	if !noMidi {
-		cfg := config.Load()
+		cfg, _ := config.Load()
 		client := osc.NewClient("127.0.0.1", 57120)
 		midiHandler = midi.NewHandler(client, cfg)
 		if midiHandler == nil {
@@ -151,7 +151,11 @@ func TestMain_OSCClientIntegration(t *testing.T) {
 
 func TestMain_ConfigLoadingForMIDI(t *testing.T) {
 	// Test that config can be loaded for MIDI handler
-	cfg := config.Load()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected config to load, got %v", err)
+	}
 
 	// Config is a struct, not a pointer, so check if it has expected fields
 	if len(cfg.CC) == 0 {
@@ -168,8 +172,8 @@ func TestMain_ConfigLoadingForMIDI(t *testing.T) {
 	if cfg.CC["gain"] != 1 {
 		t.Errorf("expected gain CC mapping to be 1, got %d", cfg.CC["gain"])
 	}
-	if cfg.Notes["input_freeze"] != 60 {
-		t.Errorf("expected input_freeze note mapping to be 60, got %d", cfg.Notes["input_freeze"])
+	if cfg.Notes["input_frozen"] != 60 {
+		t.Errorf("expected input_frozen note mapping to be 60, got %d", cfg.Notes["input_frozen"])
 	}
 }
 
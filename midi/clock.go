@@ -0,0 +1,118 @@
+package midi
+
+import (
+	"math"
+	"time"
+)
+
+// ClockMsg reports the tempo and transport state derived from MIDI clock.
+// Beat counts quarter notes from the start of the song.
+type ClockMsg struct {
+	BPM     float64
+	Running bool
+	Beat    int
+}
+
+const (
+	// ppqn is the number of MIDI clock ticks per quarter note.
+	ppqn = 24
+	// clockGap is the longest tick interval treated as a running clock.
+	// Anything longer restarts tempo detection.
+	clockGap = time.Second
+	// bpmSmoothing weights each new beat-length measurement.
+	bpmSmoothing = 0.2
+	// bpmResolution is the smallest tempo change worth reporting.
+	bpmResolution = 0.1
+)
+
+// Clock derives a smoothed tempo and song position from MIDI clock, start,
+// stop, continue and song position pointer messages. It is not safe for
+// concurrent use.
+type Clock struct {
+	intervals [ppqn]time.Duration // Ring of recent tick intervals
+	count     int                 // Intervals recorded, up to ppqn
+	next      int                 // Next ring slot
+	lastTick  time.Time
+	bpm       float64
+	running   bool
+	ticks     int // Ticks since the start of the song
+
+	reported ClockMsg
+}
+
+// Tick records a timing clock message received at now. It returns the new
+// state when it is worth reporting.
+func (c *Clock) Tick(now time.Time) (ClockMsg, bool) {
+	if !c.lastTick.IsZero() {
+		interval := now.Sub(c.lastTick)
+		if interval > clockGap {
+			c.count = 0
+		} else {
+			c.intervals[c.next] = interval
+			c.next = (c.next + 1) % ppqn
+			if c.count < ppqn {
+				c.count++
+			}
+		}
+	}
+	c.lastTick = now
+
+	if c.running {
+		c.ticks++
+	}
+
+	// Wait for a full beat of intervals so jitter averages out
+	if c.count == ppqn {
+		var beat time.Duration
+		for _, d := range c.intervals {
+			beat += d
+		}
+		bpm := 60 / beat.Seconds()
+		if c.bpm == 0 {
+			c.bpm = bpm
+		} else {
+			c.bpm += (bpm - c.bpm) * bpmSmoothing
+		}
+	}
+	return c.report()
+}
+
+// Start restarts the song from the beginning.
+func (c *Clock) Start() (ClockMsg, bool) {
+	c.ticks = 0
+	c.running = true
+	return c.report()
+}
+
+// Continue resumes the song from the current position.
+func (c *Clock) Continue() (ClockMsg, bool) {
+	c.running = true
+	return c.report()
+}
+
+// Stop pauses the song at the current position.
+func (c *Clock) Stop() (ClockMsg, bool) {
+	c.running = false
+	return c.report()
+}
+
+// SongPosition moves to a song position pointer, counted in sixteenth notes.
+func (c *Clock) SongPosition(sixteenths uint16) (ClockMsg, bool) {
+	c.ticks = int(sixteenths) * ppqn / 4
+	return c.report()
+}
+
+// report returns the current state if the tempo moved by at least
+// bpmResolution, or the transport or beat changed, since the last report.
+func (c *Clock) report() (ClockMsg, bool) {
+	state := ClockMsg{
+		BPM:     math.Round(c.bpm/bpmResolution) * bpmResolution,
+		Running: c.running,
+		Beat:    c.ticks / ppqn,
+	}
+	if state == c.reported {
+		return state, false
+	}
+	c.reported = state
+	return state, true
+}
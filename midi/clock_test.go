@@ -0,0 +1,73 @@
+package midi
+
+import (
+	"testing"
+	"time"
+)
+
+// tickBeats feeds beats of clock at bpm and returns the last reported state.
+func tickBeats(c *Clock, start time.Time, bpm float64, beats int) (ClockMsg, time.Time) {
+	interval := time.Duration(float64(time.Minute) / bpm / ppqn)
+	now := start
+	var last ClockMsg
+	for i := 0; i < beats*ppqn; i++ {
+		now = now.Add(interval)
+		if state, ok := c.Tick(now); ok {
+			last = state
+		}
+	}
+	return last, now
+}
+
+func TestClock_Tempo(t *testing.T) {
+	var c Clock
+	state, now := tickBeats(&c, time.Now(), 120, 4)
+	if state.BPM != 120 {
+		t.Errorf("expected 120 BPM, got %v", state.BPM)
+	}
+
+	// Tempo changes are smoothed rather than jumping
+	state, now = tickBeats(&c, now, 140, 1)
+	if state.BPM <= 120 || state.BPM >= 140 {
+		t.Errorf("expected smoothed tempo between 120 and 140, got %v", state.BPM)
+	}
+	state, now = tickBeats(&c, now, 140, 8)
+	if state.BPM < 139 || state.BPM > 141 {
+		t.Errorf("expected tempo to settle near 140, got %v", state.BPM)
+	}
+
+	// A steady clock reports nothing new
+	if state, _ = tickBeats(&c, now, 140, 1); state != (ClockMsg{}) {
+		t.Errorf("expected steady clock to stop reporting, got %+v", state)
+	}
+}
+
+func TestClock_Transport(t *testing.T) {
+	var c Clock
+	state, ok := c.Start()
+	if !ok || !state.Running || state.Beat != 0 {
+		t.Errorf("expected start to report running at beat 0, got %+v", state)
+	}
+
+	state, now := tickBeats(&c, time.Now(), 120, 2)
+	if state.Beat != 2 {
+		t.Errorf("expected beat 2 after two beats, got %+v", state)
+	}
+
+	state, _ = c.Stop()
+	if state.Running {
+		t.Error("expected stop to report stopped")
+	}
+	// Ticks while stopped don't move the song position
+	tickBeats(&c, now, 120, 1)
+	state, _ = c.Continue()
+	if !state.Running || state.Beat != 2 {
+		t.Errorf("expected continue to resume at beat 2, got %+v", state)
+	}
+
+	// Song position is in sixteenths
+	state, _ = c.SongPosition(16)
+	if state.Beat != 4 {
+		t.Errorf("expected song position 16 to be beat 4, got %+v", state)
+	}
+}
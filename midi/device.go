@@ -0,0 +1,168 @@
+package midi
+
+import (
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gitlab.com/gomidi/midi/v2"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// device is one MIDI input with its own mapping profile, and optionally a
+// feedback output. Messages from every device resolve through their own
+// Mapper into the same TUI messages.
+type device struct {
+	name   string // Profile name from config, empty for the single default device
+	filter *feedbackFilter
+
+	// Mappings can be replaced while input arrives
+	mu      sync.Mutex
+	profile string // Built-in controller profile the mappings use
+	mapper  *Mapper
+	clock   Clock
+
+	// Ports, guarded by the Handler
+	in       portWatcher
+	stop     func()
+	feedback portWatcher
+	out      func(midi.Message) error
+	closeOut func()
+}
+
+func newDevice(name string, cfg config.Config) *device {
+	return &device{
+		name:     name,
+		profile:  cfg.Profile,
+		mapper:   NewMapper(cfg),
+		filter:   newFeedbackFilter(),
+		in:       portWatcher{want: cfg.InputPort},
+		feedback: portWatcher{want: cfg.FeedbackPort},
+	}
+}
+
+// newDevices returns a device for each input profile in cfg, sorted by name.
+func newDevices(cfg config.Config) []*device {
+	profiles := cfg.Profiles()
+	devices := make([]*device, 0, len(profiles))
+	for _, name := range config.ProfileNames(profiles) {
+		devices = append(devices, newDevice(name, profiles[name]))
+	}
+	return devices
+}
+
+// setConfig replaces the device's mappings.
+func (d *device) setConfig(cfg config.Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.profile = cfg.Profile
+	d.mapper = NewMapper(cfg)
+}
+
+// applyConfig gives each device its profile's mappings from cfg. Devices
+// without a profile in cfg keep theirs.
+func applyConfig(devices []*device, cfg config.Config) {
+	profiles := cfg.Profiles()
+	for _, d := range devices {
+		if profile, ok := profiles[d.name]; ok {
+			d.setConfig(profile)
+		}
+	}
+}
+
+// status returns the input connection state for the TUI.
+func (d *device) status() StatusMsg {
+	d.mu.Lock()
+	profile := d.profile
+	d.mu.Unlock()
+
+	status := d.in.status()
+	status.Device = d.name
+	if status.Connected && profile == "" {
+		status.Suggest, _ = config.SuggestControllerProfile(status.Port)
+	}
+	return status
+}
+
+// handle resolves an incoming message received at now into the messages to
+// deliver to the TUI.
+func (d *device) handle(msg midi.Message, now time.Time) []tea.Msg {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var ch, key, vel uint8
+	var cc, val uint8
+	var program uint8
+	var spp, bend uint16
+	var rel int16
+
+	// Clock messages arrive 24 times a beat, so handle them first
+	switch msg.Type() {
+	case midi.TimingClockMsg:
+		return clockMsgs(d.clock.Tick(now))
+	case midi.StartMsg:
+		return clockMsgs(d.clock.Start())
+	case midi.ContinueMsg:
+		return clockMsgs(d.clock.Continue())
+	case midi.StopMsg:
+		return clockMsgs(d.clock.Stop())
+	}
+
+	if d.filter.incoming(msg, now) {
+		return nil
+	}
+
+	switch {
+	case msg.GetSPP(&spp):
+		return clockMsgs(d.clock.SongPosition(spp))
+	case msg.GetControlChange(&ch, &cc, &val):
+		return controlMsgs(d.mapper.CC(ch, int(cc), val))
+	case msg.GetNoteOn(&ch, &key, &vel):
+		if vel > 0 {
+			return controlMsgs(d.mapper.NoteOn(ch, int(key), vel))
+		}
+		return controlMsgs(d.mapper.NoteOff(ch, int(key)))
+	case msg.GetNoteOff(&ch, &key, &vel):
+		return controlMsgs(d.mapper.NoteOff(ch, int(key)))
+	case msg.GetPitchBend(&ch, &rel, &bend):
+		return controlMsgs(d.mapper.PitchBend(ch, bend))
+	case msg.GetAfterTouch(&ch, &val):
+		return controlMsgs(d.mapper.Pressure(ch, val))
+	case msg.GetPolyAfterTouch(&ch, &key, &val):
+		return controlMsgs(d.mapper.PolyPressure(ch, int(key), val))
+	case msg.GetProgramChange(&ch, &program):
+		if preset, ok := d.mapper.ProgramChange(program); ok {
+			return []tea.Msg{preset}
+		}
+	}
+	return nil
+}
+
+// sendFeedback sends a parameter's value to the device's feedback port.
+func (d *device) sendFeedback(param string, value float32, force bool) {
+	if d.out == nil {
+		return
+	}
+	d.mu.Lock()
+	msgs := d.mapper.Feedback(param, value)
+	d.mu.Unlock()
+	for _, msg := range d.filter.outgoing(msgs, force, time.Now()) {
+		d.out(msg)
+	}
+}
+
+func clockMsgs(state ClockMsg, changed bool) []tea.Msg {
+	if !changed {
+		return nil
+	}
+	return []tea.Msg{state}
+}
+
+func controlMsgs(msgs []ControlMsg) []tea.Msg {
+	out := make([]tea.Msg, len(msgs))
+	for i, msg := range msgs {
+		out[i] = msg
+	}
+	return out
+}
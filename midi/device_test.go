@@ -0,0 +1,95 @@
+package midi
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+func TestNewDevices_OwnProfiles(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Devices = map[string]config.Config{
+		"pads":   {InputPort: "MPD218", Notes: map[string]int{"input_frozen": 36}},
+		"faders": {InputPort: "nanoKONTROL2", CC: map[string]int{"dry_wet": 0}},
+	}
+	devices := newDevices(cfg)
+	if len(devices) != 2 || devices[0].name != "faders" || devices[1].name != "pads" {
+		t.Fatalf("expected faders and pads devices, got %d", len(devices))
+	}
+	now := time.Now()
+
+	// Each device resolves through its own mappings only
+	msgs := devices[0].handle(midi.ControlChange(0, 0, 127), now)
+	if len(msgs) != 1 || msgs[0].(ControlMsg).Param != "dry_wet" {
+		t.Errorf("expected faders CC 0 to set dry_wet, got %+v", msgs)
+	}
+	if msgs := devices[1].handle(midi.ControlChange(0, 0, 127), now); len(msgs) != 0 {
+		t.Errorf("expected pads CC 0 to be unmapped, got %+v", msgs)
+	}
+	msgs = devices[1].handle(midi.NoteOn(0, 36, 100), now)
+	if len(msgs) != 1 || msgs[0].(ControlMsg).Param != "input_frozen" {
+		t.Errorf("expected pads note 36 to toggle input_frozen, got %+v", msgs)
+	}
+
+	if status := devices[1].status(); status.Device != "pads" || status.Port != "MPD218" || status.Connected {
+		t.Errorf("expected pads to wait for MPD218, got %+v", status)
+	}
+}
+
+func TestNewDevices_SingleDefault(t *testing.T) {
+	devices := newDevices(config.DefaultConfig())
+	if len(devices) != 1 || devices[0].name != "" {
+		t.Fatalf("expected one unnamed device, got %d", len(devices))
+	}
+	msgs := devices[0].handle(midi.ControlChange(0, 1, 127), time.Now())
+	if len(msgs) != 1 || msgs[0].(ControlMsg).Param != "gain" {
+		t.Errorf("expected default CC 1 to set gain, got %+v", msgs)
+	}
+}
+
+func TestDevice_HandleClockAndProgramChange(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ProgramChanges = map[string]string{"0": "clean"}
+	d := newDevice("", cfg)
+
+	msgs := d.handle(midi.Start(), time.Now())
+	if len(msgs) != 1 || !msgs[0].(ClockMsg).Running {
+		t.Errorf("expected start to report a running clock, got %+v", msgs)
+	}
+
+	msgs = d.handle(midi.ProgramChange(0, 0), time.Now())
+	if len(msgs) != 1 || msgs[0].(PresetMsg).Name != "clean" {
+		t.Errorf("expected program 0 to load clean, got %+v", msgs)
+	}
+}
+
+func TestDevice_SuggestsProfile(t *testing.T) {
+	d := newDevice("", config.DefaultConfig())
+	d.in.opened("nanoKONTROL2 MIDI 1")
+	if status := d.status(); status.Suggest != "nanokontrol2" {
+		t.Errorf("expected nanokontrol2 to be suggested, got %+v", status)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Profile = "generic-8"
+	d = newDevice("", cfg)
+	d.in.opened("nanoKONTROL2 MIDI 1")
+	if status := d.status(); status.Suggest != "" {
+		t.Errorf("expected no suggestion with a profile configured, got %+v", status)
+	}
+}
+
+func TestApplyConfig_ReplacesMappings(t *testing.T) {
+	devices := newDevices(config.DefaultConfig())
+	cfg := config.DefaultConfig()
+	cfg.CC = map[string]int{"dry_wet": 1}
+	applyConfig(devices, cfg)
+
+	msgs := devices[0].handle(midi.ControlChange(0, 1, 127), time.Now())
+	if len(msgs) != 1 || msgs[0].(ControlMsg).Param != "dry_wet" {
+		t.Errorf("expected CC 1 to set dry_wet after the edit, got %+v", msgs)
+	}
+}
@@ -0,0 +1,161 @@
+package midi
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// echoWindow is how long an incoming message that matches the last feedback
+// sent for the same control is treated as the controller echoing it back.
+const echoWindow = 100 * time.Millisecond
+
+// Feedback returns the messages that show a parameter's normalized value on
+// every CC and note mapped to it. CCs get the value mapped back through
+// their curve and range, 14-bit pairs send both halves, and notes are lit
+// when the value is above zero. Parameters limited to one channel get their
+// feedback on it.
+func (m *Mapper) Feedback(param string, value float32) []midi.Message {
+	ch := m.channels[param]
+	var msgs []midi.Message
+	for _, cc := range m.ccByParam[param] {
+		msgs = append(msgs, midi.ControlChange(ch, uint8(cc), to7Bit(m.unshape(param, value))))
+	}
+	for _, msb := range m.cc14ByParam[param] {
+		v := to14Bit(m.unshape(param, value))
+		msgs = append(msgs,
+			midi.ControlChange(ch, uint8(msb), uint8(v>>7)),
+			midi.ControlChange(ch, uint8(msb+ccLSBOffset), uint8(v&0x7f)),
+		)
+	}
+	for _, note := range m.notesByParam[param] {
+		if value > 0 {
+			msgs = append(msgs, midi.NoteOn(ch, uint8(note), 127))
+		} else {
+			msgs = append(msgs, midi.NoteOff(ch, uint8(note)))
+		}
+	}
+	return msgs
+}
+
+func to7Bit(value float32) uint8 {
+	if value <= 0 {
+		return 0
+	}
+	if value >= 1 {
+		return 127
+	}
+	return uint8(value*127 + 0.5)
+}
+
+func to14Bit(value float32) uint16 {
+	if value <= 0 {
+		return 0
+	}
+	if value >= 1 {
+		return max14Bit
+	}
+	return uint16(value*max14Bit + 0.5)
+}
+
+// wireKey identifies a control on the wire.
+type wireKey struct {
+	note   bool
+	number uint8
+}
+
+// wireValue is the last value seen for a control and when it was sent.
+type wireValue struct {
+	value  uint8
+	sentAt time.Time
+}
+
+// feedbackFilter keeps feedback from fighting with the controller. It drops
+// feedback for values the controller already shows, and drops incoming
+// messages that are the controller echoing feedback straight back.
+type feedbackFilter struct {
+	mu   sync.Mutex
+	last map[wireKey]wireValue
+}
+
+func newFeedbackFilter() *feedbackFilter {
+	return &feedbackFilter{last: make(map[wireKey]wireValue)}
+}
+
+// reset forgets every value seen, so the next feedback is sent in full.
+func (f *feedbackFilter) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last = make(map[wireKey]wireValue)
+}
+
+// outgoing returns the messages that need sending and records them. When
+// force is set every message is sent.
+func (f *feedbackFilter) outgoing(msgs []midi.Message, force bool, now time.Time) []midi.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var out []midi.Message
+	for _, msg := range msgs {
+		key, value, ok := wireState(msg)
+		if !ok {
+			continue
+		}
+		if last, seen := f.last[key]; seen && last.value == value && !force {
+			continue
+		}
+		f.last[key] = wireValue{value: value, sentAt: now}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// incoming records msg and reports whether it is an echo of feedback sent
+// within echoWindow.
+func (f *feedbackFilter) incoming(msg midi.Message, now time.Time) bool {
+	key, value, ok := wireState(msg)
+	if !ok {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.echo(key, value, now) {
+		return true
+	}
+	f.last[key] = wireValue{value: value}
+	return false
+}
+
+// isEcho reports whether incoming would drop msg, without recording it.
+func (f *feedbackFilter) isEcho(msg midi.Message, now time.Time) bool {
+	key, value, ok := wireState(msg)
+	if !ok {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.echo(key, value, now)
+}
+
+func (f *feedbackFilter) echo(key wireKey, value uint8, now time.Time) bool {
+	last, seen := f.last[key]
+	return seen && last.value == value && !last.sentAt.IsZero() && now.Sub(last.sentAt) < echoWindow
+}
+
+// wireState returns the control and value carried by a CC or note message.
+func wireState(msg midi.Message) (wireKey, uint8, bool) {
+	var ch, number, value uint8
+	switch {
+	case msg.GetControlChange(&ch, &number, &value):
+		return wireKey{number: number}, value, true
+	case msg.GetNoteOn(&ch, &number, &value):
+		return wireKey{note: true, number: number}, value, true
+	case msg.GetNoteOff(&ch, &number, &value):
+		return wireKey{note: true, number: number}, 0, true
+	}
+	return wireKey{}, 0, false
+}
@@ -0,0 +1,93 @@
+package midi
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+func TestMapper_Feedback(t *testing.T) {
+	mapper := NewMapper(config.DefaultConfig())
+
+	msgs := mapper.Feedback("gain", 0.5)
+	var ch, cc, val uint8
+	if len(msgs) != 1 || !msgs[0].GetControlChange(&ch, &cc, &val) || cc != 1 || val != 64 {
+		t.Errorf("expected CC 1 = 64 for gain, got %v", msgs)
+	}
+
+	var key, vel uint8
+	msgs = mapper.Feedback("input_frozen", 1)
+	if len(msgs) != 1 || !msgs[0].GetNoteOn(&ch, &key, &vel) || key != 60 || vel != 127 {
+		t.Errorf("expected note 60 on for input_frozen, got %v", msgs)
+	}
+
+	msgs = mapper.Feedback("input_frozen", 0)
+	if len(msgs) != 1 || !msgs[0].GetNoteOff(&ch, &key, &vel) || key != 60 {
+		t.Errorf("expected note 60 off for input_frozen, got %v", msgs)
+	}
+
+	if msgs := mapper.Feedback("bit_depth", 1); len(msgs) != 0 {
+		t.Errorf("expected no feedback for unmapped parameter, got %v", msgs)
+	}
+}
+
+func TestMapper_FeedbackCC14(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.CC14 = map[string]int{"filter_cutoff": 4}
+	mapper := NewMapper(cfg)
+
+	msgs := mapper.Feedback("filter_cutoff", float32(64<<7|1)/16383)
+	var ch, cc, val uint8
+	if len(msgs) != 2 {
+		t.Fatalf("expected MSB and LSB feedback, got %d messages", len(msgs))
+	}
+	if !msgs[0].GetControlChange(&ch, &cc, &val) || cc != 4 || val != 64 {
+		t.Errorf("expected MSB CC 4 = 64, got %v", msgs[0])
+	}
+	if !msgs[1].GetControlChange(&ch, &cc, &val) || cc != 36 || val != 1 {
+		t.Errorf("expected LSB CC 36 = 1, got %v", msgs[1])
+	}
+}
+
+func TestFeedbackFilter_SkipsUnchangedValues(t *testing.T) {
+	filter := newFeedbackFilter()
+	now := time.Now()
+
+	// The knob is at 64, so feedback for 64 is redundant
+	filter.incoming(midi.ControlChange(0, 1, 64), now)
+	if out := filter.outgoing([]midi.Message{midi.ControlChange(0, 1, 64)}, false, now); len(out) != 0 {
+		t.Errorf("expected feedback matching the controller to be skipped, got %v", out)
+	}
+
+	if out := filter.outgoing([]midi.Message{midi.ControlChange(0, 1, 64)}, true, now); len(out) != 1 {
+		t.Errorf("expected forced feedback to be sent, got %v", out)
+	}
+
+	if out := filter.outgoing([]midi.Message{midi.ControlChange(0, 1, 100)}, false, now); len(out) != 1 {
+		t.Errorf("expected changed feedback to be sent, got %v", out)
+	}
+}
+
+func TestFeedbackFilter_DropsEchoes(t *testing.T) {
+	filter := newFeedbackFilter()
+	now := time.Now()
+
+	filter.outgoing([]midi.Message{midi.NoteOn(0, 60, 127)}, false, now)
+
+	if !filter.incoming(midi.NoteOn(0, 60, 127), now.Add(10*time.Millisecond)) {
+		t.Error("expected immediate echo of feedback to be dropped")
+	}
+
+	// A real press after the echo window gets through
+	filter.outgoing([]midi.Message{midi.NoteOn(0, 62, 127)}, false, now)
+	if filter.incoming(midi.NoteOn(0, 62, 127), now.Add(time.Second)) {
+		t.Error("expected message after echo window to be accepted")
+	}
+
+	if filter.incoming(midi.NoteOn(0, 64, 100), now) {
+		t.Error("expected message without prior feedback to be accepted")
+	}
+}
@@ -1,119 +1,288 @@
-//go:build cgo
-
 package midi
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"gitlab.com/gomidi/midi/v2"
-	"gitlab.com/gomidi/midi/v2/drivers"
-	_ "gitlab.com/gomidi/midi/v2/drivers/rtmididrv"
 
 	"github.com/renderorange/chroma/chroma-control/config"
 	"github.com/renderorange/chroma/chroma-control/osc"
 )
 
 type Handler struct {
-	client *osc.Client
-	config config.Config
-	port   drivers.In
-	stop   func()
+	client  *osc.Client
+	config  config.Config
+	source  Source
+	devices []*device
+	send    func(tea.Msg)
+	done    chan struct{}
+
+	// Ports come and go as devices are plugged in, so they are guarded
+	mu sync.Mutex
 }
 
+// NewHandler returns a Handler reading from the platform's MIDI driver.
 func NewHandler(client *osc.Client, cfg config.Config) *Handler {
+	return NewSourceHandler(client, cfg, DefaultSource())
+}
+
+// NewSourceHandler returns a Handler reading from source, which may be nil
+// when no MIDI driver is available.
+func NewSourceHandler(client *osc.Client, cfg config.Config, source Source) *Handler {
 	return &Handler{
-		client: client,
-		config: cfg,
+		client:  client,
+		config:  cfg,
+		source:  source,
+		devices: newDevices(cfg),
 	}
 }
 
+// SetSend sets the function used to deliver resolved MIDI messages to the
+// TUI, normally tea.Program.Send.
+func (h *Handler) SetSend(send func(tea.Msg)) {
+	h.send = send
+}
+
+// Start opens the input port of every device in config, or the first port
+// if none is configured, along with any feedback ports. Devices that are
+// missing are picked up when they are plugged in, and unplugged devices are
+// reopened when they come back, with a StatusMsg sent for each change.
 func (h *Handler) Start() error {
-	ins := midi.GetInPorts()
-	if len(ins) == 0 {
-		return fmt.Errorf("no MIDI input ports found")
+	if h.source == nil {
+		return errors.New("MIDI not available (no MIDI driver for this platform without CGO support)")
+	}
+	if _, err := h.source.Inputs(); err != nil {
+		return fmt.Errorf("MIDI not available: %w", err)
+	}
+	h.mu.Lock()
+	if h.done != nil {
+		h.mu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	h.done = done
+	h.mu.Unlock()
+	h.poll()
+	go h.watch(done)
+	return nil
+}
+
+// watch polls the port lists until Stop is called.
+func (h *Handler) watch(done chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for _, status := range h.poll() {
+				if h.send != nil {
+					h.send(status)
+				}
+			}
+		}
+	}
+}
+
+// poll opens and closes ports to match the devices currently present. It
+// returns the status of each device whose input or feedback port changed.
+func (h *Handler) poll() []StatusMsg {
+	var closers []func()
+	defer func() {
+		// Closing an input can wait for its callback, which may be waiting
+		// on the TUI, which may be waiting on h.mu
+		for _, closePort := range closers {
+			closePort()
+		}
+	}()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ins, _ := h.source.Inputs()
+	outs, _ := h.source.Outputs()
+
+	var changed []StatusMsg
+	for _, d := range h.devices {
+		before := d.status()
+		feedbackBefore := d.out != nil
+
+		open, lost := d.in.poll(h.freePorts(d, ins, func(d *device) string { return d.in.connected }))
+		if lost {
+			closers = append(closers, d.detachInput()...)
+		}
+		if open != "" {
+			h.openInput(d, open)
+		}
+
+		if d.feedback.want != "" {
+			open, lost := d.feedback.poll(h.freePorts(d, outs, func(d *device) string { return d.feedback.connected }))
+			if lost {
+				closers = append(closers, d.detachFeedback()...)
+			}
+			if open != "" {
+				h.openFeedback(d, open)
+			}
+		}
+
+		// Feedback changes are reported too, so the TUI resends its state
+		if after := d.status(); after != before || (d.out != nil) != feedbackBefore {
+			changed = append(changed, after)
+		}
+	}
+	return changed
+}
+
+// freePorts returns the port names not already opened by another device.
+func (h *Handler) freePorts(d *device, names []string, connected func(*device) string) []string {
+	var free []string
+	for _, name := range names {
+		taken := false
+		for _, other := range h.devices {
+			if other != d && connected(other) == name {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			free = append(free, name)
+		}
+	}
+	return free
+}
+
+func (h *Handler) openInput(d *device, name string) {
+	stop, err := h.source.Listen(name, func(msg midi.Message) {
+		h.dispatch(d.receive(name, msg, time.Now()))
+	})
+	if err != nil {
+		return
 	}
+	d.stop = stop
+	d.in.opened(name)
+}
 
-	// Use first available port
-	h.port = ins[0]
+// detachInput marks the device's input closed, returning the function that
+// closes the port, to be called once h.mu is released.
+func (d *device) detachInput() []func() {
+	stop := d.stop
+	d.stop = nil
+	if stop == nil {
+		return nil
+	}
+	return []func(){stop}
+}
 
-	stop, err := midi.ListenTo(h.port, h.handleMessage)
+func (h *Handler) openFeedback(d *device, name string) {
+	send, closeOut, err := h.source.Open(name)
 	if err != nil {
-		return err
+		return
 	}
-	h.stop = stop
+	d.out = send
+	d.closeOut = closeOut
+	d.feedback.opened(name)
+	// A reconnected controller shows nothing, so resend everything
+	d.filter.reset()
+}
 
-	return nil
+// detachFeedback marks the device's feedback port closed, returning the
+// function that closes it, to be called once h.mu is released.
+func (d *device) detachFeedback() []func() {
+	closeOut := d.closeOut
+	d.out = nil
+	d.closeOut = nil
+	if closeOut == nil {
+		return nil
+	}
+	return []func(){closeOut}
 }
 
+// Stop closes every port. It is safe to call more than once.
 func (h *Handler) Stop() {
-	if h.stop != nil {
-		h.stop()
+	var closers []func()
+	h.mu.Lock()
+	if h.done != nil {
+		close(h.done)
+		h.done = nil
+	}
+	for _, d := range h.devices {
+		closers = append(closers, d.detachInput()...)
+		closers = append(closers, d.detachFeedback()...)
+	}
+	h.mu.Unlock()
+	for _, closePort := range closers {
+		closePort()
 	}
 }
 
-func (h *Handler) PortName() string {
-	if h.port != nil {
-		return h.port.String()
+// Status returns the input port connection state of every device.
+func (h *Handler) Status() []StatusMsg {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	statuses := make([]StatusMsg, len(h.devices))
+	for i, d := range h.devices {
+		statuses[i] = d.status()
 	}
-	return ""
+	return statuses
 }
 
-func (h *Handler) handleMessage(msg midi.Message, timestamp int32) {
-	var ch, key, vel uint8
-	var cc, val uint8
+// PortName returns the names of the open input ports, comma separated.
+func (h *Handler) PortName() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var names []string
+	for _, d := range h.devices {
+		if d.stop != nil {
+			names = append(names, d.in.connected)
+		}
+	}
+	return strings.Join(names, ", ")
+}
 
-	switch {
-	case msg.GetControlChange(&ch, &cc, &val):
-		h.handleCC(int(cc), float32(val)/127.0)
-	case msg.GetNoteOn(&ch, &key, &vel):
-		if vel > 0 {
-			h.handleNoteOn(int(key))
+// FeedbackPortName returns the names of the open feedback output ports,
+// comma separated, or empty if feedback is disabled or no port is present.
+func (h *Handler) FeedbackPortName() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var names []string
+	for _, d := range h.devices {
+		if d.out != nil {
+			names = append(names, d.feedback.connected)
 		}
 	}
+	return strings.Join(names, ", ")
 }
 
-func (h *Handler) handleCC(cc int, value float32) {
-	cfg := h.config.CC
-
-	switch cc {
-	case cfg["gain"]:
-		h.client.SetGain(value * 2)
-	case cfg["input_freeze_len"]:
-		h.client.SetInputFreezeLength(0.05 + value*0.45)
-	case cfg["filter_amount"]:
-		h.client.SetFilterAmount(value)
-	case cfg["filter_cutoff"]:
-		h.client.SetFilterCutoff(200 + value*7800)
-	case cfg["filter_resonance"]:
-		h.client.SetFilterResonance(value)
-	case cfg["granular_density"]:
-		h.client.SetGranularDensity(1 + value*49)
-	case cfg["granular_size"]:
-		h.client.SetGranularSize(0.01 + value*0.49)
-	case cfg["granular_mix"]:
-		h.client.SetGranularMix(value)
-	case cfg["reverb_mix"]:
-		h.client.SetReverbMix(value)
-	case cfg["delay_mix"]:
-		h.client.SetDelayMix(value)
-	case cfg["dry_wet"]:
-		h.client.SetDryWet(value)
-	}
-}
-
-func (h *Handler) handleNoteOn(note int) {
-	cfg := h.config.Notes
-
-	switch note {
-	case cfg["input_freeze"]:
-		h.client.SendInt("/chroma/inputFreeze", 1)
-	case cfg["granular_freeze"]:
-		h.client.SendInt("/chroma/granularFreeze", 1)
-	case cfg["mode_mirror"]:
-		h.client.SetBlendMode(0)
-	case cfg["mode_complement"]:
-		h.client.SetBlendMode(1)
-	case cfg["mode_transform"]:
-		h.client.SetBlendMode(2)
+// Feedback sends a parameter's normalized value to the controls mapped to
+// it on every device. Values a controller already shows are skipped unless
+// force is set.
+func (h *Handler) Feedback(param string, value float32, force bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, d := range h.devices {
+		d.sendFeedback(param, value, force)
+	}
+}
+
+// SetConfig replaces the mappings of each device with its profile from cfg,
+// so edited mappings apply without reopening ports.
+func (h *Handler) SetConfig(cfg config.Config) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.config = cfg
+	applyConfig(h.devices, cfg)
+}
+
+func (h *Handler) dispatch(msgs []tea.Msg) {
+	if h.send == nil {
+		return
+	}
+	for _, msg := range msgs {
+		h.send(msg)
 	}
 }
@@ -1,8 +1,8 @@
-//go:build !cgo
-// +build !cgo
-
 package midi
 
+// These tests cover a handler without a MIDI driver, as on platforms with no
+// pure Go driver when built without CGO.
+
 import (
 	"fmt"
 	"testing"
@@ -15,7 +15,7 @@ func TestHandlerNoCGO_CreationWithValidParameters(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -25,7 +25,7 @@ func TestHandlerNoCGO_CreationWithNilClient(t *testing.T) {
 	cfg := config.DefaultConfig()
 
 	// Should handle nil client gracefully
-	handler := NewHandler(nil, cfg)
+	handler := NewSourceHandler(nil, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected no-CGO MIDI handler to handle nil client")
 	}
@@ -35,7 +35,7 @@ func TestHandlerNoCGO_CreationWithEmptyConfig(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.Config{} // Empty config
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected no-CGO MIDI handler to handle empty config")
 	}
@@ -45,7 +45,7 @@ func TestHandlerNoCGO_PortNameReturnsEmpty(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -61,7 +61,7 @@ func TestHandlerNoCGO_StartReturnsError(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -82,7 +82,7 @@ func TestHandlerNoCGO_StopDoesNothing(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -99,7 +99,7 @@ func TestHandlerNoCGO_ConfigHandling(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -127,7 +127,7 @@ func TestHandlerNoCGO_ErrorHandling(t *testing.T) {
 		Notes: map[string]int{"invalid": -1}, // Invalid note number
 	}
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected no-CGO MIDI handler to handle invalid config gracefully")
 	}
@@ -143,7 +143,7 @@ func TestHandlerNoCGO_ConcurrentAccess(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -170,7 +170,7 @@ func TestHandlerNoCGO_MultipleStartStop(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -189,7 +189,7 @@ func TestHandlerNoCGO_FallbackBehavior(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
 	cfg := config.DefaultConfig()
 
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 	if handler == nil {
 		t.Fatal("expected non-nil no-CGO MIDI handler")
 	}
@@ -219,7 +219,7 @@ func TestHandlerNoCGO_ComparisonWithCGOHandler(t *testing.T) {
 	cfg := config.DefaultConfig()
 
 	// Create handler (this is the no-CGO version due to build tags)
-	handler := NewHandler(client, cfg)
+	handler := NewSourceHandler(client, cfg, nil)
 
 	if handler == nil {
 		t.Fatal("expected non-nil MIDI handler")
@@ -234,24 +234,6 @@ func TestHandlerNoCGO_ComparisonWithCGOHandler(t *testing.T) {
 	// Clean up
 	handler.Stop()
 }
-	if nocgoHandler == nil {
-		t.Fatal("expected non-nil no-CGO MIDI handler")
-	}
-
-	// Both should have different behaviors
-	// CGO handler might succeed, no-CGO should fail
-	cgoErr := cgoHandler.Start()
-	nocgoErr := nocgoHandler.Start()
-
-	// no-CGO should always return error
-	if nocgoErr == nil {
-		t.Error("expected no-CGO handler to always return error on start")
-	}
-
-	// Clean up
-	cgoHandler.Stop()
-	nocgoHandler.Stop()
-}
 
 func TestHandlerNoCGO_ConfigIndependence(t *testing.T) {
 	client := osc.NewClient("127.0.0.1", 57120)
@@ -268,7 +250,7 @@ func TestHandlerNoCGO_ConfigIndependence(t *testing.T) {
 
 	for i, cfg := range configs {
 		t.Run(fmt.Sprintf("config_%d", i), func(t *testing.T) {
-			handler := NewHandler(client, cfg)
+			handler := NewSourceHandler(client, cfg, nil)
 			if handler == nil {
 				t.Fatal("expected non-nil no-CGO MIDI handler")
 			}
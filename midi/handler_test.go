@@ -65,8 +65,8 @@ func TestHandler_NoteMapping(t *testing.T) {
 	}
 
 	// Test that note mappings are loaded from config
-	if cfg.Notes["input_freeze"] != 60 {
-		t.Errorf("expected input_freeze note mapping to be 60, got %d", cfg.Notes["input_freeze"])
+	if cfg.Notes["input_frozen"] != 60 {
+		t.Errorf("expected input_frozen note mapping to be 60, got %d", cfg.Notes["input_frozen"])
 	}
 
 	// Test that handler has access to note mappings
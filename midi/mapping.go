@@ -0,0 +1,455 @@
+package midi
+
+import (
+	"sort"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// ControlMsg reports a change on a mapped MIDI control. Param is a
+// config.Param name and Value is normalized to 0-1. When Toggle is set the
+// receiver flips the parameter's current state instead of applying Value.
+// Takeover is the config takeover mode for CCs and empty for notes. When Bend
+// is set Value is an offset of -1 to 1 from the parameter's value before the
+// bend began, and 0 restores that value.
+type ControlMsg struct {
+	Param    string
+	Value    float32
+	Toggle   bool
+	Takeover string
+	Bend     bool
+}
+
+// PresetMsg asks the receiver to load a preset, sent for program changes.
+type PresetMsg struct {
+	Name string
+}
+
+// Mapper resolves incoming MIDI messages to parameter changes using the
+// mappings from config.
+type Mapper struct {
+	cc       map[int][]string
+	cc14     map[int][]string // By MSB controller
+	nrpn     map[int][]string
+	rpn      map[int][]string
+	notes    map[int][]noteTarget
+	poly     map[int][]string // Polyphonic aftertouch by note
+	bend     []string
+	pressure []string
+	takeover map[string]string
+	shapes   map[string]shape
+	channels map[string]uint8 // Wire channel of parameters limited to one
+
+	// Reverse indexes used for feedback
+	ccByParam    map[string][]int
+	cc14ByParam  map[string][]int
+	notesByParam map[string][]int
+
+	// Program change to preset lookup
+	programs config.Config
+	presets  func() ([]string, error)
+
+	// Running state for controls spread over several messages
+	msb      [32]uint8 // Last MSB of each 14-bit pair
+	selected paramNumber
+	data     uint16   // Current (N)RPN data entry value
+	bank     [2]uint8 // Bank select MSB and LSB
+}
+
+// paramNumber is the currently selected NRPN or RPN.
+type paramNumber struct {
+	nrpn bool
+	msb  uint8
+	lsb  uint8
+}
+
+func (p paramNumber) number() int {
+	return int(p.msb)<<7 | int(p.lsb)
+}
+
+// Controllers used by 14-bit pairs, (N)RPN messages and bank select
+const (
+	ccBankSelectMSB = 0
+	ccBankSelectLSB = 32
+	ccLSBOffset     = 32
+	ccDataEntryMSB  = 6
+	ccDataEntryLSB  = 38
+	ccDataIncrement = 96
+	ccDataDecrement = 97
+	ccNRPNLSB       = 98
+	ccNRPNMSB       = 99
+	ccRPNLSB        = 100
+	ccRPNMSB        = 101
+	max14Bit        = 16383
+	rpnNull         = 16383
+)
+
+// paramNumberControllers are claimed by (N)RPN mappings.
+var paramNumberControllers = []int{
+	ccDataEntryMSB, ccDataEntryLSB, ccDataIncrement, ccDataDecrement,
+	ccNRPNLSB, ccNRPNMSB, ccRPNLSB, ccRPNMSB,
+}
+
+// shape is the curve, range and pitch bend behaviour of a parameter's
+// continuous sources.
+type shape struct {
+	param config.Param
+	opts  config.MappingOptions
+}
+
+// Pitch bend center and the largest distance either side of it
+const (
+	bendCenter = 8192
+	bendUp     = max14Bit - bendCenter
+	bendDown   = bendCenter
+)
+
+// ccModWheel is the mod wheel controller.
+const ccModWheel = 1
+
+// noteTarget is a parameter driven by a note together with its note mode.
+type noteTarget struct {
+	param    config.Param
+	mode     string
+	velocity bool
+}
+
+// NewMapper builds a Mapper from cfg. Legacy names are resolved to their
+// current equivalent and unknown names are ignored.
+func NewMapper(cfg config.Config) *Mapper {
+	notes := make(map[int][]noteTarget)
+	for number, names := range indexMappings(cfg.Notes) {
+		for _, name := range names {
+			param, _ := config.LookupParam(name)
+			opts := cfg.MappingOptions(name)
+			notes[number] = append(notes[number], noteTarget{
+				param:    param,
+				mode:     cfg.NoteMode(name),
+				velocity: opts.Velocity,
+			})
+		}
+	}
+
+	cc := indexMappings(cfg.CC)
+	cc14 := indexMappings(cfg.CC14)
+	nrpn := indexMappings(cfg.NRPN)
+	rpn := indexMappings(cfg.RPN)
+
+	// High-resolution mappings claim their controllers, so 7-bit mappings
+	// left on them (e.g. from the defaults) are dropped
+	for msb := range cc14 {
+		delete(cc, msb)
+		delete(cc, msb+ccLSBOffset)
+	}
+	if len(nrpn) > 0 || len(rpn) > 0 {
+		for _, number := range paramNumberControllers {
+			delete(cc, number)
+		}
+	}
+	// The mod wheel is CC 1, named so it can replace the default mapping
+	if wheel := sourceMappings(cfg.ModWheel); len(wheel) > 0 {
+		cc[ccModWheel] = wheel
+	}
+
+	poly := indexMappings(cfg.PolyPressure)
+	bend := sourceMappings(cfg.PitchBend)
+	pressure := sourceMappings(cfg.Pressure)
+
+	takeover := make(map[string]string)
+	shapes := make(map[string]shape)
+	for _, index := range []map[int][]string{cc, cc14, nrpn, rpn, poly, {0: bend}, {0: pressure}} {
+		for _, names := range index {
+			for _, name := range names {
+				takeover[name] = cfg.TakeoverMode(name)
+				param, _ := config.LookupParam(name)
+				shapes[name] = shape{param: param, opts: cfg.MappingOptions(name)}
+			}
+		}
+	}
+
+	channels := make(map[string]uint8)
+	for name, opts := range cfg.Options {
+		if param, ok := config.LookupParam(name); ok && opts.Channel > 0 {
+			channels[param.Name] = uint8(opts.Channel - 1)
+		}
+	}
+
+	return &Mapper{
+		cc:           cc,
+		cc14:         cc14,
+		nrpn:         nrpn,
+		rpn:          rpn,
+		notes:        notes,
+		poly:         poly,
+		bend:         bend,
+		pressure:     pressure,
+		takeover:     takeover,
+		shapes:       shapes,
+		channels:     channels,
+		ccByParam:    reverseIndex(cc),
+		cc14ByParam:  reverseIndex(cc14),
+		notesByParam: reverseIndex(indexMappings(cfg.Notes)),
+		programs:     cfg,
+		presets:      config.ListPresets,
+	}
+}
+
+func reverseIndex(index map[int][]string) map[string][]int {
+	reverse := make(map[string][]int)
+	for number, names := range index {
+		for _, name := range names {
+			reverse[name] = append(reverse[name], number)
+		}
+	}
+	for _, numbers := range reverse {
+		sort.Ints(numbers)
+	}
+	return reverse
+}
+
+// sourceMappings resolves the parameter names mapped to a source without a
+// number, such as pitch bend.
+func sourceMappings(names []string) []string {
+	mappings := make(map[string]int, len(names))
+	for _, name := range names {
+		mappings[name] = 0
+	}
+	return indexMappings(mappings)[0]
+}
+
+func indexMappings(mappings map[string]int) map[int][]string {
+	index := make(map[int][]string)
+	seen := make(map[int]map[string]bool)
+	for name, number := range mappings {
+		param, ok := config.LookupParam(name)
+		if !ok {
+			continue
+		}
+		if seen[number] == nil {
+			seen[number] = make(map[string]bool)
+		}
+		if seen[number][param.Name] {
+			continue
+		}
+		seen[number][param.Name] = true
+		index[number] = append(index[number], param.Name)
+	}
+	for _, names := range index {
+		sort.Strings(names)
+	}
+	return index
+}
+
+// CC returns the changes for a control change message on channel ch. 14-bit
+// pairs and (N)RPNs span several messages, so CC keeps running state between
+// calls and must not be called concurrently.
+func (m *Mapper) CC(ch uint8, cc int, value uint8) []ControlMsg {
+	msgs := m.changes(ch, m.cc[cc], float32(value)/127.0)
+
+	switch cc {
+	case ccBankSelectMSB:
+		m.bank[0] = value
+	case ccBankSelectLSB:
+		m.bank[1] = value
+	}
+
+	switch {
+	case cc < ccLSBOffset && len(m.cc14[cc]) > 0:
+		// A new MSB resets the LSB, as the MIDI spec requires
+		m.msb[cc] = value
+		msgs = append(msgs, m.changes(ch, m.cc14[cc], from14Bit(uint16(value)<<7))...)
+	case cc >= ccLSBOffset && cc < 2*ccLSBOffset && len(m.cc14[cc-ccLSBOffset]) > 0:
+		msb := cc - ccLSBOffset
+		msgs = append(msgs, m.changes(ch, m.cc14[msb], from14Bit(uint16(m.msb[msb])<<7|uint16(value)))...)
+	}
+
+	if len(m.nrpn) > 0 || len(m.rpn) > 0 {
+		msgs = append(msgs, m.paramNumberCC(ch, cc, value)...)
+	}
+	return msgs
+}
+
+// ProgramChange returns the preset to load for a program change in the
+// current bank, if one is configured.
+func (m *Mapper) ProgramChange(program uint8) (PresetMsg, bool) {
+	var presets []string
+	if m.programs.ProgramChangeMode == config.ProgramChangeIndex && len(m.programs.ProgramChangeOrder) == 0 {
+		var err error
+		if presets, err = m.presets(); err != nil {
+			return PresetMsg{}, false
+		}
+	}
+	bank := int(m.bank[0])<<7 | int(m.bank[1])
+	name, ok := m.programs.ProgramPreset(bank, int(program), presets)
+	return PresetMsg{Name: name}, ok
+}
+
+// paramNumberCC handles the controllers that select an (N)RPN and set its
+// value.
+func (m *Mapper) paramNumberCC(ch uint8, cc int, value uint8) []ControlMsg {
+	switch cc {
+	case ccNRPNMSB, ccNRPNLSB, ccRPNMSB, ccRPNLSB:
+		nrpn := cc == ccNRPNMSB || cc == ccNRPNLSB
+		if nrpn != m.selected.nrpn {
+			m.selected = paramNumber{nrpn: nrpn}
+		}
+		if cc == ccNRPNMSB || cc == ccRPNMSB {
+			m.selected.msb = value
+		} else {
+			m.selected.lsb = value
+		}
+		m.data = 0
+		return nil
+	case ccDataEntryMSB:
+		m.data = uint16(value) << 7
+	case ccDataEntryLSB:
+		m.data = m.data&^0x7f | uint16(value)
+	case ccDataIncrement:
+		if m.data < max14Bit {
+			m.data++
+		}
+	case ccDataDecrement:
+		if m.data > 0 {
+			m.data--
+		}
+	default:
+		return nil
+	}
+
+	var names []string
+	if m.selected.nrpn {
+		names = m.nrpn[m.selected.number()]
+	} else if m.selected.number() != rpnNull {
+		names = m.rpn[m.selected.number()]
+	}
+	return m.changes(ch, names, from14Bit(m.data))
+}
+
+// PitchBend returns the changes for a pitch bend message on channel ch with
+// its 14-bit value. Return-to-center mappings report an offset from the
+// center.
+func (m *Mapper) PitchBend(ch uint8, value uint16) []ControlMsg {
+	var offset float32
+	switch {
+	case value > bendCenter:
+		offset = float32(value-bendCenter) / bendUp
+	case value < bendCenter:
+		offset = -float32(bendCenter-value) / bendDown
+	}
+
+	var msgs []ControlMsg
+	for _, name := range m.bend {
+		s := m.shapes[name]
+		if !m.listens(name, ch) {
+			continue
+		}
+		if !s.opts.ReturnToCenter {
+			msgs = append(msgs, m.changes(ch, []string{name}, from14Bit(value))...)
+			continue
+		}
+		lo, hi := s.opts.Span(s.param)
+		depth := config.ApplyCurve(s.opts.Curve, abs(offset)) * (hi - lo)
+		if offset < 0 {
+			depth = -depth
+		}
+		msgs = append(msgs, ControlMsg{Param: name, Value: depth, Bend: true})
+	}
+	return msgs
+}
+
+// Pressure returns the changes for a channel pressure message on channel ch.
+func (m *Mapper) Pressure(ch uint8, value uint8) []ControlMsg {
+	return m.changes(ch, m.pressure, float32(value)/127.0)
+}
+
+// PolyPressure returns the changes for a polyphonic aftertouch message on
+// channel ch.
+func (m *Mapper) PolyPressure(ch uint8, note int, value uint8) []ControlMsg {
+	return m.changes(ch, m.poly[note], float32(value)/127.0)
+}
+
+// listens reports whether a parameter's mappings respond on channel ch.
+func (m *Mapper) listens(name string, ch uint8) bool {
+	c, ok := m.channels[name]
+	return !ok || c == ch
+}
+
+// changes returns a change to value, shaped by the mapping options, for
+// each named parameter that listens on channel ch.
+func (m *Mapper) changes(ch uint8, names []string, value float32) []ControlMsg {
+	if len(names) == 0 {
+		return nil
+	}
+	msgs := make([]ControlMsg, 0, len(names))
+	for _, name := range names {
+		if !m.listens(name, ch) {
+			continue
+		}
+		s := m.shapes[name]
+		msgs = append(msgs, ControlMsg{
+			Param:    name,
+			Value:    s.opts.Shape(s.param, value),
+			Takeover: m.takeover[name],
+		})
+	}
+	return msgs
+}
+
+// unshape converts a parameter value back to the position of its control.
+func (m *Mapper) unshape(name string, value float32) float32 {
+	if s, ok := m.shapes[name]; ok {
+		return s.opts.Unshape(s.param, value)
+	}
+	return value
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// from14Bit normalizes a 14-bit value to 0-1.
+func from14Bit(value uint16) float32 {
+	return float32(value) / max14Bit
+}
+
+// NoteOn returns the changes for a note on message on channel ch with
+// non-zero velocity.
+func (m *Mapper) NoteOn(ch uint8, note int, velocity uint8) []ControlMsg {
+	var msgs []ControlMsg
+	for _, target := range m.notes[note] {
+		if !m.listens(target.param.Name, ch) {
+			continue
+		}
+		value := float32(1)
+		if target.velocity {
+			value = float32(velocity) / 127.0
+		}
+		msgs = append(msgs, ControlMsg{
+			Param:  target.param.Name,
+			Value:  value,
+			Toggle: target.mode == config.NoteModeToggle,
+		})
+	}
+	return msgs
+}
+
+// NoteOff returns the changes for a note off message on channel ch. Only
+// momentary mappings respond, and only for parameters that have an off
+// state.
+func (m *Mapper) NoteOff(ch uint8, note int) []ControlMsg {
+	var msgs []ControlMsg
+	for _, target := range m.notes[note] {
+		if target.mode != config.NoteModeMomentary || !m.listens(target.param.Name, ch) {
+			continue
+		}
+		switch target.param.Kind {
+		case config.ParamOption, config.ParamMove:
+			continue
+		}
+		msgs = append(msgs, ControlMsg{Param: target.param.Name, Value: 0})
+	}
+	return msgs
+}
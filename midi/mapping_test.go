@@ -0,0 +1,322 @@
+package midi
+
+import (
+	"testing"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+func TestMapper_CC(t *testing.T) {
+	mapper := NewMapper(config.DefaultConfig())
+
+	msgs := mapper.CC(0, 1, 127)
+	if len(msgs) != 1 || msgs[0].Param != "gain" || msgs[0].Value != 1 {
+		t.Errorf("expected CC 1 to set gain to 1, got %+v", msgs)
+	}
+
+	if msgs := mapper.CC(0, 100, 64); len(msgs) != 0 {
+		t.Errorf("expected unmapped CC to resolve to nothing, got %+v", msgs)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Options = map[string]config.MappingOptions{"gain": {Takeover: config.TakeoverPickup}}
+	msgs = NewMapper(cfg).CC(0, 1, 64)
+	if len(msgs) != 1 || msgs[0].Takeover != config.TakeoverPickup {
+		t.Errorf("expected CC 1 to carry pickup takeover, got %+v", msgs)
+	}
+}
+
+func TestMapper_Channels(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.CC["delay_mix"] = 1
+	cfg.Notes["filter_enabled"] = 60
+	cfg.Options = map[string]config.MappingOptions{
+		"gain":           {Channel: 1},
+		"delay_mix":      {Channel: 2},
+		"filter_enabled": {Channel: 16},
+	}
+	mapper := NewMapper(cfg)
+
+	if msgs := mapper.CC(0, 1, 127); len(msgs) != 1 || msgs[0].Param != "gain" {
+		t.Errorf("expected CC 1 on channel 1 to set gain only, got %+v", msgs)
+	}
+	if msgs := mapper.CC(1, 1, 127); len(msgs) != 1 || msgs[0].Param != "delay_mix" {
+		t.Errorf("expected CC 1 on channel 2 to set delay_mix only, got %+v", msgs)
+	}
+	if msgs := mapper.CC(2, 1, 127); len(msgs) != 0 {
+		t.Errorf("expected CC 1 on channel 3 to resolve to nothing, got %+v", msgs)
+	}
+	// input_frozen has no channel, so it responds on every one
+	if msgs := mapper.NoteOn(15, 60, 100); len(msgs) != 2 {
+		t.Errorf("expected note 60 on channel 16 to drive both notes, got %+v", msgs)
+	}
+	if msgs := mapper.NoteOn(0, 60, 100); len(msgs) != 1 || msgs[0].Param != "input_frozen" {
+		t.Errorf("expected note 60 on channel 1 to drive input_frozen only, got %+v", msgs)
+	}
+
+	var ch, cc, val uint8
+	if msgs := mapper.Feedback("delay_mix", 1); len(msgs) != 1 || !msgs[0].GetControlChange(&ch, &cc, &val) || ch != 1 {
+		t.Errorf("expected feedback for delay_mix on channel 2, got %v", msgs)
+	}
+}
+
+func TestMapper_NoteOn(t *testing.T) {
+	mapper := NewMapper(config.DefaultConfig())
+
+	msgs := mapper.NoteOn(0, 65, 100)
+	if len(msgs) != 1 || msgs[0].Param != "blend_mode_complement" || msgs[0].Toggle {
+		t.Errorf("expected note 65 to trigger complement, got %+v", msgs)
+	}
+
+	// Freeze notes default to toggle mode
+	msgs = mapper.NoteOn(0, 60, 100)
+	if len(msgs) != 1 || msgs[0].Param != "input_frozen" || !msgs[0].Toggle {
+		t.Errorf("expected note 60 to toggle input freeze, got %+v", msgs)
+	}
+	if msgs := mapper.NoteOff(0, 60); len(msgs) != 0 {
+		t.Errorf("expected toggle note off to be ignored, got %+v", msgs)
+	}
+}
+
+func TestMapper_NoteModes(t *testing.T) {
+	cfg := config.Config{
+		Notes: map[string]int{
+			"granular_frozen":   62,
+			"granular_mix":      63,
+			"blend_mode_mirror": 64,
+			"filter_enabled":    65,
+		},
+		Options: map[string]config.MappingOptions{
+			"granular_frozen":   {Mode: config.NoteModeMomentary},
+			"granular_mix":      {Mode: config.NoteModeMomentary, Velocity: true},
+			"blend_mode_mirror": {Mode: config.NoteModeMomentary},
+			"filter_enabled":    {Mode: config.NoteModeTrigger},
+		},
+	}
+	mapper := NewMapper(cfg)
+
+	msgs := mapper.NoteOn(0, 62, 10)
+	if len(msgs) != 1 || msgs[0].Value != 1 || msgs[0].Toggle {
+		t.Errorf("expected momentary note on to set 1, got %+v", msgs)
+	}
+	msgs = mapper.NoteOff(0, 62)
+	if len(msgs) != 1 || msgs[0].Value != 0 {
+		t.Errorf("expected momentary note off to set 0, got %+v", msgs)
+	}
+
+	msgs = mapper.NoteOn(0, 63, 127)
+	if len(msgs) != 1 || msgs[0].Value != 1 {
+		t.Errorf("expected velocity 127 to set 1, got %+v", msgs)
+	}
+	msgs = mapper.NoteOn(0, 63, 0x40)
+	if len(msgs) != 1 || msgs[0].Value != float32(0x40)/127 {
+		t.Errorf("expected velocity to set the value, got %+v", msgs)
+	}
+
+	// Options have no off state, so momentary note off does nothing
+	if msgs := mapper.NoteOff(0, 64); len(msgs) != 0 {
+		t.Errorf("expected option note off to be ignored, got %+v", msgs)
+	}
+
+	msgs = mapper.NoteOn(0, 65, 100)
+	if len(msgs) != 1 || msgs[0].Toggle || msgs[0].Value != 1 {
+		t.Errorf("expected trigger note on to set on, got %+v", msgs)
+	}
+	if msgs := mapper.NoteOff(0, 65); len(msgs) != 0 {
+		t.Errorf("expected trigger note off to be ignored, got %+v", msgs)
+	}
+}
+
+func TestMapper_LegacyNames(t *testing.T) {
+	cfg := config.Config{
+		CC:    map[string]int{"input_freeze_len": 2, "input_freeze_length": 2},
+		Notes: map[string]int{"input_freeze": 60, "unknown": 61},
+	}
+	mapper := NewMapper(cfg)
+
+	msgs := mapper.CC(0, 2, 0)
+	if len(msgs) != 1 || msgs[0].Param != "input_freeze_length" {
+		t.Errorf("expected legacy and current names to collapse to one mapping, got %+v", msgs)
+	}
+
+	msgs = mapper.NoteOn(0, 60, 100)
+	if len(msgs) != 1 || msgs[0].Param != "input_frozen" {
+		t.Errorf("expected input_freeze to resolve to input_frozen, got %+v", msgs)
+	}
+
+	if msgs := mapper.NoteOn(0, 61, 100); len(msgs) != 0 {
+		t.Errorf("expected unknown name to be ignored, got %+v", msgs)
+	}
+}
+
+func TestMapper_CC14(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.CC14 = map[string]int{"filter_cutoff": 4}
+	mapper := NewMapper(cfg)
+
+	// MSB alone resets the LSB
+	msgs := mapper.CC(0, 4, 64)
+	if len(msgs) != 1 || msgs[0].Param != "filter_cutoff" || msgs[0].Value != float32(64<<7)/16383 {
+		t.Errorf("expected MSB to set filter_cutoff, got %+v", msgs)
+	}
+
+	msgs = mapper.CC(0, 36, 1)
+	if len(msgs) != 1 || msgs[0].Value != float32(64<<7|1)/16383 {
+		t.Errorf("expected LSB to refine filter_cutoff, got %+v", msgs)
+	}
+
+	// One 14-bit step is finer than a 7-bit one
+	param, _ := config.LookupParam("filter_cutoff")
+	step := param.Scale(float32(64<<7|1)/16383) - param.Scale(float32(64<<7)/16383)
+	if step <= 0 || step >= 1 {
+		t.Errorf("expected a sub-hertz cutoff step, got %f", step)
+	}
+
+	// The pair's controllers no longer drive the default 7-bit mapping
+	cfg.CC14 = map[string]int{"gain": 1}
+	if msgs := NewMapper(cfg).CC(0, 1, 10); len(msgs) != 1 || msgs[0].Value != float32(10<<7)/16383 {
+		t.Errorf("expected CC 1 to be the gain MSB only, got %+v", msgs)
+	}
+}
+
+func TestMapper_NRPN(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.NRPN = map[string]int{"filter_cutoff": 1<<7 | 2}
+	cfg.RPN = map[string]int{"gain": 0}
+	mapper := NewMapper(cfg)
+
+	if msgs := mapper.CC(0, 99, 1); len(msgs) != 0 {
+		t.Errorf("expected parameter select to produce nothing, got %+v", msgs)
+	}
+	mapper.CC(0, 98, 2)
+
+	msgs := mapper.CC(0, 6, 127)
+	if len(msgs) != 1 || msgs[0].Param != "filter_cutoff" || msgs[0].Value != float32(127<<7)/16383 {
+		t.Errorf("expected data entry MSB to set filter_cutoff, got %+v", msgs)
+	}
+	msgs = mapper.CC(0, 38, 127)
+	if len(msgs) != 1 || msgs[0].Value != 1 {
+		t.Errorf("expected data entry LSB to complete filter_cutoff, got %+v", msgs)
+	}
+	msgs = mapper.CC(0, 97, 0)
+	if len(msgs) != 1 || msgs[0].Value != float32(16382)/16383 {
+		t.Errorf("expected data decrement to lower filter_cutoff, got %+v", msgs)
+	}
+
+	mapper.CC(0, 101, 0)
+	mapper.CC(0, 100, 0)
+	msgs = mapper.CC(0, 6, 64)
+	if len(msgs) != 1 || msgs[0].Param != "gain" {
+		t.Errorf("expected RPN 0 to set gain, got %+v", msgs)
+	}
+
+	// The RPN null parameter deselects
+	mapper.CC(0, 101, 127)
+	mapper.CC(0, 100, 127)
+	if msgs := mapper.CC(0, 6, 64); len(msgs) != 0 {
+		t.Errorf("expected data entry after RPN null to be ignored, got %+v", msgs)
+	}
+}
+
+func TestMapper_ProgramChange(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ProgramChanges = map[string]string{"3": "clean", "2:3": "wash"}
+	mapper := NewMapper(cfg)
+
+	if preset, ok := mapper.ProgramChange(3); !ok || preset.Name != "clean" {
+		t.Errorf("expected program 3 to load clean, got %+v", preset)
+	}
+
+	// Bank select MSB 0, LSB 2 is bank 2
+	mapper.CC(0, 0, 0)
+	mapper.CC(0, 32, 2)
+	if preset, ok := mapper.ProgramChange(3); !ok || preset.Name != "wash" {
+		t.Errorf("expected bank 2 program 3 to load wash, got %+v", preset)
+	}
+
+	cfg.ProgramChanges = nil
+	cfg.ProgramChangeMode = config.ProgramChangeIndex
+	mapper = NewMapper(cfg)
+	mapper.presets = func() ([]string, error) { return []string{"b", "a"}, nil }
+	if preset, ok := mapper.ProgramChange(1); !ok || preset.Name != "b" {
+		t.Errorf("expected program 1 to load the second preset, got %+v", preset)
+	}
+}
+
+func TestMapper_PitchBend(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.PitchBend = []string{"granular_pitch_scatter", "delay_mix"}
+	cfg.Options = map[string]config.MappingOptions{
+		"granular_pitch_scatter": {ReturnToCenter: true, Range: []float32{0, 0.5}},
+	}
+	mapper := NewMapper(cfg)
+
+	msgs := mapper.PitchBend(0, max14Bit)
+	if len(msgs) != 2 {
+		t.Fatalf("expected two changes, got %+v", msgs)
+	}
+	// Sorted by name: delay_mix is absolute, pitch scatter is an offset
+	if msgs[0].Param != "delay_mix" || msgs[0].Value != 1 || msgs[0].Bend {
+		t.Errorf("expected full bend to set delay_mix to 1, got %+v", msgs[0])
+	}
+	if msgs[1].Param != "granular_pitch_scatter" || !msgs[1].Bend || msgs[1].Value != 0.5 {
+		t.Errorf("expected full bend to offset pitch scatter by its range, got %+v", msgs[1])
+	}
+
+	msgs = mapper.PitchBend(0, 0)
+	if msgs[1].Value != -0.5 {
+		t.Errorf("expected full bend down to offset by -0.5, got %+v", msgs[1])
+	}
+	msgs = mapper.PitchBend(0, bendCenter)
+	if msgs[1].Value != 0 || msgs[0].Value != float32(bendCenter)/max14Bit {
+		t.Errorf("expected center to clear the offset, got %+v", msgs)
+	}
+}
+
+func TestMapper_Pressure(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Pressure = []string{"granular_pitch_scatter"}
+	cfg.PolyPressure = map[string]int{"reverb_mix": 60}
+	cfg.Options = map[string]config.MappingOptions{
+		"granular_pitch_scatter": {Curve: config.CurveExponential},
+	}
+	mapper := NewMapper(cfg)
+
+	msgs := mapper.Pressure(0, 127)
+	if len(msgs) != 1 || msgs[0].Param != "granular_pitch_scatter" || msgs[0].Value != 1 {
+		t.Errorf("expected full pressure to set pitch scatter, got %+v", msgs)
+	}
+	if msgs := mapper.Pressure(0, 64); msgs[0].Value >= float32(64)/127 {
+		t.Errorf("expected the exponential curve to lower half pressure, got %f", msgs[0].Value)
+	}
+
+	if msgs := mapper.PolyPressure(0, 60, 127); len(msgs) != 1 || msgs[0].Param != "reverb_mix" {
+		t.Errorf("expected note 60 pressure to set reverb_mix, got %+v", msgs)
+	}
+	if msgs := mapper.PolyPressure(0, 61, 127); len(msgs) != 0 {
+		t.Errorf("expected note 61 pressure to be unmapped, got %+v", msgs)
+	}
+}
+
+func TestMapper_ModWheelAndShapedFeedback(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ModWheel = []string{"granular_mix"}
+	cfg.Options = map[string]config.MappingOptions{
+		"granular_mix": {Range: []float32{0.5, 1}},
+	}
+	mapper := NewMapper(cfg)
+
+	// The mod wheel replaces the default gain mapping on CC 1
+	msgs := mapper.CC(0, ccModWheel, 0)
+	if len(msgs) != 1 || msgs[0].Param != "granular_mix" || msgs[0].Value != 0.5 {
+		t.Errorf("expected the mod wheel to set granular_mix from 0.5, got %+v", msgs)
+	}
+
+	// Feedback maps the value back into the wheel's travel. The default
+	// CC 8 mapping shares the parameter's options.
+	fb := mapper.Feedback("granular_mix", 0.75)
+	var ch, cc, val uint8
+	if len(fb) != 2 || !fb[0].GetControlChange(&ch, &cc, &val) || cc != ccModWheel || val != 64 {
+		t.Errorf("expected feedback at the middle of the wheel, got %v", fb)
+	}
+}
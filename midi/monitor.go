@@ -0,0 +1,91 @@
+package midi
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// MonitorMsg describes an incoming MIDI message for the MIDI monitor.
+// Channel is 1-16, or 0 for system messages. Number and Value are -1 when
+// the message has none. Resolved lists what the message was mapped to, and
+// is empty when it was unmapped.
+type MonitorMsg struct {
+	Time     time.Time
+	Port     string
+	Channel  int
+	Type     string
+	Number   int
+	Value    int
+	Resolved []string
+	Echo     bool // Dropped as an echo of controller feedback
+}
+
+// receive handles msg from port and adds an entry describing it for the
+// MIDI monitor. Timing clock is too frequent to be worth logging.
+func (d *device) receive(port string, msg midi.Message, now time.Time) []tea.Msg {
+	if msg.Is(midi.TimingClockMsg) {
+		return d.handle(msg, now)
+	}
+	echo := d.filter.isEcho(msg, now)
+	msgs := d.handle(msg, now)
+	return append(msgs, describe(port, msg, now, msgs, echo))
+}
+
+// describe returns the monitor entry for msg given what it resolved to.
+func describe(port string, msg midi.Message, now time.Time, resolved []tea.Msg, echo bool) MonitorMsg {
+	entry := MonitorMsg{
+		Time:   now,
+		Port:   port,
+		Type:   msg.Type().String(),
+		Number: -1,
+		Value:  -1,
+		Echo:   echo,
+	}
+
+	var ch, number, value uint8
+	var rel int16
+	var bend, spp uint16
+	switch {
+	case msg.GetControlChange(&ch, &number, &value):
+		entry.Type = "CC"
+		entry.Number, entry.Value = int(number), int(value)
+	case msg.GetNoteOn(&ch, &number, &value):
+		entry.Type = "Note On"
+		entry.Number, entry.Value = int(number), int(value)
+	case msg.GetNoteOff(&ch, &number, &value):
+		entry.Type = "Note Off"
+		entry.Number, entry.Value = int(number), int(value)
+	case msg.GetProgramChange(&ch, &number):
+		entry.Type = "Program"
+		entry.Number = int(number)
+	case msg.GetPitchBend(&ch, &rel, &bend):
+		entry.Type = "Pitch Bend"
+		entry.Value = int(bend)
+	case msg.GetAfterTouch(&ch, &value):
+		entry.Type = "Pressure"
+		entry.Value = int(value)
+	case msg.GetPolyAfterTouch(&ch, &number, &value):
+		entry.Type = "Poly Pressure"
+		entry.Number, entry.Value = int(number), int(value)
+	case msg.GetSPP(&spp):
+		entry.Type = "Song Position"
+		entry.Value = int(spp)
+	}
+	if msg.Is(midi.ChannelMsg) {
+		entry.Channel = int(ch) + 1
+	}
+
+	for _, r := range resolved {
+		switch r := r.(type) {
+		case ControlMsg:
+			entry.Resolved = append(entry.Resolved, r.Param)
+		case PresetMsg:
+			entry.Resolved = append(entry.Resolved, "preset "+r.Name)
+		case ClockMsg:
+			entry.Resolved = append(entry.Resolved, "clock")
+		}
+	}
+	return entry
+}
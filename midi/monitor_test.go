@@ -0,0 +1,61 @@
+package midi
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+func TestDevice_ReceiveDescribesMessages(t *testing.T) {
+	d := newDevice("", config.DefaultConfig())
+	now := time.Now()
+
+	msgs := d.receive("nanoKONTROL2", midi.ControlChange(2, 1, 100), now)
+	if len(msgs) != 2 {
+		t.Fatalf("expected a control and a monitor message, got %+v", msgs)
+	}
+	entry := msgs[1].(MonitorMsg)
+	if entry.Port != "nanoKONTROL2" || entry.Channel != 3 || entry.Type != "CC" || entry.Number != 1 || entry.Value != 100 {
+		t.Errorf("unexpected monitor entry %+v", entry)
+	}
+	if len(entry.Resolved) != 1 || entry.Resolved[0] != "gain" {
+		t.Errorf("expected CC 1 to resolve to gain, got %v", entry.Resolved)
+	}
+
+	// Unmapped messages are still logged
+	msgs = d.receive("nanoKONTROL2", midi.ControlChange(0, 90, 1), now)
+	if len(msgs) != 1 || len(msgs[0].(MonitorMsg).Resolved) != 0 {
+		t.Errorf("expected an unresolved monitor entry, got %+v", msgs)
+	}
+
+	msgs = d.receive("nanoKONTROL2", midi.Pitchbend(0, 0), now)
+	if entry := msgs[len(msgs)-1].(MonitorMsg); entry.Type != "Pitch Bend" || entry.Value != 8192 || entry.Number != -1 {
+		t.Errorf("unexpected pitch bend entry %+v", entry)
+	}
+
+	// Timing clock is not logged
+	if msgs := d.receive("nanoKONTROL2", midi.TimingClock(), now); len(msgs) != 0 {
+		t.Errorf("expected no monitor entry for timing clock, got %+v", msgs)
+	}
+	msgs = d.receive("nanoKONTROL2", midi.Start(), now)
+	if entry := msgs[len(msgs)-1].(MonitorMsg); entry.Channel != 0 || len(entry.Resolved) != 1 || entry.Resolved[0] != "clock" {
+		t.Errorf("unexpected start entry %+v", entry)
+	}
+}
+
+func TestDevice_ReceiveMarksEchoes(t *testing.T) {
+	d := newDevice("", config.DefaultConfig())
+	now := time.Now()
+	d.filter.outgoing([]midi.Message{midi.ControlChange(0, 1, 64)}, true, now)
+
+	msgs := d.receive("in", midi.ControlChange(0, 1, 64), now.Add(time.Millisecond))
+	if len(msgs) != 1 {
+		t.Fatalf("expected only a monitor entry for an echo, got %+v", msgs)
+	}
+	if entry := msgs[0].(MonitorMsg); !entry.Echo || len(entry.Resolved) != 0 {
+		t.Errorf("expected an echo entry, got %+v", entry)
+	}
+}
@@ -0,0 +1,198 @@
+package midi
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gitlab.com/gomidi/midi/v2"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// progressInterval is how often a playing Player reports its position.
+const progressInterval = 250 * time.Millisecond
+
+// PlaybackMsg reports the state of MIDI file playback.
+type PlaybackMsg struct {
+	File     string
+	Position time.Duration
+	Length   time.Duration
+	Playing  bool
+	Loop     bool
+}
+
+// Player plays the CC and note events of a MIDI file through a mapping
+// profile, delivering the same messages a controller sending them would.
+// Events are timed through the file's tempo map, and a pass lasts until the
+// end of the file's longest track.
+type Player struct {
+	file   string
+	events []Timed
+	length time.Duration
+	send   func(tea.Msg)
+	dev    *device
+
+	mu      sync.Mutex
+	loop    bool
+	stop    chan struct{} // Closed to stop playback, nil while stopped
+	started time.Time     // Start of the current pass
+}
+
+// NewPlayer reads the MIDI file at path for playback through cfg's
+// mappings, with resolved messages delivered to send. With [devices] in cfg
+// it plays through the first device profile by name. send is called from
+// the playback goroutine only.
+func NewPlayer(path string, cfg config.Config, send func(tea.Msg)) (*Player, error) {
+	events, length, err := ReadMIDIFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Player{
+		file:   filepath.Base(path),
+		length: length,
+		send:   send,
+		dev:    newDevices(cfg)[0],
+	}
+	for _, ev := range events {
+		if ev.Msg.Is(midi.ControlChangeMsg) || ev.Msg.Is(midi.NoteOnMsg) || ev.Msg.Is(midi.NoteOffMsg) {
+			p.events = append(p.events, ev)
+		}
+	}
+	if len(p.events) == 0 {
+		return nil, errors.New("no CC or note events")
+	}
+	return p, nil
+}
+
+// SetConfig replaces the mappings events play through with those of the
+// player's profile in cfg.
+func (p *Player) SetConfig(cfg config.Config) {
+	applyConfig([]*device{p.dev}, cfg)
+}
+
+// SetLoop sets whether playback restarts when it reaches the end.
+func (p *Player) SetLoop(loop bool) {
+	p.mu.Lock()
+	p.loop = loop
+	p.mu.Unlock()
+}
+
+// Start plays the file from the beginning, restarting it if it is playing.
+func (p *Player) Start() {
+	p.Stop()
+	p.mu.Lock()
+	stop := make(chan struct{})
+	p.stop = stop
+	p.started = time.Now()
+	p.mu.Unlock()
+	go p.run(stop)
+}
+
+// Stop stops playback, releasing any notes it holds.
+func (p *Player) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// Status returns the playback state. Progress is reported to send only
+// while playing, so after SetLoop or Start the new state is read here.
+func (p *Player) Status() PlaybackMsg {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := PlaybackMsg{File: p.file, Length: p.length, Playing: p.stop != nil, Loop: p.loop}
+	if status.Playing {
+		status.Position = time.Since(p.started)
+		if status.Position > p.length {
+			status.Position = p.length
+		}
+	}
+	return status
+}
+
+func (p *Player) report() {
+	if p.send != nil {
+		p.send(p.Status())
+	}
+}
+
+func (p *Player) deliver(msg midi.Message) {
+	msgs := p.dev.receive(p.file, msg, time.Now())
+	if p.send != nil {
+		for _, msg := range msgs {
+			p.send(msg)
+		}
+	}
+}
+
+// run plays passes of the file until it ends without looping or stop is
+// closed.
+func (p *Player) run(stop chan struct{}) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	held := make(map[[2]uint8]bool) // Channel and key of notes on
+
+	defer func() {
+		// Momentary notes would otherwise stay on
+		for note := range held {
+			p.deliver(midi.NoteOff(note[0], note[1]))
+		}
+		p.mu.Lock()
+		if p.stop == stop {
+			p.stop = nil
+		}
+		p.mu.Unlock()
+		p.report()
+	}()
+
+	for {
+		p.mu.Lock()
+		start := p.started
+		p.mu.Unlock()
+
+		for _, ev := range p.events {
+			if !p.wait(start.Add(ev.At), stop, ticker.C) {
+				return
+			}
+			var ch, key, vel uint8
+			switch {
+			case ev.Msg.GetNoteOn(&ch, &key, &vel) && vel > 0:
+				held[[2]uint8{ch, key}] = true
+			case ev.Msg.GetNoteOn(&ch, &key, &vel), ev.Msg.GetNoteOff(&ch, &key, &vel):
+				delete(held, [2]uint8{ch, key})
+			}
+			p.deliver(ev.Msg)
+		}
+
+		p.mu.Lock()
+		loop := p.loop && p.length > 0
+		p.started = start.Add(p.length)
+		p.mu.Unlock()
+		if !loop {
+			return
+		}
+	}
+}
+
+// wait sleeps until t, reporting progress on each tick. It returns false if
+// stop is closed first.
+func (p *Player) wait(t time.Time, stop chan struct{}, tick <-chan time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-tick:
+			p.report()
+		case <-timer.C:
+			return true
+		}
+	}
+}
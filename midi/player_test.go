@@ -0,0 +1,223 @@
+package midi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+func writeTake(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "take.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// collect gathers a player's gain values until it reports stopping.
+func collect(t *testing.T, msgs chan tea.Msg) []float32 {
+	t.Helper()
+	var values []float32
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-msgs:
+			switch msg := msg.(type) {
+			case ControlMsg:
+				if msg.Param == "gain" {
+					values = append(values, msg.Value)
+				}
+			case PlaybackMsg:
+				if !msg.Playing {
+					return values
+				}
+			}
+		case <-timeout:
+			t.Fatalf("timed out with gain values %v", values)
+		}
+	}
+}
+
+func TestPlayer_PlaysThroughMappings(t *testing.T) {
+	// The program change is dropped, only CCs and notes play
+	path := writeTake(t, "0 B0 01 7F\n0.02 C0 05\n0.05 B0 01 00\n")
+	msgs := make(chan tea.Msg, 100)
+	p, err := NewPlayer(path, config.DefaultConfig(), func(msg tea.Msg) { msgs <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := p.Status(); status.File != "take.txt" || status.Length != 50*time.Millisecond || status.Playing {
+		t.Fatalf("unexpected status before playing: %+v", status)
+	}
+
+	p.Start()
+	if !p.Status().Playing {
+		t.Error("expected the player to be playing")
+	}
+	if values := collect(t, msgs); len(values) != 2 || values[0] != 1 || values[1] != 0 {
+		t.Errorf("expected gain 1 then 0, got %v", values)
+	}
+	for len(msgs) > 0 {
+		if _, ok := (<-msgs).(PresetMsg); ok {
+			t.Error("expected program changes to be skipped")
+		}
+	}
+}
+
+func TestPlayer_Loops(t *testing.T) {
+	path := writeTake(t, "0 B0 01 7F\n0.01 B0 01 00\n")
+	msgs := make(chan tea.Msg, 100)
+	p, err := NewPlayer(path, config.DefaultConfig(), func(msg tea.Msg) { msgs <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetLoop(true)
+	if !p.Status().Loop {
+		t.Errorf("expected loop to be set, got %+v", p.Status())
+	}
+	p.Start()
+	time.Sleep(35 * time.Millisecond)
+	p.Stop()
+	if values := collect(t, msgs); len(values) < 4 {
+		t.Errorf("expected at least two passes, got gain values %v", values)
+	}
+	if p.Status().Playing {
+		t.Error("expected the player to stop")
+	}
+}
+
+func TestPlayer_StopReleasesNotes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Options = map[string]config.MappingOptions{"input_frozen": {Mode: config.NoteModeMomentary}}
+	path := writeTake(t, "0 90 3C 7F\n10 80 3C 00\n")
+	msgs := make(chan tea.Msg, 100)
+	p, err := NewPlayer(path, cfg, func(msg tea.Msg) { msgs <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Start()
+	time.Sleep(10 * time.Millisecond)
+	p.Stop()
+
+	var frozen []float32
+	timeout := time.After(time.Second)
+	for len(frozen) < 2 {
+		select {
+		case msg := <-msgs:
+			if c, ok := msg.(ControlMsg); ok && c.Param == "input_frozen" {
+				frozen = append(frozen, c.Value)
+			}
+		case <-timeout:
+			t.Fatalf("expected the held note to be released, got %v", frozen)
+		}
+	}
+	if frozen[0] != 1 || frozen[1] != 0 {
+		t.Errorf("expected input_frozen on then off, got %v", frozen)
+	}
+}
+
+func TestPlayer_ControlsDontWaitForSend(t *testing.T) {
+	// Nothing reads msgs, as when the model is busy calling the player
+	path := writeTake(t, "0 B0 01 7F\n0.01 B0 01 00\n")
+	msgs := make(chan tea.Msg)
+	p, err := NewPlayer(path, config.DefaultConfig(), func(msg tea.Msg) { msgs <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.SetLoop(true)
+		p.Start()
+		p.SetLoop(false)
+		p.Start()
+		p.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected SetLoop, Start and Stop not to wait for send")
+	}
+	go func() {
+		for range msgs {
+		}
+	}()
+}
+
+func TestPlayer_UsesDeviceProfile(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Devices = map[string]config.Config{
+		"pads": {InputPort: "MPD218", CC: map[string]int{"reverb_mix": 1}},
+	}
+	path := writeTake(t, "0 B0 01 7F\n")
+	msgs := make(chan tea.Msg, 100)
+	p, err := NewPlayer(path, cfg, func(msg tea.Msg) { msgs <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() string {
+		p.Start()
+		timeout := time.After(2 * time.Second)
+		for {
+			select {
+			case msg := <-msgs:
+				if c, ok := msg.(ControlMsg); ok {
+					return c.Param
+				}
+			case <-timeout:
+				t.Fatal("timed out waiting for a control")
+			}
+		}
+	}
+	if param := next(); param != "reverb_mix" {
+		t.Errorf("expected the device profile's mapping, got %s", param)
+	}
+
+	cfg.Devices["pads"] = config.Config{InputPort: "MPD218", CC: map[string]int{"delay_mix": 1}}
+	p.SetConfig(cfg)
+	if param := next(); param != "delay_mix" {
+		t.Errorf("expected the edited profile's mapping, got %s", param)
+	}
+}
+
+func TestNewPlayer_LengthIsEndOfTrack(t *testing.T) {
+	ticks := smf.MetricTicks(960)
+	var tr smf.Track
+	tr.Add(0, smf.MetaTempo(120))
+	tr.Add(0, midi.ControlChange(0, 1, 10))
+	tr.Close(4 * ticks.Ticks4th())
+	file := smf.New()
+	file.TimeFormat = ticks
+	file.Add(tr)
+	path := filepath.Join(t.TempDir(), "bar.mid")
+	if err := file.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bar with one event at its start loops a whole bar
+	p, err := NewPlayer(path, config.DefaultConfig(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if length := p.Status().Length; length != 2*time.Second {
+		t.Errorf("expected the bar's length of 2s, got %v", length)
+	}
+}
+
+func TestNewPlayer_NeedsEvents(t *testing.T) {
+	if _, err := NewPlayer(writeTake(t, "0 C0 05\n"), config.DefaultConfig(), nil); err == nil {
+		t.Error("expected an error for a file without CC or note events")
+	}
+	if _, err := NewPlayer(filepath.Join(t.TempDir(), "missing.mid"), config.DefaultConfig(), nil); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,166 @@
+package midi
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Source is a MIDI driver: it lists the ports present, delivers the
+// messages arriving on an input port and sends to an output port. The
+// Handler polls a source's port lists to follow devices being plugged in and
+// resolves every message through the same devices and Mappers, so mappings
+// behave the same whichever source the messages come from.
+type Source interface {
+	Inputs() ([]string, error)
+	Outputs() ([]string, error)
+
+	// Listen calls fn, from any goroutine, with each message arriving on
+	// the named input port until stop is called.
+	Listen(port string, fn func(midi.Message)) (stop func(), err error)
+
+	// Open opens the named output port.
+	Open(port string) (send func(midi.Message) error, close func(), err error)
+}
+
+// DefaultSource returns the platform's MIDI driver: rtmidi when built with
+// CGO, raw ALSA MIDI devices on Linux without it, or nil when there is none.
+func DefaultSource() Source {
+	return defaultSource()
+}
+
+// parseStream splits a raw MIDI byte stream into messages, following
+// running status and real-time bytes interleaved with other messages.
+// System exclusive messages are dropped.
+func parseStream(fn func(midi.Message)) *drivers.Reader {
+	return drivers.NewReader(drivers.ListenConfig{TimeCode: true}, func(data []byte, _ int32) {
+		fn(midi.Message(append([]byte(nil), data...)))
+	})
+}
+
+// Injector is an in-memory Source for tests and tools. Messages injected on
+// a port are delivered synchronously to its listener, and messages sent to
+// an output port are recorded.
+type Injector struct {
+	mu      sync.Mutex
+	inputs  map[string]func(midi.Message) // Listener per plugged in port, nil until listened to
+	outputs map[string]bool
+	sent    map[string][]midi.Message
+}
+
+// NewInjector returns an Injector with the given input ports plugged in.
+func NewInjector(inputs ...string) *Injector {
+	i := &Injector{
+		inputs:  make(map[string]func(midi.Message)),
+		outputs: make(map[string]bool),
+		sent:    make(map[string][]midi.Message),
+	}
+	for _, port := range inputs {
+		i.inputs[port] = nil
+	}
+	return i
+}
+
+// Plug adds an input port, as if a device had been plugged in.
+func (i *Injector) Plug(port string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.inputs[port]; !ok {
+		i.inputs[port] = nil
+	}
+}
+
+// PlugOutput adds an output port.
+func (i *Injector) PlugOutput(port string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.outputs[port] = true
+}
+
+// Unplug removes an input or output port, as if a device had been
+// unplugged. Its listener stops receiving messages.
+func (i *Injector) Unplug(port string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.inputs, port)
+	delete(i.outputs, port)
+}
+
+// Inject delivers msg to the listener on port. It reports whether the port
+// was being listened to.
+func (i *Injector) Inject(port string, msg midi.Message) bool {
+	i.mu.Lock()
+	fn := i.inputs[port]
+	i.mu.Unlock()
+	if fn == nil {
+		return false
+	}
+	fn(msg)
+	return true
+}
+
+// Sent returns the messages sent to an output port.
+func (i *Injector) Sent(port string) []midi.Message {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]midi.Message(nil), i.sent[port]...)
+}
+
+func (i *Injector) Inputs() ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return sortedKeys(i.inputs), nil
+}
+
+func (i *Injector) Outputs() ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return sortedKeys(i.outputs), nil
+}
+
+func (i *Injector) Listen(port string, fn func(midi.Message)) (func(), error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.inputs[port]; !ok {
+		return nil, errNoPort(port)
+	}
+	i.inputs[port] = fn
+	return func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		if _, ok := i.inputs[port]; ok {
+			i.inputs[port] = nil
+		}
+	}, nil
+}
+
+func (i *Injector) Open(port string) (func(midi.Message) error, func(), error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.outputs[port] {
+		return nil, nil, errNoPort(port)
+	}
+	send := func(msg midi.Message) error {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		i.sent[port] = append(i.sent[port], msg)
+		return nil
+	}
+	return send, func() {}, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func errNoPort(port string) error {
+	return fmt.Errorf("no MIDI port %q", port)
+}
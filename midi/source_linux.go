@@ -0,0 +1,7 @@
+//go:build !cgo && linux
+
+package midi
+
+func defaultSource() Source {
+	return rawmidiSource{dev: "/dev/snd", proc: "/proc/asound"}
+}
@@ -0,0 +1,8 @@
+//go:build !cgo && !linux
+
+package midi
+
+// Without CGO there is only a pure Go driver for Linux.
+func defaultSource() Source {
+	return nil
+}
@@ -0,0 +1,123 @@
+package midi
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// rawmidiSource reads and writes ALSA raw MIDI devices, /dev/snd/midiC*D*,
+// as plain files, so MIDI works on Linux without CGO. Each device is both an
+// input and an output port, named after its card so controller profile
+// suggestions and port matching work as they do with rtmidi.
+type rawmidiSource struct {
+	dev  string // Device directory, normally /dev/snd
+	proc string // ALSA proc directory for device names, normally /proc/asound
+}
+
+// devices returns the raw MIDI devices present, by port name.
+func (s rawmidiSource) devices() (map[string]string, error) {
+	entries, err := os.ReadDir(s.dev)
+	if err != nil {
+		return nil, fmt.Errorf("no ALSA devices: %w", err)
+	}
+	devices := make(map[string]string)
+	for _, entry := range entries {
+		var card, dev int
+		if _, err := fmt.Sscanf(entry.Name(), "midiC%dD%d", &card, &dev); err != nil {
+			continue
+		}
+		devices[s.portName(card, dev)] = filepath.Join(s.dev, entry.Name())
+	}
+	return devices, nil
+}
+
+// portName names a device after the first line of its proc entry, or its
+// card ID, followed by its ALSA hardware address.
+func (s rawmidiSource) portName(card, dev int) string {
+	hw := fmt.Sprintf("hw:%d,%d", card, dev)
+	for _, file := range []string{
+		filepath.Join(s.proc, fmt.Sprintf("card%d", card), fmt.Sprintf("midi%d", dev)),
+		filepath.Join(s.proc, fmt.Sprintf("card%d", card), "id"),
+	} {
+		if name := firstLine(file); name != "" {
+			return name + " " + hw
+		}
+	}
+	return hw
+}
+
+func firstLine(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		return strings.TrimSpace(scanner.Text())
+	}
+	return ""
+}
+
+func (s rawmidiSource) Inputs() ([]string, error) {
+	devices, err := s.devices()
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(devices), nil
+}
+
+func (s rawmidiSource) Outputs() ([]string, error) {
+	return s.Inputs()
+}
+
+func (s rawmidiSource) Listen(port string, fn func(midi.Message)) (func(), error) {
+	f, err := s.open(port, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	parser := parseStream(fn)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := f.Read(buf)
+			if n > 0 {
+				parser.EachMessage(buf[:n], 0)
+			}
+			// Closing the file or unplugging the device ends the read
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return func() { f.Close() }, nil
+}
+
+func (s rawmidiSource) Open(port string) (func(midi.Message) error, func(), error) {
+	f, err := s.open(port, os.O_WRONLY)
+	if err != nil {
+		return nil, nil, err
+	}
+	send := func(msg midi.Message) error {
+		_, err := f.Write(msg)
+		return err
+	}
+	return send, func() { f.Close() }, nil
+}
+
+func (s rawmidiSource) open(port string, flag int) (*os.File, error) {
+	devices, err := s.devices()
+	if err != nil {
+		return nil, err
+	}
+	path, ok := devices[port]
+	if !ok {
+		return nil, errNoPort(port)
+	}
+	return os.OpenFile(path, flag, 0)
+}
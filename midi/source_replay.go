@@ -0,0 +1,173 @@
+package midi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+// Timed is a MIDI message at a time from the start of a recording.
+type Timed struct {
+	At  time.Duration
+	Msg midi.Message
+}
+
+// ReadSMF reads the channel and system messages of a Standard MIDI File,
+// from every track, timed through its tempo map. The length is the end of
+// the longest track, which can be after its last message.
+func ReadSMF(r io.Reader) ([]Timed, time.Duration, error) {
+	var events []Timed
+	var length time.Duration
+	err := smf.ReadTracksFrom(r).Do(func(ev smf.TrackEvent) {
+		at := time.Duration(ev.AbsMicroSeconds) * time.Microsecond
+		if at > length {
+			length = at
+		}
+		if ev.Message.IsPlayable() {
+			events = append(events, Timed{At: at, Msg: midi.Message(ev.Message)})
+		}
+	}).Error()
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At < events[j].At })
+	return events, length, nil
+}
+
+// ReadMIDIText reads a text recording of MIDI messages: one line per
+// message with the time in seconds followed by its bytes in hex, such as
+// "1.5 B0 07 64". Blank lines and lines starting with # are skipped.
+// Running status is followed across lines.
+func ReadMIDIText(r io.Reader) ([]Timed, error) {
+	var events []Timed
+	var at time.Duration
+	parser := parseStream(func(msg midi.Message) {
+		events = append(events, Timed{At: at, Msg: msg})
+	})
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("line %d: invalid time %q", line, fields[0])
+		}
+		data, err := hex.DecodeString(strings.Join(fields[1:], ""))
+		if err != nil || len(data) == 0 {
+			return nil, fmt.Errorf("line %d: invalid MIDI bytes", line)
+		}
+		at = time.Duration(seconds * float64(time.Second))
+		parser.EachMessage(data, 0)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At < events[j].At })
+	return events, nil
+}
+
+// ReadMIDIFile reads a Standard MIDI File or a text recording, returning
+// its messages and length. A text recording ends at its last message.
+func ReadMIDIFile(path string) ([]Timed, time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if bytes.HasPrefix(data, []byte("MThd")) {
+		return ReadSMF(bytes.NewReader(data))
+	}
+	events, err := ReadMIDIText(bytes.NewReader(data))
+	if err != nil || len(events) == 0 {
+		return events, 0, err
+	}
+	return events, events[len(events)-1].At, nil
+}
+
+// ReplaySource is a Source with one input port that plays a recording in
+// real time each time it is listened to, for trying mappings without a
+// controller.
+type ReplaySource struct {
+	name   string
+	events []Timed
+
+	mu   sync.Mutex
+	done chan struct{} // Closed when the current playback ends
+}
+
+// NewReplaySource returns a source replaying the MIDI file at path on a
+// port named after the file.
+func NewReplaySource(path string) (*ReplaySource, error) {
+	events, _, err := ReadMIDIFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &ReplaySource{name: filepath.Base(path), events: events}, nil
+}
+
+// Done returns a channel closed when the current playback has finished or
+// been stopped, or nil before the port is listened to.
+func (s *ReplaySource) Done() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done
+}
+
+func (s *ReplaySource) Inputs() ([]string, error) {
+	return []string{s.name}, nil
+}
+
+func (s *ReplaySource) Outputs() ([]string, error) {
+	return nil, nil
+}
+
+func (s *ReplaySource) Listen(port string, fn func(midi.Message)) (func(), error) {
+	if port != s.name {
+		return nil, errNoPort(port)
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.done = done
+	s.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		start := time.Now()
+		for _, ev := range s.events {
+			if wait := time.Until(start.Add(ev.At)); wait > 0 {
+				select {
+				case <-stop:
+					return
+				case <-time.After(wait):
+				}
+			}
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			fn(ev.Msg)
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(stop) }) }, nil
+}
+
+func (s *ReplaySource) Open(port string) (func(midi.Message) error, func(), error) {
+	return nil, nil, errNoPort(port)
+}
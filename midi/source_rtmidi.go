@@ -0,0 +1,68 @@
+//go:build cgo
+
+package midi
+
+import (
+	"fmt"
+
+	"gitlab.com/gomidi/midi/v2"
+	_ "gitlab.com/gomidi/midi/v2/drivers/rtmididrv"
+)
+
+func defaultSource() Source {
+	return rtmidiSource{}
+}
+
+// rtmidiSource reads and writes ports through rtmidi, which covers ALSA,
+// CoreMIDI and Windows MIDI.
+type rtmidiSource struct{}
+
+func (rtmidiSource) Inputs() ([]string, error) {
+	return portNames(midi.GetInPorts()), nil
+}
+
+func (rtmidiSource) Outputs() ([]string, error) {
+	return portNames(midi.GetOutPorts()), nil
+}
+
+func (rtmidiSource) Listen(name string, fn func(midi.Message)) (func(), error) {
+	for _, port := range midi.GetInPorts() {
+		if port.String() != name {
+			continue
+		}
+		// Time code covers MIDI clock, which tempo sync needs
+		stop, err := midi.ListenTo(port, func(msg midi.Message, timestamp int32) {
+			fn(msg)
+		}, midi.UseTimeCode())
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			stop()
+			port.Close()
+		}, nil
+	}
+	return nil, errNoPort(name)
+}
+
+func (rtmidiSource) Open(name string) (func(midi.Message) error, func(), error) {
+	for _, port := range midi.GetOutPorts() {
+		if port.String() != name {
+			continue
+		}
+		send, err := midi.SendTo(port)
+		if err != nil {
+			return nil, nil, err
+		}
+		return send, func() { port.Close() }, nil
+	}
+	return nil, nil, errNoPort(name)
+}
+
+func portNames[P fmt.Stringer](ports []P) []string {
+	names := make([]string, len(ports))
+	for i, port := range ports {
+		names[i] = port.String()
+	}
+	return names
+}
@@ -0,0 +1,310 @@
+package midi
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// recorder collects the messages a Handler delivers.
+type recorder struct{ msgs []tea.Msg }
+
+func (r *recorder) send(msg tea.Msg) { r.msgs = append(r.msgs, msg) }
+
+func (r *recorder) controls() []ControlMsg {
+	var out []ControlMsg
+	for _, msg := range r.msgs {
+		if c, ok := msg.(ControlMsg); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestHandler_InjectorDrivesMappings(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.FeedbackPort = "Ctl"
+	src := NewInjector("Ctl In")
+	src.PlugOutput("Ctl Out")
+
+	h := NewSourceHandler(nil, cfg, src)
+	var rec recorder
+	h.SetSend(rec.send)
+	if err := h.Start(); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	defer h.Stop()
+
+	if h.PortName() != "Ctl In" || h.FeedbackPortName() != "Ctl Out" {
+		t.Fatalf("expected Ctl In and Ctl Out to open, got %q and %q", h.PortName(), h.FeedbackPortName())
+	}
+	if !src.Inject("Ctl In", midi.ControlChange(0, 1, 127)) {
+		t.Fatal("expected Ctl In to be listened to")
+	}
+	controls := rec.controls()
+	if len(controls) != 1 || controls[0].Param != "gain" || controls[0].Value != 1 {
+		t.Errorf("expected CC 1 to set gain to 1, got %+v", controls)
+	}
+
+	h.Feedback("gain", 0, true)
+	sent := src.Sent("Ctl Out")
+	if len(sent) != 1 || !bytes.Equal(sent[0], midi.ControlChange(0, 1, 0)) {
+		t.Errorf("expected gain feedback on CC 1, got %v", sent)
+	}
+}
+
+func TestHandler_InjectorHotPlug(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.InputPort = "Pads"
+	src := NewInjector()
+	h := NewSourceHandler(nil, cfg, src)
+	if err := h.Start(); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	defer h.Stop()
+
+	src.Plug("Pads 1")
+	changed := h.poll()
+	if len(changed) != 1 || !changed[0].Connected || changed[0].Port != "Pads 1" {
+		t.Fatalf("expected Pads 1 to connect, got %+v", changed)
+	}
+	src.Unplug("Pads 1")
+	changed = h.poll()
+	if len(changed) != 1 || changed[0].Connected {
+		t.Fatalf("expected Pads 1 to disconnect, got %+v", changed)
+	}
+	if src.Inject("Pads 1", midi.NoteOn(0, 60, 100)) {
+		t.Error("expected no listener on an unplugged port")
+	}
+}
+
+// joiningSource waits for running callbacks when a listener is stopped, as
+// rtmidi does when closing an input.
+type joiningSource struct {
+	*Injector
+	running  sync.WaitGroup
+	stopping chan struct{} // Closed when a listener starts stopping
+}
+
+func (s *joiningSource) Listen(port string, fn func(midi.Message)) (func(), error) {
+	stop, err := s.Injector.Listen(port, func(msg midi.Message) {
+		s.running.Add(1)
+		defer s.running.Done()
+		fn(msg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		close(s.stopping)
+		stop()
+		s.running.Wait()
+	}, nil
+}
+
+func TestHandler_UnplugWhileDeliveringDoesNotDeadlock(t *testing.T) {
+	src := &joiningSource{Injector: NewInjector("Ctl"), stopping: make(chan struct{})}
+	h := NewSourceHandler(nil, config.DefaultConfig(), src)
+	delivering := make(chan struct{})
+	release := make(chan struct{})
+	// The TUI is busy, as when it is calling the handler itself
+	var once sync.Once
+	h.SetSend(func(tea.Msg) {
+		once.Do(func() { close(delivering) })
+		<-release
+	})
+	if err := h.Start(); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	defer h.Stop()
+
+	go src.Inject("Ctl", midi.ControlChange(0, 1, 127))
+	<-delivering
+	src.Unplug("Ctl")
+	polled := make(chan struct{})
+	go func() {
+		h.poll()
+		close(polled)
+	}()
+	<-src.stopping
+
+	done := make(chan struct{})
+	go func() {
+		h.Feedback("gain", 1, true)
+		h.SetConfig(config.DefaultConfig())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		close(release)
+		t.Fatal("expected the handler not to be held while closing a port")
+	}
+	close(release)
+	select {
+	case <-polled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the port to close once delivery finished")
+	}
+}
+
+func TestHandler_StopTwice(t *testing.T) {
+	h := NewSourceHandler(nil, config.DefaultConfig(), NewInjector("In"))
+	if err := h.Start(); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	h.Stop()
+	h.Stop()
+	if h.PortName() != "" {
+		t.Errorf("expected no open ports after stop, got %q", h.PortName())
+	}
+}
+
+func TestRawMIDISource_ReadsDevices(t *testing.T) {
+	dev, proc := t.TempDir(), t.TempDir()
+	// Two CCs, the second with running status, around a clock tick
+	data := []byte{0xB0, 0x01, 0x40, 0xF8, 0x01, 0x7F}
+	if err := os.WriteFile(filepath.Join(dev, "midiC1D0"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dev, "controlC1"), nil, 0o644)
+	os.MkdirAll(filepath.Join(proc, "card1"), 0o755)
+	os.WriteFile(filepath.Join(proc, "card1", "id"), []byte("nanoKONTROL2\n"), 0o644)
+
+	src := rawmidiSource{dev: dev, proc: proc}
+	ins, err := src.Inputs()
+	if err != nil || len(ins) != 1 || ins[0] != "nanoKONTROL2 hw:1,0" {
+		t.Fatalf("expected one device named from its card, got %v (%v)", ins, err)
+	}
+
+	got := make(chan midi.Message, 3)
+	stop, err := src.Listen(ins[0], func(msg midi.Message) { got <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	want := []midi.Message{midi.ControlChange(0, 1, 0x40), midi.TimingClock(), midi.ControlChange(0, 1, 0x7F)}
+	for _, w := range want {
+		select {
+		case msg := <-got:
+			if !bytes.Equal(msg, w) {
+				t.Errorf("expected %v, got %v", w, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %v", w)
+		}
+	}
+
+	if _, err := (rawmidiSource{dev: filepath.Join(dev, "missing")}).Inputs(); err == nil {
+		t.Error("expected an error without a device directory")
+	}
+}
+
+func TestReadMIDIText(t *testing.T) {
+	text := "# fader then pad\n0 B0 07 64\n0.5 F8\n\n1.25 90 3C 7F\n"
+	events, err := ReadMIDIText(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Timed{
+		{0, midi.ControlChange(0, 7, 100)},
+		{500 * time.Millisecond, midi.TimingClock()},
+		{1250 * time.Millisecond, midi.NoteOn(0, 60, 127)},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i].At != w.At || !bytes.Equal(events[i].Msg, w.Msg) {
+			t.Errorf("event %d: expected %v at %v, got %v at %v", i, w.Msg, w.At, events[i].Msg, events[i].At)
+		}
+	}
+
+	for _, bad := range []string{"x B0 07 64", "1 B0 0G", "1"} {
+		if _, err := ReadMIDIText(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestReadSMF_FollowsTempoMap(t *testing.T) {
+	ticks := smf.MetricTicks(960)
+	var tr smf.Track
+	tr.Add(0, smf.MetaTempo(120))
+	tr.Add(0, midi.ControlChange(0, 1, 10))
+	tr.Add(ticks.Ticks4th(), smf.MetaTempo(60))
+	tr.Add(0, midi.ControlChange(0, 1, 20))
+	tr.Add(ticks.Ticks4th(), midi.ControlChange(0, 1, 30))
+	tr.Close(ticks.Ticks4th())
+	file := smf.New()
+	file.TimeFormat = ticks
+	file.Add(tr)
+	var buf bytes.Buffer
+	if _, err := file.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	events, length, err := ReadSMF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The track ends a beat after its last event
+	if length != 2500*time.Millisecond {
+		t.Errorf("expected a length of 2.5s, got %v", length)
+	}
+	// A beat at 120 BPM, then a beat at 60 BPM
+	want := []time.Duration{0, 500 * time.Millisecond, 1500 * time.Millisecond}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %v", len(want), events)
+	}
+	for i, at := range want {
+		if events[i].At != at {
+			t.Errorf("event %d: expected %v, got %v", i, at, events[i].At)
+		}
+	}
+}
+
+func TestReplaySource_DrivesHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "take.txt")
+	os.WriteFile(path, []byte("0 B0 01 7F\n0.01 B0 01 00\n"), 0o644)
+	src, err := NewReplaySource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewSourceHandler(nil, config.DefaultConfig(), src)
+	msgs := make(chan tea.Msg, 10)
+	h.SetSend(func(msg tea.Msg) { msgs <- msg })
+	if err := h.Start(); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	defer h.Stop()
+	if h.PortName() != "take.txt" {
+		t.Fatalf("expected the replay port to open, got %q", h.PortName())
+	}
+
+	select {
+	case <-src.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the replay")
+	}
+	var values []float32
+	for len(msgs) > 0 {
+		if c, ok := (<-msgs).(ControlMsg); ok && c.Param == "gain" {
+			values = append(values, c.Value)
+		}
+	}
+	if len(values) != 2 || values[0] != 1 || values[1] != 0 {
+		t.Errorf("expected gain 1 then 0, got %v", values)
+	}
+}
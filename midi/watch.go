@@ -0,0 +1,77 @@
+package midi
+
+import (
+	"strings"
+	"time"
+)
+
+// pollInterval is how often the port lists are checked for devices being
+// plugged in or unplugged.
+const pollInterval = time.Second
+
+// StatusMsg reports a MIDI input device connecting or disconnecting. Device
+// is the profile name from config, empty for the single default device.
+// While disconnected Port is the port being waited for, or empty if any port
+// will do. Suggest names a built-in controller profile matching the
+// connected port when the config doesn't use one.
+type StatusMsg struct {
+	Device    string
+	Port      string
+	Connected bool
+	Suggest   string
+}
+
+// portWatcher follows one wanted port across polls of a port list.
+type portWatcher struct {
+	want      string // Port name to match, as a substring; empty takes the first port
+	connected string // Name of the open port, empty while disconnected
+}
+
+// poll compares the current port names with the connection state. It
+// returns the name of a port to open, if the wanted port is available and
+// not open, and whether the open port has gone away.
+func (w *portWatcher) poll(ports []string) (open string, lost bool) {
+	if w.connected != "" {
+		for _, name := range ports {
+			if name == w.connected {
+				return "", false
+			}
+		}
+		w.connected = ""
+		lost = true
+	}
+
+	if name, ok := matchPort(ports, w.want); ok {
+		return name, lost
+	}
+	return "", lost
+}
+
+// opened records that name was opened. The first port opened without a
+// wanted name becomes the wanted one, so reconnection returns to the same
+// device rather than whichever is listed first.
+func (w *portWatcher) opened(name string) {
+	w.connected = name
+	if w.want == "" {
+		w.want = name
+	}
+}
+
+// status returns the connection state for the TUI.
+func (w *portWatcher) status() StatusMsg {
+	if w.connected != "" {
+		return StatusMsg{Port: w.connected, Connected: true}
+	}
+	return StatusMsg{Port: w.want}
+}
+
+// matchPort returns the first port whose name contains want, or the first
+// port when want is empty.
+func matchPort(ports []string, want string) (string, bool) {
+	for _, name := range ports {
+		if strings.Contains(name, want) {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+package midi
+
+import "testing"
+
+func TestPortWatcher_Reconnect(t *testing.T) {
+	w := portWatcher{want: "nanoKONTROL2"}
+
+	// Waiting for a device that isn't plugged in
+	if open, lost := w.poll([]string{"Midi Through"}); open != "" || lost {
+		t.Errorf("expected nothing to open, got %q lost=%v", open, lost)
+	}
+	if status := w.status(); status.Connected || status.Port != "nanoKONTROL2" {
+		t.Errorf("expected to wait for nanoKONTROL2, got %+v", status)
+	}
+
+	ports := []string{"Midi Through", "nanoKONTROL2 MIDI 1"}
+	open, _ := w.poll(ports)
+	if open != "nanoKONTROL2 MIDI 1" {
+		t.Fatalf("expected to open the plugged in device, got %q", open)
+	}
+	w.opened(open)
+	if open, lost := w.poll(ports); open != "" || lost {
+		t.Errorf("expected an open device to stay put, got %q lost=%v", open, lost)
+	}
+
+	// Unplugged
+	if _, lost := w.poll([]string{"Midi Through"}); !lost {
+		t.Error("expected the unplugged device to be lost")
+	}
+	if status := w.status(); status.Connected {
+		t.Errorf("expected disconnected status, got %+v", status)
+	}
+
+	// Plugged back in
+	if open, _ := w.poll(ports); open != "nanoKONTROL2 MIDI 1" {
+		t.Errorf("expected to reopen the device, got %q", open)
+	}
+}
+
+func TestPortWatcher_FirstPortIsRemembered(t *testing.T) {
+	var w portWatcher
+	open, _ := w.poll([]string{"Pads", "Faders"})
+	if open != "Pads" {
+		t.Fatalf("expected the first port, got %q", open)
+	}
+	w.opened(open)
+
+	// After unplugging, another device is not taken in its place
+	w.poll([]string{"Faders"})
+	if open, _ := w.poll([]string{"Faders"}); open != "" {
+		t.Errorf("expected to wait for Pads, got %q", open)
+	}
+}
@@ -0,0 +1,114 @@
+package osc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Server receives OSC messages sent to chroma-control itself, such as a
+// morph position from another performer's control surface. Handlers run on
+// the server's goroutine.
+type Server struct {
+	addr       string
+	dispatcher *osc.StandardDispatcher
+	server     *osc.Server
+
+	mu   sync.Mutex
+	conn net.PacketConn
+	done chan struct{}
+}
+
+// NewServer returns a server that will listen on host and port. An empty
+// host listens on all interfaces, and port 0 picks a free port.
+func NewServer(host string, port int) *Server {
+	dispatcher := osc.NewStandardDispatcher()
+	return &Server{
+		addr:       net.JoinHostPort(host, fmt.Sprint(port)),
+		dispatcher: dispatcher,
+		server:     &osc.Server{Dispatcher: dispatcher},
+	}
+}
+
+// HandleFloat calls fn with the first argument of each message sent to
+// address. Integer and double arguments are converted; messages without a
+// finite number are ignored.
+func (s *Server) HandleFloat(address string, fn func(float32)) error {
+	return s.dispatcher.AddMsgHandler(address, func(msg *osc.Message) {
+		if len(msg.Arguments) == 0 {
+			return
+		}
+		var v float64
+		switch arg := msg.Arguments[0].(type) {
+		case float32:
+			v = float64(arg)
+		case float64:
+			v = arg
+		case int32:
+			v = float64(arg)
+		case int64:
+			v = float64(arg)
+		default:
+			return
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return
+		}
+		fn(float32(v))
+	})
+}
+
+// Start starts listening. Malformed packets are dropped without stopping
+// the server.
+func (s *Server) Start() error {
+	conn, err := net.ListenPacket("udp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.conn = conn
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	go func() {
+		defer close(s.done)
+		for {
+			packet, err := s.server.ReceivePacket(conn)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err == nil {
+				s.dispatcher.Dispatch(packet)
+			}
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server listens on, once started.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
+// Close stops the server and waits for its goroutine to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	conn, done := s.conn, s.done
+	s.conn = nil
+	s.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	err := conn.Close()
+	<-done
+	return err
+}
@@ -0,0 +1,49 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServer_HandleFloat(t *testing.T) {
+	server := NewServer("127.0.0.1", 0)
+	received := make(chan float32, 4)
+	if err := server.HandleFloat("/chroma/morph", func(v float32) { received <- v }); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	port := server.Addr().(*net.UDPAddr).Port
+	client := NewClient("127.0.0.1", port)
+
+	// A malformed packet doesn't stop the server
+	conn, _ := net.Dial("udp", server.Addr().String())
+	conn.Write([]byte("garbage"))
+	conn.Close()
+
+	client.Send("/chroma/other", float32(0.9))
+	client.SendFloat("/chroma/morph", 0.25)
+	client.SendInt("/chroma/morph", 1)
+
+	for _, want := range []float32{0.25, 1} {
+		select {
+		case v := <-received:
+			if v != want {
+				t.Errorf("expected %v, got %v", want, v)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %v", want)
+		}
+	}
+
+	if err := server.Close(); err != nil {
+		t.Errorf("expected a clean close, got %v", err)
+	}
+	if err := server.Close(); err != nil {
+		t.Errorf("expected closing twice to be harmless, got %v", err)
+	}
+}
@@ -1,7 +1,12 @@
 package tui
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/renderorange/chroma/chroma-control/config"
@@ -48,6 +53,66 @@ func availableCommands() []Command {
 			Description: "Load preset (:load name)",
 			Handler:     cmdLoad,
 		},
+		{
+			Name:        "restore",
+			Aliases:     []string{},
+			Description: "Restore a preset backup (:restore [name] [n])",
+			Handler:     cmdRestore,
+		},
+		{
+			Name:        "meta",
+			Aliases:     []string{"info"},
+			Description: "Set preset metadata (:meta description|tags|author|notes text)",
+			Handler:     cmdMeta,
+		},
+		{
+			Name:        "diff",
+			Aliases:     []string{},
+			Description: "Compare presets (:diff a [b], a against the current state without b)",
+			Handler:     cmdDiff,
+		},
+		{
+			Name:        "undo",
+			Aliases:     []string{},
+			Description: "Undo the last change (:undo [n])",
+			Handler:     cmdUndo,
+		},
+		{
+			Name:        "redo",
+			Aliases:     []string{},
+			Description: "Redo the last change undone (:redo [n])",
+			Handler:     cmdRedo,
+		},
+		{
+			Name:        "history",
+			Aliases:     []string{"hist"},
+			Description: "Undo history",
+			Handler:     cmdHistory,
+		},
+		{
+			Name:        "snapshot",
+			Aliases:     []string{"snap"},
+			Description: "Effect snapshots (:snapshot [save] [effect] [name])",
+			Handler:     cmdSnapshot,
+		},
+		{
+			Name:        "morph",
+			Aliases:     []string{},
+			Description: "Morph between presets (:morph a b, :morph 0-1, threshold 0-1, off)",
+			Handler:     cmdMorph,
+		},
+		{
+			Name:        "export",
+			Aliases:     []string{},
+			Description: "Export presets to a bundle (:export file.zip|file.json [preset or folder])",
+			Handler:     cmdExport,
+		},
+		{
+			Name:        "import",
+			Aliases:     []string{},
+			Description: "Import a preset bundle (:import file [rename|overwrite|skip])",
+			Handler:     cmdImport,
+		},
 		{
 			Name:        "presets",
 			Aliases:     []string{"browser"},
@@ -60,6 +125,30 @@ func availableCommands() []Command {
 			Description: "Reset to defaults",
 			Handler:     cmdReset,
 		},
+		{
+			Name:        "feedback",
+			Aliases:     []string{"fb"},
+			Description: "Resend MIDI controller feedback",
+			Handler:     cmdFeedback,
+		},
+		{
+			Name:        "monitor",
+			Aliases:     []string{"midi"},
+			Description: "MIDI monitor",
+			Handler:     cmdMonitor,
+		},
+		{
+			Name:        "mappings",
+			Aliases:     []string{"map"},
+			Description: "Edit MIDI mappings",
+			Handler:     cmdMappings,
+		},
+		{
+			Name:        "play-midi",
+			Aliases:     []string{"play"},
+			Description: "Play a MIDI file (:play-midi file [loop], start, stop, loop)",
+			Handler:     cmdPlayMIDI,
+		},
 	}
 }
 
@@ -110,6 +199,9 @@ func cmdSave(m *Model, args []string) tea.Cmd {
 		if len(args) > 0 {
 			name := strings.Join(args, " ")
 			preset := m.buildCurrentPreset()
+			if name != m.currentPresetName {
+				preset.Meta.Created = time.Time{} // A new preset, not a copy's history
+			}
 			if err := config.SavePreset(preset, name); err == nil {
 				m.currentPresetName = name
 				config.SaveLastPresetName(name)
@@ -132,15 +224,16 @@ func cmdSave(m *Model, args []string) tea.Cmd {
 	}
 }
 
+// presetLoadedMsg is returned after a preset loads outside of Update so the
+// model gets a chance to sync controller feedback.
+type presetLoadedMsg struct{}
+
 // cmdLoad handles the load command.
 func cmdLoad(m *Model, args []string) tea.Cmd {
 	if len(args) > 0 {
 		return func() tea.Msg {
-			name := strings.Join(args, " ")
-			if preset, err := config.LoadPreset(name); err == nil {
-				m.applyPreset(preset)
-				m.currentPresetName = name
-				config.SaveLastPresetName(name)
+			if err := m.loadPreset(strings.Join(args, " ")); err == nil {
+				return presetLoadedMsg{}
 			}
 			return nil
 		}
@@ -151,6 +244,197 @@ func cmdLoad(m *Model, args []string) tea.Cmd {
 	return nil
 }
 
+// cmdRestore handles the restore command: it brings back backup n (default
+// 1, the most recent) of the named or current preset and loads it. Failures
+// are shown in the preset browser.
+func cmdRestore(m *Model, args []string) tea.Cmd {
+	n := 1
+	if len(args) > 0 {
+		if v, err := strconv.Atoi(args[len(args)-1]); err == nil {
+			n = v
+			args = args[:len(args)-1]
+		}
+	}
+	name := strings.Join(args, " ")
+	if name == "" {
+		name = m.currentPresetName
+	}
+
+	err := fmt.Errorf("no preset to restore")
+	if name != "" {
+		err = config.RestorePreset(name, n)
+	}
+	if err == nil {
+		err = m.loadPreset(name)
+	}
+	if err != nil {
+		m.showPresetError(err)
+		return nil
+	}
+	m.showPresetReport()
+	return nil
+}
+
+// cmdMeta handles the meta command: it sets a metadata field of the current
+// preset and writes it to the preset file straight away, since metadata
+// doesn't make a preset dirty. With no arguments it opens the browser on
+// the current preset, which shows its metadata.
+func cmdMeta(m *Model, args []string) tea.Cmd {
+	if len(args) == 0 {
+		m.refreshPresetList()
+		m.selectPresetEntry(m.currentPresetName)
+		m.switchScreen(screenPresetBrowser)
+		return nil
+	}
+
+	field, value := strings.ToLower(args[0]), strings.Join(args[1:], " ")
+	var update func(*config.PresetMeta)
+	switch field {
+	case "description", "desc":
+		update = func(meta *config.PresetMeta) { meta.Description = value }
+	case "tags", "tag":
+		update = func(meta *config.PresetMeta) { meta.Tags = config.ParseTags(value) }
+	case "author":
+		update = func(meta *config.PresetMeta) { meta.Author = value }
+	case "notes", "note":
+		update = func(meta *config.PresetMeta) { meta.Notes = value }
+	}
+
+	err := fmt.Errorf("unknown metadata field %q", field)
+	if update != nil {
+		err = fmt.Errorf("save the preset before adding metadata")
+		if m.currentPresetName != "" && m.currentPresetName != "_last" {
+			var meta config.PresetMeta
+			if meta, err = config.UpdatePresetMeta(m.currentPresetName, update); err == nil {
+				m.presetMeta = meta
+			}
+		}
+	}
+	if err != nil {
+		m.showPresetError(err)
+	}
+	return nil
+}
+
+// cmdDiff handles the diff command: it compares two saved presets, or one
+// against the current state, and shows what differs. Presets are read
+// without being loaded or upgraded on disk.
+func cmdDiff(m *Model, args []string) tea.Cmd {
+	a, b := splitPresetNames(args)
+	if a == "" {
+		m.showPresetError(fmt.Errorf("diff needs a preset, e.g. :diff wash or :diff wash storm"))
+		return nil
+	}
+
+	from, err := config.ReadPreset(a)
+	if err != nil {
+		m.showPresetError(err)
+		return nil
+	}
+	to, toName := m.buildCurrentPreset(), "current"
+	if b != "" {
+		if to, err = config.ReadPreset(b); err != nil {
+			m.showPresetError(err)
+			return nil
+		}
+		toName = b
+	}
+
+	m.presetBrowser.diff = config.DiffPresets(from, to)
+	m.presetBrowser.diffTitle = fmt.Sprintf("%s → %s", a, toName)
+	m.presetBrowser.mode = browserModeDiff
+	m.switchScreen(screenPresetBrowser)
+	return nil
+}
+
+// splitPresetNames splits command arguments into one or two preset names.
+// Names may hold spaces, so the split is made where both halves name saved
+// presets; otherwise all arguments make up one name.
+func splitPresetNames(args []string) (string, string) {
+	for i := 1; i < len(args); i++ {
+		a, b := strings.Join(args[:i], " "), strings.Join(args[i:], " ")
+		if config.PresetExists(a) && config.PresetExists(b) {
+			return a, b
+		}
+	}
+	return strings.Join(args, " "), ""
+}
+
+// cmdExport handles the export command: it writes the named preset or
+// folder, or all presets, to a bundle and lists what was exported.
+func cmdExport(m *Model, args []string) tea.Cmd {
+	if len(args) == 0 {
+		m.showPresetError(fmt.Errorf("export needs a file, e.g. :export show1.zip show1"))
+		return nil
+	}
+	path := expandHome(args[0])
+	var names []string
+	if len(args) > 1 {
+		names = append(names, strings.Join(args[1:], " "))
+	}
+
+	exported, err := config.ExportPresets(path, names...)
+	if err != nil {
+		m.showPresetError(err)
+		return nil
+	}
+	m.presetBrowser.reportTitle = fmt.Sprintf("Exported %d Preset(s) to %s", len(exported), filepath.Base(path))
+	m.presetBrowser.report = exported
+	m.showPresetReport()
+	return nil
+}
+
+// cmdImport handles the import command: it saves the presets in a bundle,
+// renaming, overwriting or skipping those whose name is taken, and lists
+// what became of each.
+func cmdImport(m *Model, args []string) tea.Cmd {
+	mode := config.ImportRename
+	if len(args) > 1 {
+		if v, err := config.ParseImportMode(args[len(args)-1]); err == nil {
+			mode = v
+			args = args[:len(args)-1]
+		}
+	}
+	if len(args) == 0 {
+		m.showPresetError(fmt.Errorf("import needs a file, e.g. :import show1.zip"))
+		return nil
+	}
+	path := expandHome(strings.Join(args, " "))
+
+	results, err := config.ImportPresets(path, mode)
+	if err != nil {
+		m.showPresetError(err)
+		return nil
+	}
+	m.presetBrowser.reportTitle = fmt.Sprintf("Imported %s", filepath.Base(path))
+	m.presetBrowser.report = make([]string, len(results))
+	for i, result := range results {
+		m.presetBrowser.report[i] = result.String()
+	}
+	m.refreshPresetList()
+	m.showPresetReport()
+	return nil
+}
+
+// expandHome expands a leading ~/ in a path typed into the palette.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// showPresetError opens the preset browser with an error from a preset
+// command.
+func (m *Model) showPresetError(err error) {
+	m.refreshPresetList()
+	m.presetBrowser.mode = browserModeList
+	m.presetBrowser.errorMsg = err.Error()
+	m.switchScreen(screenPresetBrowser)
+}
+
 // cmdPresets handles the presets command.
 func cmdPresets(m *Model, args []string) tea.Cmd {
 	m.refreshPresetList()
@@ -160,8 +444,27 @@ func cmdPresets(m *Model, args []string) tea.Cmd {
 
 // cmdReset handles the reset command.
 func cmdReset(m *Model, args []string) tea.Cmd {
-	m.applyPreset(config.DefaultPreset())
+	m.labelHistory("reset", "", true)
 	m.currentPresetName = ""
+	m.applyPreset(config.DefaultPreset())
 	m.isDirty = true
 	return nil
 }
+
+// cmdFeedback handles the feedback command.
+func cmdFeedback(m *Model, args []string) tea.Cmd {
+	m.syncFeedback(true)
+	return nil
+}
+
+// cmdMonitor handles the monitor command.
+func cmdMonitor(m *Model, args []string) tea.Cmd {
+	m.switchScreen(screenMIDIMonitor)
+	return nil
+}
+
+// cmdMappings handles the mappings command.
+func cmdMappings(m *Model, args []string) tea.Cmd {
+	m.switchScreen(screenMappingEditor)
+	return nil
+}
@@ -23,6 +23,8 @@ func helpContent() []helpSection {
 			Items: []helpItem{
 				{Key: ":", Description: "Open command palette"},
 				{Key: "?", Description: "Toggle help"},
+				{Key: "[/]", Description: "Move morph position"},
+				{Key: "u/ctrl+r", Description: "Undo/redo"},
 				{Key: "ctrl+c", Description: "Quit"},
 			},
 		},
@@ -31,6 +33,7 @@ func helpContent() []helpSection {
 			Items: []helpItem{
 				{Key: "j/k", Description: "Navigate effects"},
 				{Key: "enter", Description: "Open parameters"},
+				{Key: "s", Description: "Effect snapshots"},
 				{Key: "1/2/3", Description: "Set blend mode"},
 			},
 		},
@@ -51,12 +54,32 @@ func helpContent() []helpSection {
 				{Key: "h/l", Description: "Move effect"},
 			},
 		},
+		{
+			Title: "Preset Browser",
+			Items: []helpItem{
+				{Key: "enter/bksp", Description: "Open folder/go up"},
+				{Key: "f", Description: "New folder"},
+				{Key: "r/m", Description: "Rename/move"},
+				{Key: "t", Description: "Filter by tag"},
+			},
+		},
 		{
 			Title: "Commands",
 			Items: []helpItem{
 				{Key: ":quit", Description: "Exit application"},
 				{Key: "help/h/?", Description: "Show help"},
 				{Key: "settings/set", Description: "Open settings"},
+				{Key: "meta field text", Description: "Set preset metadata"},
+				{Key: "diff a [b]", Description: "Compare presets"},
+				{Key: "undo/redo [n]", Description: "Undo/redo changes"},
+				{Key: "history", Description: "Show undo history"},
+				{Key: "snapshot [save] fx name", Description: "Load/save an effect snapshot"},
+				{Key: "morph a b", Description: "Morph between presets"},
+				{Key: "export/import file", Description: "Share preset bundles"},
+				{Key: "restore [name] [n]", Description: "Restore a preset backup"},
+				{Key: "monitor/midi", Description: "Open MIDI monitor"},
+				{Key: "mappings/map", Description: "Edit MIDI mappings"},
+				{Key: "play-midi/play", Description: "Play a MIDI file through the mappings"},
 			},
 		},
 	}
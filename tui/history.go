@@ -0,0 +1,345 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// historySize is the number of steps kept for undo.
+const historySize = 100
+
+// historyMergeWindow is how soon a change to the same continuous parameter
+// must follow the last to merge into one step, so a knob turn or held key
+// undoes in one go.
+const historyMergeWindow = time.Second
+
+// historyPoint is a state the history can return to.
+type historyPoint struct {
+	values     config.Preset
+	hash       string // values.Hash()
+	presetName string // Loaded preset and its hash, used only by load steps
+	loadedHash string
+}
+
+// historyStep is one undoable change.
+type historyStep struct {
+	label         string
+	merge         string // Steps with the same merge key merge while rapid
+	load          bool   // A preset load or reset, which changes the loaded preset too
+	before, after historyPoint
+	at            time.Time
+}
+
+// historyState holds the undo history. Every change to the model's values
+// goes through checkDirty, which records it here as a step.
+type historyState struct {
+	steps     []historyStep
+	pos       int          // Steps applied; those after it can be redone
+	current   historyPoint // State after the last change
+	next      historyStep  // Label for the change being recorded, set by labelHistory
+	restoring bool         // Moving through the history, so nothing is recorded
+	selected  int          // Selected row in the history view, newest first
+}
+
+// resetHistory clears the history, starting it from the current state.
+func (m *Model) resetHistory() {
+	values := m.buildCurrentPreset()
+	m.history = historyState{current: m.historyPoint(values, values.Hash())}
+}
+
+func (m *Model) historyPoint(values config.Preset, hash string) historyPoint {
+	return historyPoint{
+		values:     values,
+		hash:       hash,
+		presetName: m.currentPresetName,
+		loadedHash: m.loadedPresetHash,
+	}
+}
+
+// labelHistory names the change about to be recorded, for changes that
+// aren't clearly described by the values they set. Changes labelled with the
+// same merge key merge while rapid. load marks a preset load or reset, and
+// must be called before the loaded preset changes, so undo can return to it.
+func (m *Model) labelHistory(label, merge string, load bool) {
+	m.history.next = historyStep{label: label, merge: merge, load: load}
+	if load {
+		m.history.next.before = historyPoint{presetName: m.currentPresetName, loadedHash: m.loadedPresetHash}
+	}
+}
+
+// recordHistory adds a step for the change from the last recorded state to
+// values, if there was one.
+func (m *Model) recordHistory(values config.Preset, hash string) {
+	h := &m.history
+	next := h.next
+	h.next = historyStep{}
+	point := m.historyPoint(values, hash)
+	if h.restoring || hash == h.current.hash {
+		h.current = point
+		return
+	}
+	before := h.current
+	h.current = point
+	if next.load {
+		before.presetName, before.loadedHash = next.before.presetName, next.before.loadedHash
+	}
+
+	described := next.label == ""
+	if described {
+		next.label, next.merge = describeChanges(config.DiffPresets(before.values, values))
+	}
+	now := time.Now()
+
+	// Merge into the last step while the same parameter keeps changing
+	if next.merge != "" && h.pos == len(h.steps) && h.pos > 0 {
+		last := &h.steps[h.pos-1]
+		if last.merge == next.merge && now.Sub(last.at) < historyMergeWindow {
+			if hash == last.before.hash {
+				// Back where it started, so nothing to undo
+				h.steps = h.steps[:h.pos-1]
+				h.pos--
+				return
+			}
+			if described {
+				next.label, _ = describeChanges(config.DiffPresets(last.before.values, values))
+			}
+			last.label = next.label
+			last.after = point
+			last.at = now
+			return
+		}
+	}
+
+	next.before, next.after, next.at = before, point, now
+	h.steps = append(h.steps[:h.pos], next)
+	if len(h.steps) > historySize {
+		h.steps = h.steps[len(h.steps)-historySize:]
+	}
+	h.pos = len(h.steps)
+	h.selected = 0
+}
+
+// describeChanges returns a label for a step, and its merge key when it
+// changes a single continuous parameter.
+func describeChanges(changes []config.PresetChange) (label, merge string) {
+	if len(changes) == 1 {
+		change := changes[0]
+		if change.Key == "effects_order" {
+			return "effects order: " + change.New, ""
+		}
+		if p, ok := config.LookupParam(change.Key); ok && p.Kind == config.ParamContinuous {
+			merge = change.Key
+		}
+		return fmt.Sprintf("%s: %s → %s", change.Key, change.Old, change.New), merge
+	}
+
+	keys := make([]string, 0, len(changes))
+	for _, change := range changes {
+		keys = append(keys, change.Key)
+	}
+	if len(keys) > 3 {
+		keys = append(keys[:3], fmt.Sprintf("%d more", len(changes)-3))
+	}
+	return strings.Join(keys, ", "), ""
+}
+
+// undo reverts the last step, returning false when there is nothing to
+// undo.
+func (m *Model) undo() bool {
+	if m.history.pos == 0 {
+		return false
+	}
+	m.history.pos--
+	step := m.history.steps[m.history.pos]
+	m.restoreHistory(step.before, step.load)
+	return true
+}
+
+// redo applies the last step undone, returning false when there is nothing
+// to redo.
+func (m *Model) redo() bool {
+	if m.history.pos == len(m.history.steps) {
+		return false
+	}
+	step := m.history.steps[m.history.pos]
+	m.history.pos++
+	m.restoreHistory(step.after, step.load)
+	return true
+}
+
+// restoreHistory returns to a recorded state, sending the engine the values
+// that change. Undoing a load returns to the preset loaded before it.
+func (m *Model) restoreHistory(point historyPoint, load bool) {
+	if load {
+		m.currentPresetName = point.presetName
+		m.loadedPresetHash = point.loadedHash
+		m.presetMeta = point.values.Meta
+	}
+	m.history.restoring = true
+	m.applyValues(point.values)
+	m.history.restoring = false
+}
+
+// cmdUndo handles the undo command, ":undo 3" undoing three steps.
+func cmdUndo(m *Model, args []string) tea.Cmd {
+	for n := historySteps(args); n > 0 && m.undo(); n-- {
+	}
+	return nil
+}
+
+// cmdRedo handles the redo command, ":redo 3" redoing three steps.
+func cmdRedo(m *Model, args []string) tea.Cmd {
+	for n := historySteps(args); n > 0 && m.redo(); n-- {
+	}
+	return nil
+}
+
+// historySteps returns the number of steps asked for by :undo or :redo,
+// one by default.
+func historySteps(args []string) int {
+	if len(args) > 0 {
+		var n int
+		if _, err := fmt.Sscan(args[0], &n); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 1
+}
+
+// cmdHistory handles the history command.
+func cmdHistory(m *Model, args []string) tea.Cmd {
+	m.history.selected = 0
+	m.switchScreen(screenHistory)
+	return nil
+}
+
+// historyRowPos returns the number of steps applied at a row of the history
+// view, which lists the newest step first and the starting state last.
+func (m *Model) historyRowPos(row int) int {
+	return len(m.history.steps) - row
+}
+
+// goToHistory undoes or redoes until pos steps are applied.
+func (m *Model) goToHistory(pos int) {
+	for m.history.pos > pos && m.undo() {
+	}
+	for m.history.pos < pos && m.redo() {
+	}
+}
+
+// updateHistory handles updates on the history screen.
+func (m *Model) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.goBack()
+		case "k", "up":
+			if m.history.selected > 0 {
+				m.history.selected--
+			}
+		case "j", "down":
+			if m.history.selected < len(m.history.steps) {
+				m.history.selected++
+			}
+		case "enter":
+			m.goToHistory(m.historyRowPos(m.history.selected))
+		case "u":
+			m.undo()
+		case "ctrl+r":
+			m.redo()
+		case "?":
+			m.switchScreen(screenHelp)
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	}
+	return m, nil
+}
+
+func (m *Model) renderHistory() string {
+	modalWidth := 70
+	if m.width > 0 && m.width < modalWidth+4 {
+		modalWidth = m.width - 4
+	}
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(colorTextNormal).
+		MaxWidth(modalWidth - 4)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(colorTextHighlight).
+		Bold(true).
+		MaxWidth(modalWidth - 4)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted)
+	redoStyle := mutedStyle.
+		MaxWidth(modalWidth - 4)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("History"))
+	content.WriteString(mutedStyle.Render(fmt.Sprintf("  %d of %d", m.history.pos, len(m.history.steps))))
+	content.WriteString("\n\n")
+
+	// Keep the selected row in view
+	rows := len(m.history.steps) + 1
+	visible := m.height - 12
+	if visible < 5 {
+		visible = 5
+	}
+	start := 0
+	if m.history.selected >= visible {
+		start = m.history.selected - visible + 1
+	}
+	end := min(start+visible, rows)
+
+	for row := start; row < end; row++ {
+		pos := m.historyRowPos(row)
+		label := "start"
+		at := ""
+		if pos > 0 {
+			step := m.history.steps[pos-1]
+			label = step.label
+			at = step.at.Format("15:04:05") + "  "
+		}
+		marker := "  "
+		if pos == m.history.pos {
+			marker = "● "
+		}
+		line := marker + at + label
+
+		style := itemStyle
+		if pos > m.history.pos {
+			style = redoStyle
+		}
+		prefix := "  "
+		if row == m.history.selected {
+			style = selectedStyle
+			prefix = "> "
+		}
+		content.WriteString(style.Render(prefix + line))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render(strings.Repeat("─", modalWidth-4)))
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render("enter:go to  u:undo  ctrl+r:redo  esc:back"))
+
+	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
+}
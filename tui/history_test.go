@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+// selectParameter focuses a parameter of the current section for editing.
+func selectParameter(t *testing.T, model *Model, ctrl control) {
+	t.Helper()
+	model.navigationMode = modeParameterList
+	model.refreshParameterList()
+	for i, item := range model.parameterList.Items() {
+		if param, ok := item.(parameterItem); ok && param.ctrl == ctrl {
+			model.parameterList.Select(i)
+			return
+		}
+	}
+	t.Fatalf("parameter %v not in section %s", ctrl, model.currentSection)
+}
+
+func TestUndoRedo(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	engine := osc.NewServer("127.0.0.1", 0)
+	gains := make(chan float32, 64)
+	engine.HandleFloat("/chroma/gain", func(v float32) { gains <- v })
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	model := NewModel(osc.NewClient("127.0.0.1", engine.Addr().(*net.UDPAddr).Port))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+	start := model.Gain
+
+	// Rapid adjustments of one slider undo as one step
+	selectParameter(t, &model, ctrlGain)
+	for i := 0; i < 4; i++ {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	}
+	selectParameter(t, &model, ctrlMasterEnabled)
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(model.history.steps) != 2 || model.MasterEnabled {
+		t.Fatalf("expected a gain step and a toggle step, got %d steps", len(model.history.steps))
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	if !model.MasterEnabled {
+		t.Error("expected undo to turn master back on")
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	if model.Gain != start {
+		t.Errorf("expected gain back at %v, got %v", start, model.Gain)
+	}
+	// Values arrive in order, ending on the one undone to
+	var last float32
+	timeout := time.After(2 * time.Second)
+	for last != start {
+		select {
+		case last = <-gains:
+		case <-timeout:
+			t.Fatalf("expected gain %v resent, last got %v", start, last)
+		}
+	}
+	if model.undo() {
+		t.Error("expected nothing left to undo")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if model.Gain == start || !model.MasterEnabled {
+		t.Errorf("expected redo to restore the gain change only, got gain %v", model.Gain)
+	}
+
+	// A new change drops what could be redone
+	selectParameter(t, &model, ctrlInputFreeze)
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.redo() || len(model.history.steps) != 2 {
+		t.Errorf("expected the redo step dropped, got %d steps", len(model.history.steps))
+	}
+}
+
+func TestUndoRedo_Merging(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	selectParameter(t, &model, ctrlGain)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	model.history.steps[0].at = time.Now().Add(-2 * historyMergeWindow)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if len(model.history.steps) != 2 {
+		t.Errorf("expected separate steps after a pause, got %d", len(model.history.steps))
+	}
+
+	// Returning to where a step started leaves nothing to undo
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if len(model.history.steps) != 1 {
+		t.Errorf("expected the step dropped, got %d", len(model.history.steps))
+	}
+}
+
+func TestUndoRedo_ClockTempo(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+	model.DelaySync = "1/8."
+	model.resetHistory()
+	before := model.DelayTime
+
+	// A drifting clock retimes delay time as one labelled step
+	model.Update(midi.ClockMsg{BPM: 120, Running: true})
+	model.Update(midi.ClockMsg{BPM: 121, Running: true})
+	if len(model.history.steps) != 1 || !strings.HasPrefix(model.history.steps[0].label, "tempo: ") {
+		t.Fatalf("expected one tempo step, got %+v", model.history.steps)
+	}
+	step := model.history.steps[0]
+	if step.before.values.DelayTime != before || step.after.values.DelayTime != model.DelayTime {
+		t.Errorf("expected the step to span delay time %f to %f, got %f to %f",
+			before, model.DelayTime, step.before.values.DelayTime, step.after.values.DelayTime)
+	}
+}
+
+func TestUndoRedo_PresetLoadAndReset(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	model.Gain = 0.4
+	model.executeCommand("save quiet")()
+	model.Gain = 1.6
+	model.executeCommand("save loud")()
+	model.executeCommand("load quiet")()
+	model.resetHistory()
+
+	// Reordering effects
+	selectParameter(t, &model, ctrlEffectsOrder)
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if model.EffectsOrder[0] != "overdrive" {
+		t.Fatalf("expected filter moved, got %v", model.EffectsOrder)
+	}
+	model.effectsOrderEditMode = false
+	model.navigationMode = modeEffectsList
+
+	model.executeCommand("load loud")()
+	if model.Gain != 1.6 || model.currentPresetName != "loud" {
+		t.Fatalf("expected loud loaded, got %v %q", model.Gain, model.currentPresetName)
+	}
+	model.executeCommand("reset")
+	model.executeCommand("undo")
+	if model.Gain != 1.6 || model.currentPresetName != "loud" {
+		t.Errorf("expected undoing the reset to return to loud, got %v %q", model.Gain, model.currentPresetName)
+	}
+	model.executeCommand("undo")
+	if model.Gain != 0.4 || model.currentPresetName != "quiet" || !model.isDirty {
+		t.Errorf("expected the modified quiet back, got %v %q dirty %v", model.Gain, model.currentPresetName, model.isDirty)
+	}
+	model.executeCommand("undo")
+	if model.EffectsOrder[0] != "filter" || model.isDirty {
+		t.Errorf("expected the order restored and quiet unmodified, got %v dirty %v", model.EffectsOrder, model.isDirty)
+	}
+	model.executeCommand("redo 3")
+	if model.Gain != 0 || model.currentPresetName != "" {
+		t.Errorf("expected redo to reach the reset, got %v %q", model.Gain, model.currentPresetName)
+	}
+
+	// The history view lists every step and jumps to any of them
+	model.executeCommand("history")
+	view := model.View()
+	for _, want := range []string{"reset", "load loud", "effects order", "start"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the history view:\n%s", want, view)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.history.pos != 0 || model.EffectsOrder[0] != "filter" || model.currentPresetName != "quiet" {
+		t.Errorf("expected the starting state, got pos %d", model.history.pos)
+	}
+}
@@ -177,13 +177,23 @@ func (m *Model) buildParameterList(section string) []list.Item {
 		items = []list.Item{
 			newParameterItem("enabled", "Delay", 0, 0, 0, ctrlDelayEnabled, true, m.DelayEnabled, m.sliderWidth),
 			newParameterItem("time", "Time", m.DelayTime, 0.1, 1, ctrlDelayTime, false, false, m.sliderWidth),
+			newParameterItemWithDesc("timeSync", "Time Sync", m.formatSync(m.DelaySync), ctrlDelaySync, m.sliderWidth),
 			newParameterItem("decay", "Decay", m.DelayDecayTime, 0.5, 10, ctrlDelayDecayTime, false, false, m.sliderWidth),
 			newParameterItem("modRate", "Mod Rate", m.ModRate, 0.1, 10, ctrlModRate, false, false, m.sliderWidth),
+			newParameterItemWithDesc("modSync", "Rate Sync", m.formatSync(m.ModSync), ctrlModSync, m.sliderWidth),
 			newParameterItem("modDepth", "Mod Depth", m.ModDepth, 0, 1, ctrlModDepth, false, false, m.sliderWidth),
 			newParameterItem("mix", "Mix", m.DelayMix, 0, 1, ctrlDelayMix, false, false, m.sliderWidth),
 		}
 	}
 
+	for i, item := range items {
+		pi := item.(parameterItem)
+		if m.awaitingPickup(controlParams[pi.ctrl]) {
+			pi.title += " (pickup)"
+			items[i] = pi
+		}
+	}
+
 	return items
 }
 
@@ -131,7 +131,7 @@ func TestLists_buildParameterList(t *testing.T) {
 		{"bitcrush", 5},
 		{"granular", 8},
 		{"reverb", 3},
-		{"delay", 6},
+		{"delay", 8},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,496 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
+)
+
+// MIDIMappings receives edited MIDI mappings so they apply straight away.
+type MIDIMappings interface {
+	SetConfig(cfg config.Config)
+}
+
+// mappingInput is the value being typed in the mapping editor.
+type mappingInput int
+
+const (
+	mappingInputNone mappingInput = iota
+	mappingInputSource
+	mappingInputRange
+)
+
+// mappingEditorState holds the MIDI mapping editor's selection and input.
+type mappingEditorState struct {
+	profile   int // Index into the config's profile names
+	selection int // Index into config.Params()
+	input     mappingInput
+	buffer    string
+	learning  bool   // The next MIDI message becomes the source
+	dirty     bool   // Edits not yet saved to the config file
+	message   string // Result of the last action
+}
+
+// SetMIDIConfig sets the MIDI mappings shown in the mapping editor, and where
+// edits are applied. target may be nil.
+func (m *Model) SetMIDIConfig(cfg config.Config, target MIDIMappings) {
+	m.midiConfig = cfg
+	m.midiMappings = target
+}
+
+// mappingProfile returns the name of the device profile being edited, empty
+// for the top-level mappings, and its config.
+func (m *Model) mappingProfile() (string, config.Config) {
+	profiles := m.midiConfig.Profiles()
+	names := config.ProfileNames(profiles)
+	name := names[m.mappingEditor.profile%len(names)]
+	return name, profiles[name]
+}
+
+// selectedMappingParam returns the parameter selected in the editor.
+func (m *Model) selectedMappingParam() config.Param {
+	params := config.Params()
+	idx := m.mappingEditor.selection
+	if idx < 0 || idx >= len(params) {
+		idx = 0
+	}
+	return params[idx]
+}
+
+// editMappings applies edit to the profile being edited. The result must
+// validate, and is sent to the MIDI handler so it takes effect at once.
+func (m *Model) editMappings(edit func(cfg *config.Config) error) bool {
+	name, _ := m.mappingProfile()
+	cfg := m.midiConfig.Clone()
+	target := &cfg
+	var dev config.Config
+	if name != "" {
+		dev = cfg.Devices[name]
+		target = &dev
+	}
+	if err := edit(target); err != nil {
+		m.mappingEditor.message = err.Error()
+		return false
+	}
+	if name != "" {
+		cfg.Devices[name] = dev
+	}
+	if err := cfg.Validate(); err != nil {
+		m.mappingEditor.message = err.Error()
+		return false
+	}
+
+	m.midiConfig = cfg
+	m.mappingEditor.dirty = true
+	m.mappingEditor.message = ""
+	if m.playback.player != nil {
+		m.playback.player.SetConfig(cfg)
+	}
+	if m.midiMappings != nil {
+		m.midiMappings.SetConfig(cfg)
+	}
+	return true
+}
+
+// setMappingSource maps the selected parameter to src alone.
+func (m *Model) setMappingSource(src config.Source) {
+	param := m.selectedMappingParam()
+	m.editMappings(func(cfg *config.Config) error {
+		return cfg.SetSource(param.Name, src)
+	})
+}
+
+// editMappingOptions changes the selected parameter's mapping options.
+func (m *Model) editMappingOptions(change func(p config.Param, opts *config.MappingOptions) error) {
+	param := m.selectedMappingParam()
+	m.editMappings(func(cfg *config.Config) error {
+		opts := cfg.MappingOptions(param.Name)
+		if err := change(param, &opts); err != nil {
+			return err
+		}
+		cfg.SetMappingOptions(param.Name, opts)
+		return nil
+	})
+}
+
+// learnMapping maps the selected parameter to the control that sent msg,
+// on msg's channel, while learning. With device profiles only the edited
+// device's port is learned from.
+func (m *Model) learnMapping(msg midi.MonitorMsg) {
+	if !m.mappingEditor.learning || msg.Echo {
+		return
+	}
+	if name, _ := m.mappingProfile(); name != "" && msg.Port != m.devicePort(name) {
+		return
+	}
+	var src config.Source
+	switch msg.Type {
+	case "CC":
+		src = config.Source{Kind: config.SourceCC, Number: msg.Number}
+	case "Note On":
+		src = config.Source{Kind: config.SourceNote, Number: msg.Number}
+	case "Poly Pressure":
+		src = config.Source{Kind: config.SourcePolyPressure, Number: msg.Number}
+	case "Pitch Bend":
+		src = config.Source{Kind: config.SourcePitchBend}
+	case "Pressure":
+		src = config.Source{Kind: config.SourcePressure}
+	default:
+		return
+	}
+	src.Channel = msg.Channel
+	m.mappingEditor.learning = false
+	m.setMappingSource(src)
+}
+
+// devicePort returns the port a device profile is connected to, or "" while
+// it is disconnected.
+func (m *Model) devicePort(device string) string {
+	for _, status := range m.midiStatus {
+		if status.Device == device && status.Connected {
+			return status.Port
+		}
+	}
+	return ""
+}
+
+// parseRange parses "low high" in parameter units; empty clears the range.
+func parseRange(text string) ([]float32, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("range needs a low and high value")
+	}
+	out := make([]float32, 2)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range value %q", field)
+		}
+		out[i] = float32(v)
+	}
+	return out, nil
+}
+
+// nextOption returns the option after current in options, wrapping around.
+func nextOption(options []string, current string) string {
+	for i, opt := range options {
+		if opt == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return options[0]
+}
+
+// formatMappingRow renders one parameter's mapping.
+func formatMappingRow(cfg config.Config, param config.Param, conflicted map[string]bool) string {
+	var sources []string
+	for _, src := range cfg.Sources(param.Name) {
+		sources = append(sources, src.String())
+	}
+	source := strings.Join(sources, ", ")
+	if source == "" {
+		source = "-"
+	}
+
+	opts := cfg.MappingOptions(param.Name)
+	rangeText, curve, takeover := "-", "-", "-"
+	if param.Kind == config.ParamContinuous {
+		rangeText = "full"
+		if len(opts.Range) == 2 {
+			rangeText = fmt.Sprintf("%g-%g", opts.Range[0], opts.Range[1])
+		}
+		curve = config.CurveLinear
+		if opts.Curve != "" {
+			curve = opts.Curve
+		}
+		takeover = cfg.TakeoverMode(param.Name)
+		if opts.ReturnToCenter {
+			rangeText += " ±"
+		}
+	}
+
+	marker := " "
+	if conflicted[param.Name] {
+		marker = "!"
+	}
+	return fmt.Sprintf("%s %-24.24s %-16.16s %-13.13s %-11.11s %s", marker, param.Name, source, rangeText, curve, takeover)
+}
+
+func (m *Model) renderMappingEditor() string {
+	modalWidth := 90
+	if m.width > 0 && m.width < modalWidth+4 {
+		modalWidth = m.width - 4
+	}
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true)
+	headerStyle := lipgloss.NewStyle().
+		Foreground(colorSecondary)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(colorTextNormal).
+		MaxWidth(modalWidth - 4)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true).
+		MaxWidth(modalWidth - 4)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted).
+		Width(modalWidth - 4)
+	errorStyle := lipgloss.NewStyle().
+		Foreground(colorTextError).
+		Width(modalWidth - 4)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+
+	editor := m.mappingEditor
+	name, cfg := m.mappingProfile()
+	conflicts := cfg.Conflicts()
+	conflicted := make(map[string]bool)
+	for _, names := range conflicts {
+		for _, n := range names {
+			conflicted[n] = true
+		}
+	}
+
+	var content strings.Builder
+
+	title := "MIDI Mappings"
+	if name != "" {
+		title += ": " + name
+	}
+	if cfg.Profile != "" {
+		title += " (" + cfg.Profile + ")"
+	}
+	if editor.dirty {
+		title += " *"
+	}
+	content.WriteString(titleStyle.Render(title))
+	content.WriteString("\n\n")
+	content.WriteString(headerStyle.Render(fmt.Sprintf("  %-24s %-16s %-13s %-11s %s", "Parameter", "Source", "Range", "Curve", "Takeover")))
+	content.WriteString("\n")
+
+	params := config.Params()
+	rows := m.height - 16
+	if rows < 5 {
+		rows = 5
+	}
+	start := editor.selection - rows/2
+	if start > len(params)-rows {
+		start = len(params) - rows
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + rows
+	if end > len(params) {
+		end = len(params)
+	}
+	for i := start; i < end; i++ {
+		row := formatMappingRow(cfg, params[i], conflicted)
+		if i == editor.selection {
+			content.WriteString(selectedStyle.Render(row))
+		} else {
+			content.WriteString(itemStyle.Render(row))
+		}
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+
+	// Conflicts on the selected parameter, then any input or message
+	selected := m.selectedMappingParam()
+	var sources []config.Source
+	for src := range conflicts {
+		sources = append(sources, src)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].String() < sources[j].String() })
+	for _, src := range sources {
+		for _, n := range conflicts[src] {
+			if n == selected.Name {
+				content.WriteString(errorStyle.Render(fmt.Sprintf("Conflict: %s drives %s", src, strings.Join(conflicts[src], ", "))))
+				content.WriteString("\n")
+				break
+			}
+		}
+	}
+	switch {
+	case editor.learning:
+		content.WriteString(mutedStyle.Render("Move a control to map " + selected.Name + "... (esc to cancel)"))
+	case editor.input == mappingInputSource:
+		content.WriteString(titleStyle.Render("Source: " + editor.buffer + "_"))
+		content.WriteString("\n")
+		content.WriteString(mutedStyle.Render("cc 7, note 60, cc14 4, nrpn 300, rpn 0, poly 60, pb, at, mw; add ch 2 for one channel"))
+	case editor.input == mappingInputRange:
+		content.WriteString(titleStyle.Render(fmt.Sprintf("Range (%g-%g): %s_", selected.Min, selected.Max, editor.buffer)))
+		content.WriteString("\n")
+		content.WriteString(mutedStyle.Render("low high, high first to invert, empty for the full range"))
+	case editor.message != "":
+		content.WriteString(mutedStyle.Render(editor.message))
+	default:
+		content.WriteString(mutedStyle.Render("Sources respond on every MIDI channel unless given one, as in cc 7 ch 2"))
+	}
+	content.WriteString("\n")
+
+	content.WriteString(mutedStyle.Render(strings.Repeat("─", modalWidth-4)))
+	content.WriteString("\n")
+	footer := "enter:source  L:learn  d:clear  r:range  c:curve  t:takeover  s:save  esc:back"
+	if len(m.midiConfig.Devices) > 0 {
+		footer = "tab:device  " + footer
+	}
+	content.WriteString(mutedStyle.Render(footer))
+
+	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
+}
+
+// updateMappingEditor handles updates on the MIDI mapping editor screen.
+func (m *Model) updateMappingEditor(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.mappingEditor.input != mappingInputNone {
+			return m.handleMappingInputKey(msg)
+		}
+		if m.mappingEditor.learning {
+			if msg.Type == tea.KeyEsc {
+				m.mappingEditor.learning = false
+			}
+			return m, nil
+		}
+		return m.handleMappingEditorKey(msg)
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	}
+	return m, nil
+}
+
+func (m *Model) handleMappingEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	editor := &m.mappingEditor
+	switch msg.String() {
+	case "esc", "q":
+		editor.message = ""
+		m.goBack()
+
+	case "j", "down":
+		if editor.selection < len(config.Params())-1 {
+			editor.selection++
+		}
+	case "k", "up":
+		if editor.selection > 0 {
+			editor.selection--
+		}
+
+	case "tab":
+		editor.profile = (editor.profile + 1) % len(m.midiConfig.Profiles())
+
+	case "enter", "e":
+		editor.input = mappingInputSource
+		editor.buffer = ""
+	case "L":
+		editor.learning = true
+	case "d", "x", "backspace":
+		param := m.selectedMappingParam()
+		m.editMappings(func(cfg *config.Config) error {
+			cfg.ClearSources(param.Name)
+			return nil
+		})
+
+	case "r":
+		if m.selectedMappingParam().Kind == config.ParamContinuous {
+			editor.input = mappingInputRange
+			editor.buffer = ""
+		}
+	case "c":
+		if m.selectedMappingParam().Kind == config.ParamContinuous {
+			m.editMappingOptions(func(p config.Param, opts *config.MappingOptions) error {
+				curve := opts.Curve
+				if curve == "" {
+					curve = config.CurveLinear
+				}
+				opts.Curve = nextOption(config.Curves, curve)
+				if opts.Curve == config.CurveLinear {
+					opts.Curve = ""
+				}
+				return nil
+			})
+		}
+	case "t":
+		if m.selectedMappingParam().Kind == config.ParamContinuous {
+			_, cfg := m.mappingProfile()
+			m.editMappingOptions(func(p config.Param, opts *config.MappingOptions) error {
+				opts.Takeover = nextOption(config.TakeoverModes, cfg.TakeoverMode(p.Name))
+				return nil
+			})
+		}
+
+	case "s":
+		m.saveMappings()
+	}
+	return m, nil
+}
+
+func (m *Model) handleMappingInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	editor := &m.mappingEditor
+	switch msg.Type {
+	case tea.KeyEsc:
+		editor.input = mappingInputNone
+	case tea.KeyEnter:
+		input := editor.input
+		editor.input = mappingInputNone
+		switch input {
+		case mappingInputSource:
+			src, err := config.ParseSource(editor.buffer)
+			if err != nil {
+				editor.message = err.Error()
+				return m, nil
+			}
+			m.setMappingSource(src)
+		case mappingInputRange:
+			r, err := parseRange(editor.buffer)
+			if err != nil {
+				editor.message = err.Error()
+				return m, nil
+			}
+			m.editMappingOptions(func(p config.Param, opts *config.MappingOptions) error {
+				opts.Range = r
+				return nil
+			})
+		}
+	case tea.KeyBackspace:
+		if len(editor.buffer) > 0 {
+			editor.buffer = editor.buffer[:len(editor.buffer)-1]
+		}
+	case tea.KeySpace:
+		editor.buffer += " "
+	case tea.KeyRunes:
+		editor.buffer += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// saveMappings writes the edited mappings to the MIDI config file.
+func (m *Model) saveMappings() {
+	path, err := config.ConfigPath()
+	if err == nil {
+		err = config.SaveMappings(m.midiConfig, path)
+	}
+	if err != nil {
+		m.mappingEditor.message = "Save failed: " + err.Error()
+		return
+	}
+	m.mappingEditor.dirty = false
+	m.mappingEditor.message = "Saved to " + path
+}
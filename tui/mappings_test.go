@@ -0,0 +1,188 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+type mappingsSink struct {
+	configs []config.Config
+}
+
+func (s *mappingsSink) SetConfig(cfg config.Config) {
+	s.configs = append(s.configs, cfg)
+}
+
+func newMappingEditorModel(t *testing.T) (*Model, *mappingsSink) {
+	t.Helper()
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+	sink := &mappingsSink{}
+	model.SetMIDIConfig(config.DefaultConfig(), sink)
+	model.executeCommand("mappings")
+	if model.screen != screenMappingEditor {
+		t.Fatalf("expected :mappings to open the editor, got screen %d", model.screen)
+	}
+	return &model, sink
+}
+
+func typeKeys(m *Model, text string) {
+	for _, r := range text {
+		if r == ' ' {
+			m.Update(tea.KeyMsg{Type: tea.KeySpace})
+			continue
+		}
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestMappingEditor_ReassignAndClear(t *testing.T) {
+	model, sink := newMappingEditorModel(t)
+
+	// gain is the second parameter
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if model.selectedMappingParam().Name != "gain" {
+		t.Fatalf("expected gain selected, got %s", model.selectedMappingParam().Name)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(model, "cc 20")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if sources := model.midiConfig.Sources("gain"); len(sources) != 1 || sources[0].Number != 20 {
+		t.Errorf("expected gain on CC 20, got %v", sources)
+	}
+	if len(sink.configs) != 1 || sink.configs[0].CC["gain"] != 20 {
+		t.Errorf("expected the edit to be applied to the handler, got %d", len(sink.configs))
+	}
+	if !strings.Contains(model.View(), "CC 20") {
+		t.Error("expected the editor to show the new source")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if sources := model.midiConfig.Sources("gain"); len(sources) != 0 {
+		t.Errorf("expected gain cleared, got %v", sources)
+	}
+
+	// Invalid sources leave the mappings alone
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(model, "cc 300")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(model.mappingEditor.message, "out of range") {
+		t.Errorf("expected a range error, got %q", model.mappingEditor.message)
+	}
+}
+
+func TestMappingEditor_OptionsAndConflicts(t *testing.T) {
+	model, _ := newMappingEditorModel(t)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}) // gain
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	typeKeys(model, "0.5 1.5")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	opts := model.midiConfig.MappingOptions("gain")
+	if opts.Curve != config.CurveExponential || opts.Takeover != config.TakeoverPickup || len(opts.Range) != 2 || opts.Range[1] != 1.5 {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	// A range outside the parameter is rejected
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	typeKeys(model, "0 5")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.midiConfig.MappingOptions("gain").Range[1] != 1.5 {
+		t.Error("expected an invalid range to be rejected")
+	}
+
+	// Putting gain on dry/wet's CC is flagged
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(model, "cc 11")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(model.View(), "Conflict: CC 11 drives gain, dry_wet") {
+		t.Errorf("expected a conflict warning:\n%s", model.View())
+	}
+
+	// but not once they are on different channels
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(model, "cc 11 ch 2")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	for i, p := range config.Params() {
+		if p.Name == "dry_wet" {
+			model.mappingEditor.selection = i
+		}
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(model, "cc 11 ch 1")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	view := model.View()
+	if strings.Contains(view, "Conflict:") || !strings.Contains(view, "CC 11 ch 1") {
+		t.Errorf("expected dry_wet on channel 1 without a conflict:\n%s", view)
+	}
+	if opts := model.midiConfig.MappingOptions("gain"); opts.Channel != 2 || opts.Curve != config.CurveExponential {
+		t.Errorf("expected gain on channel 2 with its options kept, got %+v", opts)
+	}
+}
+
+func TestMappingEditor_LearnAndSave(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model, _ := newMappingEditorModel(t)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}) // gain
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	model.Update(midi.MonitorMsg{Channel: 3, Type: "Pitch Bend", Number: -1, Value: 9000})
+	if sources := model.midiConfig.Sources("gain"); len(sources) != 1 || sources[0].Kind != config.SourcePitchBend || sources[0].Channel != 3 {
+		t.Errorf("expected gain learned from pitch bend on channel 3, got %v", sources)
+	}
+	if model.mappingEditor.learning {
+		t.Error("expected learning to stop after one message")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if model.mappingEditor.dirty || !strings.HasPrefix(model.mappingEditor.message, "Saved") {
+		t.Fatalf("expected save to succeed, got %q", model.mappingEditor.message)
+	}
+	loaded, err := config.LoadWithProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sources := loaded.Sources("gain"); len(sources) != 1 || sources[0].Kind != config.SourcePitchBend {
+		t.Errorf("expected the saved config to map gain to pitch bend, got %v", sources)
+	}
+}
+
+func TestMappingEditor_LearnFromDevicePort(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model, _ := newMappingEditorModel(t)
+	cfg := config.Config{Devices: map[string]config.Config{
+		"faders": {InputPort: "nanoKONTROL2"},
+		"pads":   {InputPort: "MPD218"},
+	}}
+	model.SetMIDIConfig(cfg, nil)
+	model.Update(midi.StatusMsg{Device: "faders", Port: "nanoKONTROL2 MIDI 1", Connected: true})
+	model.Update(midi.StatusMsg{Device: "pads", Port: "MPD218 MIDI 1", Connected: true})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}) // gain
+
+	// Editing faders, so a pad is ignored until a fader moves
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	model.Update(midi.MonitorMsg{Port: "MPD218 MIDI 1", Channel: 10, Type: "Note On", Number: 36, Value: 100})
+	if !model.mappingEditor.learning {
+		t.Fatal("expected learning to ignore another device's port")
+	}
+	model.Update(midi.MonitorMsg{Port: "nanoKONTROL2 MIDI 1", Channel: 1, Type: "CC", Number: 16, Value: 64})
+	faders := model.midiConfig.Devices["faders"]
+	if sources := faders.Sources("gain"); len(sources) != 1 || sources[0] != (config.Source{Kind: config.SourceCC, Number: 16, Channel: 1}) {
+		t.Errorf("expected faders to learn gain from CC 16 on channel 1, got %v", sources)
+	}
+	if sources := model.midiConfig.Devices["pads"].Sources("gain"); len(sources) != 0 {
+		t.Errorf("expected pads left alone, got %v", sources)
+	}
+}
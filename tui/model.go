@@ -4,6 +4,7 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
 	"github.com/renderorange/chroma/chroma-control/osc"
 )
 
@@ -21,6 +22,12 @@ var (
 
 type control int
 
+// MIDIFeedback receives normalized parameter values to show on a MIDI
+// controller. It is implemented by midi.Handler.
+type MIDIFeedback interface {
+	Feedback(param string, value float32, force bool)
+}
+
 type navigationMode int
 
 const (
@@ -36,6 +43,10 @@ const (
 	screenSettings
 	screenHelp
 	screenPresetBrowser
+	screenMIDIMonitor
+	screenMappingEditor
+	screenEffectSnapshots
+	screenHistory
 )
 
 type splashOption int
@@ -63,15 +74,31 @@ const (
 	browserModeList presetBrowserMode = iota
 	browserModeConfirmDelete
 	browserModeSaveAs
+	browserModeReport
+	browserModeNewFolder
+	browserModeRename
+	browserModeMove
+	browserModeTag
+	browserModeDiff
 )
 
 type presetBrowserState struct {
-	mode        presetBrowserMode
-	presets     []string
-	selectedIdx int
-	inputBuffer string
-	confirmName string
-	errorMsg    string
+	mode          presetBrowserMode
+	folder        string   // Folder being browsed, "" for the top level
+	folders       []string // Folders inside it, listed before the presets
+	presets       []string
+	selectedIdx   int
+	inputBuffer   string
+	confirmName   string
+	confirmFolder bool                         // Whether confirmName is a folder
+	tag           string                       // Only list presets with this tag, from all folders
+	metas         map[string]config.PresetMeta // Metadata of the listed presets
+	errorMsg      string
+	report        []string // Changes made upgrading or fixing the last preset loaded, or an import's outcome
+	reportName    string
+	reportTitle   string // Replaces the upgrade title for other reports, like imports
+	diff          []config.PresetChange
+	diffTitle     string
 }
 
 const (
@@ -115,6 +142,8 @@ const (
 	ctrlEffectsOrder
 	ctrlEffectsMoveUp
 	ctrlEffectsMoveDown
+	ctrlDelaySync
+	ctrlModSync
 	ctrlCount
 )
 
@@ -155,6 +184,8 @@ type Model struct {
 	ModRate              float32
 	ModDepth             float32
 	DelayMix             float32
+	DelaySync            string // Note division delay time follows, empty when off
+	ModSync              string // Note division one mod cycle follows, empty when off
 	BlendMode            int
 	DryWet               float32
 	EffectsOrder         []string // Current effects processing order
@@ -165,7 +196,7 @@ type Model struct {
 	effectsOrderEditMode bool // Whether we're in effects reorder mode
 	effectGrabbed        bool // Whether the selected effect is grabbed for moving
 	connected            bool
-	midiPort             string
+	midiStatus           []midi.StatusMsg // Connection state of each MIDI input device
 	width                int
 	height               int
 	sliderWidth          int
@@ -183,6 +214,32 @@ type Model struct {
 	// OSC
 	client *osc.Client
 
+	// MIDI controller feedback
+	midiFeedback   MIDIFeedback
+	feedbackValues map[string]float32       // Last values sent as feedback
+	takeover       map[string]takeoverState // Soft takeover state by parameter
+	bendBase       map[string]float32       // Values before a return-to-center pitch bend
+
+	// Tempo from MIDI clock
+	clock midi.ClockMsg
+
+	morph morphState // Crossfade between two presets
+
+	snapshots effectSnapshotState // Effect snapshot screen
+
+	history historyState // Undo and redo
+
+	// MIDI monitor
+	monitor midiMonitorState
+
+	// MIDI mapping editor
+	midiConfig    config.Config
+	midiMappings  MIDIMappings
+	mappingEditor mappingEditorState
+
+	// MIDI file playback
+	playback playbackState
+
 	// Version
 	version string
 
@@ -202,7 +259,8 @@ type Model struct {
 
 	// Current preset tracking
 	currentPresetName string
-	loadedPresetHash  string // Hash at load time
+	loadedPresetHash  string            // Hash at load time
+	presetMeta        config.PresetMeta // Metadata of the loaded preset, saved with it
 
 	// Dirty tracking
 	isDirty bool
@@ -271,6 +329,7 @@ func NewModel(client *osc.Client) Model {
 		showTitle:           true,
 		sliderWidth:         10, // default, will be recalculated on resize
 		splashSelection:     splashLast,
+		midiConfig:          config.DefaultConfig(),
 	}
 
 	// Load UI settings only
@@ -291,6 +350,7 @@ func NewModel(client *osc.Client) Model {
 		}
 	}
 
+	m.resetHistory()
 	return m
 }
 
@@ -396,7 +456,29 @@ func (m *Model) IsConnected() bool {
 }
 
 func (m *Model) SetMidiPort(name string) {
-	m.midiPort = name
+	m.midiStatus = nil
+	if name != "" {
+		m.midiStatus = []midi.StatusMsg{{Port: name, Connected: true}}
+	}
+}
+
+// SetMIDIStatus records a MIDI input device's connection state for the
+// status bar, replacing any earlier state for the same device.
+func (m *Model) SetMIDIStatus(status midi.StatusMsg) {
+	for i, s := range m.midiStatus {
+		if s.Device == status.Device {
+			m.midiStatus[i] = status
+			return
+		}
+	}
+	m.midiStatus = append(m.midiStatus, status)
+}
+
+// SetMIDIFeedback sets where parameter changes are sent as controller
+// feedback.
+func (m *Model) SetMIDIFeedback(f MIDIFeedback) {
+	m.midiFeedback = f
+	m.feedbackValues = nil
 }
 
 func (m *Model) SetConnected(connected bool) {
@@ -433,7 +515,44 @@ func (m *Model) syncParameterPanel() {
 	}
 }
 
+// applyValues sets the values of preset without loading it: the loaded
+// preset stays the same, and only the values that change are sent.
+func (m *Model) applyValues(preset config.Preset) {
+	before := m.buildCurrentPreset()
+	m.setPresetValues(preset)
+	m.applyTempo()
+	for _, change := range config.DiffPresets(before, m.buildCurrentPreset()) {
+		m.sendParam(change.Key)
+	}
+
+	m.refreshEffectsList()
+	if len(m.parameterList.Items()) > 0 {
+		m.refreshParameterList()
+	}
+	m.checkDirty()
+}
+
 func (m *Model) applyPreset(preset config.Preset) {
+	m.setPresetValues(preset)
+	m.applyTempo()
+
+	// Refresh UI
+	m.refreshEffectsList()
+	m.refreshParameterList()
+
+	// Send to SuperCollider
+	m.syncAllToOSC()
+
+	m.presetMeta = preset.Meta
+
+	// Store hash for dirty detection
+	m.loadedPresetHash = preset.Hash()
+	m.checkDirty()
+}
+
+// setPresetValues sets every parameter from a preset, without sending
+// anything to SuperCollider.
+func (m *Model) setPresetValues(preset config.Preset) {
 	// Master
 	m.MasterEnabled = preset.MasterEnabled
 	m.Gain = preset.Gain
@@ -441,7 +560,7 @@ func (m *Model) applyPreset(preset config.Preset) {
 	m.InputFreezeLength = preset.InputFreezeLength
 	m.DryWet = preset.DryWet
 	m.BlendMode = preset.BlendMode
-	m.EffectsOrder = preset.EffectsOrder
+	m.EffectsOrder = append([]string(nil), preset.EffectsOrder...) // Reordering works in place
 
 	// Filter
 	m.FilterEnabled = preset.FilterEnabled
@@ -485,28 +604,21 @@ func (m *Model) applyPreset(preset config.Preset) {
 	m.ModRate = preset.ModRate
 	m.ModDepth = preset.ModDepth
 	m.DelayMix = preset.DelayMix
-
-	// Refresh UI
-	m.refreshEffectsList()
-	m.refreshParameterList()
-
-	// Send to SuperCollider
-	m.syncAllToOSC()
-
-	// Store hash for dirty detection
-	m.loadedPresetHash = preset.Hash()
-	m.checkDirty()
+	m.DelaySync = preset.DelaySync
+	m.ModSync = preset.ModSync
 }
 
 func (m *Model) buildCurrentPreset() config.Preset {
 	return config.Preset{
+		SchemaVersion:        config.PresetSchemaVersion,
+		Meta:                 m.presetMeta,
 		MasterEnabled:        m.MasterEnabled,
 		Gain:                 m.Gain,
 		InputFrozen:          m.InputFrozen,
 		InputFreezeLength:    m.InputFreezeLength,
 		DryWet:               m.DryWet,
 		BlendMode:            m.BlendMode,
-		EffectsOrder:         m.EffectsOrder,
+		EffectsOrder:         append([]string(nil), m.EffectsOrder...),
 		FilterEnabled:        m.FilterEnabled,
 		FilterAmount:         m.FilterAmount,
 		FilterCutoff:         m.FilterCutoff,
@@ -538,12 +650,16 @@ func (m *Model) buildCurrentPreset() config.Preset {
 		ModRate:              m.ModRate,
 		ModDepth:             m.ModDepth,
 		DelayMix:             m.DelayMix,
+		DelaySync:            m.DelaySync,
+		ModSync:              m.ModSync,
 	}
 }
 
 func (m *Model) checkDirty() {
 	current := m.buildCurrentPreset()
-	m.isDirty = m.loadedPresetHash != current.Hash()
+	hash := current.Hash()
+	m.isDirty = m.loadedPresetHash != hash
+	m.recordHistory(current, hash)
 }
 
 func (m *Model) saveUISettings() {
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/renderorange/chroma/chroma-control/midi"
+)
+
+// monitorSize is the number of MIDI messages kept by the monitor.
+const monitorSize = 200
+
+// midiMonitorState holds the MIDI monitor log, oldest entry first.
+type midiMonitorState struct {
+	entries []midi.MonitorMsg
+	paused  bool
+	scroll  int // Entries scrolled back from the newest
+}
+
+// log adds an entry unless the monitor is paused, dropping the oldest once
+// the log is full.
+func (s *midiMonitorState) log(msg midi.MonitorMsg) {
+	if s.paused {
+		return
+	}
+	if len(s.entries) == monitorSize {
+		copy(s.entries, s.entries[1:])
+		s.entries = s.entries[:monitorSize-1]
+	}
+	s.entries = append(s.entries, msg)
+	// Keep a scrolled view on the same entries as new ones arrive
+	if s.scroll > 0 && s.scroll < len(s.entries)-1 {
+		s.scroll++
+	}
+}
+
+func (s *midiMonitorState) clear() {
+	s.entries = nil
+	s.scroll = 0
+}
+
+// monitorRows returns the number of log rows that fit on screen.
+func (m *Model) monitorRows() int {
+	rows := m.height - 12
+	if rows < 5 {
+		rows = 5
+	}
+	return rows
+}
+
+// formatMonitorEntry renders one log row.
+func formatMonitorEntry(e midi.MonitorMsg) string {
+	channel := "-"
+	if e.Channel > 0 {
+		channel = fmt.Sprint(e.Channel)
+	}
+	number := "-"
+	if e.Number >= 0 {
+		number = fmt.Sprint(e.Number)
+	}
+	value := "-"
+	if e.Value >= 0 {
+		value = fmt.Sprint(e.Value)
+	}
+	resolved := strings.Join(e.Resolved, ", ")
+	switch {
+	case e.Echo:
+		resolved = "echo"
+	case resolved == "":
+		resolved = "unmapped"
+	}
+	return fmt.Sprintf("%-14.14s %2s  %-13.13s %5s %5s  %s", e.Port, channel, e.Type, number, value, resolved)
+}
+
+func (m *Model) renderMIDIMonitor() string {
+	modalWidth := 80
+	if m.width > 0 && m.width < modalWidth+4 {
+		modalWidth = m.width - 4
+	}
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true)
+	headerStyle := lipgloss.NewStyle().
+		Foreground(colorSecondary)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(colorTextNormal).
+		MaxWidth(modalWidth - 4)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("MIDI Monitor"))
+	if m.monitor.paused {
+		content.WriteString(mutedStyle.Render("  (paused)"))
+	}
+	content.WriteString("\n\n")
+	for _, status := range m.midiStatus {
+		if status.Suggest != "" {
+			content.WriteString(mutedStyle.Width(modalWidth - 4).Render(fmt.Sprintf("%s matches the built-in %q profile, try --midi-profile %s", status.Port, status.Suggest, status.Suggest)))
+			content.WriteString("\n\n")
+		}
+	}
+	content.WriteString(headerStyle.Render(fmt.Sprintf("%-14s %2s  %-13s %5s %5s  %s", "Port", "Ch", "Type", "Num", "Value", "Parameter")))
+	content.WriteString("\n")
+
+	entries := m.monitor.entries
+	if len(entries) == 0 {
+		content.WriteString(mutedStyle.Render("Waiting for MIDI input..."))
+		content.WriteString("\n")
+	}
+	end := len(entries) - m.monitor.scroll
+	start := end - m.monitorRows()
+	if start < 0 {
+		start = 0
+	}
+	for _, e := range entries[start:end] {
+		content.WriteString(itemStyle.Render(formatMonitorEntry(e)))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render(strings.Repeat("─", modalWidth-4)))
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render("p:pause  c:clear  j/k:scroll  esc:back"))
+
+	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
+}
+
+// updateMIDIMonitor handles updates on the MIDI monitor screen.
+func (m *Model) updateMIDIMonitor(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.goBack()
+		case "p", " ":
+			m.monitor.paused = !m.monitor.paused
+		case "c":
+			m.monitor.clear()
+		case "k", "up":
+			if m.monitor.scroll < len(m.monitor.entries)-1 {
+				m.monitor.scroll++
+			}
+		case "j", "down":
+			if m.monitor.scroll > 0 {
+				m.monitor.scroll--
+			}
+		case "?":
+			m.switchScreen(screenHelp)
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	}
+	return m, nil
+}
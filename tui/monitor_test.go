@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+func TestMIDIMonitor_LogPauseClear(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+	model.executeCommand("monitor")
+	if model.screen != screenMIDIMonitor {
+		t.Fatalf("expected :monitor to open the MIDI monitor, got screen %d", model.screen)
+	}
+
+	model.Update(midi.MonitorMsg{Port: "nanoKONTROL2", Channel: 1, Type: "CC", Number: 1, Value: 100, Resolved: []string{"gain"}})
+	model.Update(midi.MonitorMsg{Port: "nanoKONTROL2", Channel: 1, Type: "CC", Number: 90, Value: 5})
+	if len(model.monitor.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(model.monitor.entries))
+	}
+	view := model.View()
+	if !strings.Contains(view, "gain") || !strings.Contains(view, "unmapped") {
+		t.Errorf("expected resolved and unmapped entries in view:\n%s", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	model.Update(midi.MonitorMsg{Type: "CC"})
+	if len(model.monitor.entries) != 2 {
+		t.Errorf("expected paused monitor to ignore input, got %d entries", len(model.monitor.entries))
+	}
+	if !strings.Contains(model.View(), "paused") {
+		t.Error("expected view to show paused")
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if len(model.monitor.entries) != 0 {
+		t.Errorf("expected clear to empty the log, got %d entries", len(model.monitor.entries))
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if model.screen != screenMain {
+		t.Errorf("expected esc to return to main screen, got %d", model.screen)
+	}
+}
+
+func TestMIDIMonitor_KeepsNewest(t *testing.T) {
+	var s midiMonitorState
+	for i := 0; i < monitorSize+10; i++ {
+		s.log(midi.MonitorMsg{Value: i})
+	}
+	if len(s.entries) != monitorSize {
+		t.Fatalf("expected %d entries, got %d", monitorSize, len(s.entries))
+	}
+	if s.entries[0].Value != 10 || s.entries[monitorSize-1].Value != monitorSize+9 {
+		t.Errorf("expected the oldest entries to be dropped, got %d..%d", s.entries[0].Value, s.entries[monitorSize-1].Value)
+	}
+}
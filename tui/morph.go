@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// morphStep is how far [ and ] move the morph position.
+const morphStep = 0.05
+
+// morphState is a crossfade between two presets, see config.MorphPresets.
+type morphState struct {
+	from, to  string
+	a, b      config.Preset
+	position  float32 // 0 plays a, 1 plays b
+	threshold float32
+}
+
+func (s morphState) active() bool {
+	return s.from != ""
+}
+
+// MorphMsg sets the morph position, 0-1, from outside the TUI, such as an
+// OSC message.
+type MorphMsg struct {
+	Position float32
+}
+
+// startMorph sets up a morph between two saved presets, starting on the
+// first.
+func (m *Model) startMorph(from, to string) error {
+	a, err := config.LoadPreset(from)
+	if err != nil {
+		return err
+	}
+	b, err := config.LoadPreset(to)
+	if err != nil {
+		return err
+	}
+	m.morph = morphState{from: from, to: to, a: a, b: b, threshold: config.LoadSettings().MorphThreshold}
+	m.applyMorph()
+	return nil
+}
+
+// setMorph moves the morph position and applies it. It does nothing when no
+// morph is set up.
+func (m *Model) setMorph(position float32) {
+	if !m.morph.active() {
+		return
+	}
+	m.morph.position = clamp(position, 0, 1)
+	m.applyMorph()
+}
+
+// applyMorph sets every parameter to the current morph position, sending
+// only the values that changed.
+func (m *Model) applyMorph() {
+	if !m.morph.active() {
+		return
+	}
+	m.labelHistory(fmt.Sprintf("morph %s → %s", m.morph.from, m.morph.to), "morph", false)
+	m.applyValues(config.MorphPresets(m.morph.a, m.morph.b, m.morph.position, m.morph.threshold))
+}
+
+// formatMorph returns the morph slider for the status bar, empty when no
+// morph is set up.
+func (m Model) formatMorph() string {
+	if !m.morph.active() {
+		return ""
+	}
+	const width = 10
+	pos := int(m.morph.position*width + 0.5)
+	slider := strings.Repeat("━", pos) + "●" + strings.Repeat("─", width-pos)
+	return lipgloss.NewStyle().Foreground(colorPrimary).Render(
+		fmt.Sprintf("%s %s %s %d%%", m.morph.from, slider, m.morph.to, int(m.morph.position*100+0.5)))
+}
+
+// cmdMorph handles the morph command: ":morph a b" sets up a morph starting
+// on a, ":morph 0.3" moves it, ":morph threshold 0.3" sets where switched
+// values change, and ":morph off" stops morphing, keeping the current
+// values.
+func cmdMorph(m *Model, args []string) tea.Cmd {
+	var err error
+	switch {
+	case len(args) == 0:
+		err = fmt.Errorf("morph needs two presets, e.g. :morph verse chorus")
+	case len(args) == 1 && args[0] == "off":
+		m.morph = morphState{}
+	case len(args) == 2 && args[0] == "threshold":
+		err = setMorphThreshold(m, args[1])
+	case len(args) == 1:
+		var v float64
+		if v, err = strconv.ParseFloat(args[0], 32); err != nil {
+			err = fmt.Errorf("morph position must be 0-1, or give two presets")
+		} else if !m.morph.active() {
+			err = fmt.Errorf("no morph set up, use :morph from to")
+		} else {
+			m.setMorph(float32(v))
+		}
+	default:
+		from, to := splitPresetNames(args)
+		if to == "" {
+			err = fmt.Errorf("morph needs two saved presets")
+		} else {
+			err = m.startMorph(from, to)
+		}
+	}
+	if err != nil {
+		m.showPresetError(err)
+	}
+	return nil
+}
+
+// setMorphThreshold sets the morph threshold, remembering it in the settings
+// file.
+func setMorphThreshold(m *Model, arg string) error {
+	v, err := strconv.ParseFloat(arg, 32)
+	if err != nil || v < 0 || v > 1 {
+		return fmt.Errorf("morph threshold must be 0-1")
+	}
+	settings := config.LoadSettings()
+	settings.MorphThreshold = float32(v)
+	if err := config.SaveSettings(settings); err != nil {
+		return err
+	}
+	m.morph.threshold = float32(v)
+	m.applyMorph()
+	return nil
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+func TestMorph(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// Capture what is streamed to the engine
+	engine := osc.NewServer("127.0.0.1", 0)
+	gains := make(chan float32, 64)
+	engine.HandleFloat("/chroma/gain", func(v float32) { gains <- v })
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	model := NewModel(osc.NewClient("127.0.0.1", engine.Addr().(*net.UDPAddr).Port))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	model.Gain = 0.5
+	model.executeCommand("save verse")()
+	model.Gain = 1.5
+	model.ReverbEnabled = true
+	model.executeCommand("save big chorus")()
+
+	model.executeCommand("morph verse big chorus")
+	if model.Gain != 0.5 || model.ReverbEnabled {
+		t.Fatalf("expected the morph to start on verse, got gain %v reverb %v", model.Gain, model.ReverbEnabled)
+	}
+
+	model.Update(MorphMsg{Position: 0.25})
+	if model.Gain != 0.75 || model.ReverbEnabled {
+		t.Errorf("expected gain 0.75 with reverb still off, got %v %v", model.Gain, model.ReverbEnabled)
+	}
+	if !strings.Contains(model.View(), "verse") || !strings.Contains(model.View(), "25%") {
+		t.Errorf("expected the morph slider in the status bar:\n%s", model.View())
+	}
+
+	for i := 0; i < 6; i++ {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("]")})
+	}
+	if !model.ReverbEnabled || model.Gain < 1 {
+		t.Errorf("expected the slider past the threshold, got gain %v reverb %v", model.Gain, model.ReverbEnabled)
+	}
+
+	// A mapped MIDI CC drives it too
+	model.Update(midi.ControlMsg{Param: "morph", Value: 1})
+	if model.Gain != 1.5 {
+		t.Errorf("expected the CC to finish the morph, got gain %v", model.Gain)
+	}
+
+	// Streamed values arrive in order, ending on the last position
+	var last float32
+	timeout := time.After(2 * time.Second)
+	for last != 1.5 {
+		select {
+		case last = <-gains:
+		case <-timeout:
+			t.Fatalf("expected gain 1.5 streamed, last got %v", last)
+		}
+	}
+
+	model.executeCommand("morph threshold 0.9")
+	model.executeCommand("morph 0.8")
+	if model.ReverbEnabled {
+		t.Error("expected reverb off below the new threshold")
+	}
+	model.executeCommand("morph off")
+	model.Update(MorphMsg{Position: 0})
+	if model.Gain == 0.5 || model.formatMorph() != "" {
+		t.Error("expected morph off to leave the values alone")
+	}
+}
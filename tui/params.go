@@ -0,0 +1,398 @@
+package tui
+
+import (
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// floatParam returns the model field for a continuous parameter name, or nil.
+func (m *Model) floatParam(name string) *float32 {
+	switch name {
+	case "gain":
+		return &m.Gain
+	case "input_freeze_length":
+		return &m.InputFreezeLength
+	case "dry_wet":
+		return &m.DryWet
+	case "filter_amount":
+		return &m.FilterAmount
+	case "filter_cutoff":
+		return &m.FilterCutoff
+	case "filter_resonance":
+		return &m.FilterResonance
+	case "overdrive_drive":
+		return &m.OverdriveDrive
+	case "overdrive_tone":
+		return &m.OverdriveTone
+	case "overdrive_bias":
+		return &m.OverdriveBias
+	case "overdrive_mix":
+		return &m.OverdriveMix
+	case "bit_depth":
+		return &m.BitDepth
+	case "bitcrush_sample_rate":
+		return &m.BitcrushSampleRate
+	case "bitcrush_drive":
+		return &m.BitcrushDrive
+	case "bitcrush_mix":
+		return &m.BitcrushMix
+	case "granular_density":
+		return &m.GranularDensity
+	case "granular_size":
+		return &m.GranularSize
+	case "granular_pitch_scatter":
+		return &m.GranularPitchScatter
+	case "granular_pos_scatter":
+		return &m.GranularPosScatter
+	case "granular_mix":
+		return &m.GranularMix
+	case "reverb_decay_time":
+		return &m.ReverbDecayTime
+	case "reverb_mix":
+		return &m.ReverbMix
+	case "delay_time":
+		return &m.DelayTime
+	case "delay_decay_time":
+		return &m.DelayDecayTime
+	case "mod_rate":
+		return &m.ModRate
+	case "mod_depth":
+		return &m.ModDepth
+	case "delay_mix":
+		return &m.DelayMix
+	case "morph":
+		return &m.morph.position
+	}
+	return nil
+}
+
+// boolParam returns the model field for a toggle parameter name, or nil.
+func (m *Model) boolParam(name string) *bool {
+	switch name {
+	case "master_enabled":
+		return &m.MasterEnabled
+	case "input_frozen":
+		return &m.InputFrozen
+	case "filter_enabled":
+		return &m.FilterEnabled
+	case "overdrive_enabled":
+		return &m.OverdriveEnabled
+	case "bitcrush_enabled":
+		return &m.BitcrushEnabled
+	case "granular_enabled":
+		return &m.GranularEnabled
+	case "granular_frozen":
+		return &m.GranularFrozen
+	case "reverb_enabled":
+		return &m.ReverbEnabled
+	case "delay_enabled":
+		return &m.DelayEnabled
+	}
+	return nil
+}
+
+// sendParam sends the current value of the named parameter to SuperCollider.
+func (m *Model) sendParam(name string) {
+	if m.client == nil {
+		return
+	}
+	switch name {
+	case "master_enabled":
+		m.client.SetMasterEnabled(m.MasterEnabled)
+	case "gain":
+		m.client.SetGain(m.Gain)
+	case "input_frozen":
+		m.client.SetInputFreeze(m.InputFrozen)
+	case "input_freeze_length":
+		m.client.SetInputFreezeLength(m.InputFreezeLength)
+	case "dry_wet":
+		m.client.SetDryWet(m.DryWet)
+	case "blend_mode":
+		m.client.SetBlendMode(m.BlendMode)
+	case "effects_order":
+		m.client.SetEffectsOrder(m.EffectsOrder)
+	case "filter_enabled":
+		m.client.SetFilterEnabled(m.FilterEnabled)
+	case "filter_amount":
+		m.client.SetFilterAmount(m.FilterAmount)
+	case "filter_cutoff":
+		m.client.SetFilterCutoff(m.FilterCutoff)
+	case "filter_resonance":
+		m.client.SetFilterResonance(m.FilterResonance)
+	case "overdrive_enabled":
+		m.client.SetOverdriveEnabled(m.OverdriveEnabled)
+	case "overdrive_drive":
+		m.client.SetOverdriveDrive(m.OverdriveDrive)
+	case "overdrive_tone":
+		m.client.SetOverdriveTone(m.OverdriveTone)
+	case "overdrive_bias":
+		m.client.SetOverdriveBias(m.OverdriveBias)
+	case "overdrive_mix":
+		m.client.SetOverdriveMix(m.OverdriveMix)
+	case "bitcrush_enabled":
+		m.client.SetBitcrushEnabled(m.BitcrushEnabled)
+	case "bit_depth":
+		m.client.SetBitDepth(m.BitDepth)
+	case "bitcrush_sample_rate":
+		m.client.SetBitcrushSampleRate(m.BitcrushSampleRate)
+	case "bitcrush_drive":
+		m.client.SetBitcrushDrive(m.BitcrushDrive)
+	case "bitcrush_mix":
+		m.client.SetBitcrushMix(m.BitcrushMix)
+	case "granular_enabled":
+		m.client.SetGranularEnabled(m.GranularEnabled)
+	case "granular_density":
+		m.client.SetGranularDensity(m.GranularDensity)
+	case "granular_size":
+		m.client.SetGranularSize(m.GranularSize)
+	case "granular_pitch_scatter":
+		m.client.SetGranularPitchScatter(m.GranularPitchScatter)
+	case "granular_pos_scatter":
+		m.client.SetGranularPosScatter(m.GranularPosScatter)
+	case "granular_mix":
+		m.client.SetGranularMix(m.GranularMix)
+	case "granular_frozen":
+		m.client.SetGranularFreeze(m.GranularFrozen)
+	case "grain_intensity":
+		m.client.SetGrainIntensity(m.GrainIntensity)
+	case "reverb_enabled":
+		m.client.SetReverbEnabled(m.ReverbEnabled)
+	case "reverb_decay_time":
+		m.client.SetReverbDecayTime(m.ReverbDecayTime)
+	case "reverb_mix":
+		m.client.SetReverbMix(m.ReverbMix)
+	case "delay_enabled":
+		m.client.SetDelayEnabled(m.DelayEnabled)
+	case "delay_time":
+		m.client.SetDelayTime(m.DelayTime)
+	case "delay_decay_time":
+		m.client.SetDelayDecayTime(m.DelayDecayTime)
+	case "mod_rate":
+		m.client.SetModRate(m.ModRate)
+	case "mod_depth":
+		m.client.SetModDepth(m.ModDepth)
+	case "delay_mix":
+		m.client.SetDelayMix(m.DelayMix)
+	}
+}
+
+// applyControl applies a normalized 0-1 control value to the named parameter
+// and sends the result to SuperCollider. It reports whether the name was known.
+func (m *Model) applyControl(name string, value float32) bool {
+	return m.updateControl(name, value, false)
+}
+
+// toggleControl flips the named parameter's current state. Toggles switch
+// on/off, continuous parameters alternate between their minimum and value,
+// and enums advance to the next option.
+func (m *Model) toggleControl(name string, value float32) bool {
+	return m.updateControl(name, value, true)
+}
+
+func (m *Model) updateControl(name string, value float32, toggle bool) bool {
+	param, ok := config.LookupParam(name)
+	if !ok {
+		return false
+	}
+
+	switch param.Kind {
+	case config.ParamContinuous:
+		field := m.floatParam(param.Name)
+		if field == nil {
+			return false
+		}
+		if toggle && *field > param.Min {
+			*field = param.Min
+		} else {
+			*field = param.Scale(value)
+		}
+		if param.Name == "morph" {
+			m.applyMorph()
+			break
+		}
+		m.unsync(param.Name)
+		m.sendParam(param.Name)
+
+	case config.ParamToggle:
+		field := m.boolParam(param.Name)
+		if field == nil {
+			return false
+		}
+		if toggle {
+			*field = !*field
+		} else {
+			*field = value >= 0.5
+		}
+		m.sendParam(param.Name)
+
+	case config.ParamEnum:
+		if toggle {
+			m.selectOption(param.Name, (m.optionIndex(param.Name)+1)%len(param.Options))
+		} else {
+			m.selectOption(param.Name, int(value*float32(len(param.Options)-1)+0.5))
+		}
+
+	case config.ParamOption:
+		m.selectOption(param.Target, param.Index)
+
+	case config.ParamMove:
+		m.moveEffect(param.Target, param.Index)
+	}
+
+	m.refreshEffectsList()
+	if len(m.parameterList.Items()) > 0 {
+		m.refreshParameterList()
+	}
+	m.checkDirty()
+	return true
+}
+
+// optionIndex returns the index of the current option of an enum parameter.
+func (m *Model) optionIndex(name string) int {
+	switch name {
+	case "blend_mode":
+		return m.BlendMode
+	case "grain_intensity":
+		for i, opt := range config.GrainIntensities {
+			if opt == m.GrainIntensity {
+				return i
+			}
+		}
+	case "delay_sync", "mod_sync":
+		return m.syncIndex(name)
+	}
+	return 0
+}
+
+// selectOption sets an enum parameter to the option at idx.
+func (m *Model) selectOption(name string, idx int) {
+	switch name {
+	case "blend_mode":
+		if idx >= 0 && idx < len(config.BlendModes) {
+			m.setBlendMode(idx)
+		}
+	case "grain_intensity":
+		if idx >= 0 && idx < len(config.GrainIntensities) {
+			m.GrainIntensity = config.GrainIntensities[idx]
+			m.sendParam("grain_intensity")
+		}
+	case "delay_sync", "mod_sync":
+		m.setSync(name, idx)
+	}
+}
+
+// moveEffect moves an effect one slot earlier (-1) or later (1) in the
+// effects order.
+func (m *Model) moveEffect(effect string, direction int) {
+	order := m.GetEffectsOrder()
+	for i, name := range order {
+		if name != effect {
+			continue
+		}
+		j := i + direction
+		if j < 0 || j >= len(order) {
+			return
+		}
+		order[i], order[j] = order[j], order[i]
+		m.SetEffectsOrder(order)
+		m.sendParam("effects_order")
+		return
+	}
+}
+
+// controlValue returns the named parameter's current value normalized to
+// 0-1. Options report 1 while selected. Moves have no value.
+func (m *Model) controlValue(name string) (float32, bool) {
+	param, ok := config.LookupParam(name)
+	if !ok {
+		return 0, false
+	}
+
+	switch param.Kind {
+	case config.ParamContinuous:
+		if field := m.floatParam(param.Name); field != nil {
+			return param.Normalize(*field), true
+		}
+	case config.ParamToggle:
+		if field := m.boolParam(param.Name); field != nil {
+			if *field {
+				return 1, true
+			}
+			return 0, true
+		}
+	case config.ParamEnum:
+		return float32(m.optionIndex(param.Name)) / float32(len(param.Options)-1), true
+	case config.ParamOption:
+		if m.optionIndex(param.Target) == param.Index {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// syncFeedback sends controller feedback for every parameter that changed
+// since the last sync, or for every parameter when force is set.
+func (m *Model) syncFeedback(force bool) {
+	if m.midiFeedback == nil {
+		return
+	}
+	if m.feedbackValues == nil {
+		m.feedbackValues = make(map[string]float32)
+		force = true
+	}
+	for _, param := range config.Params() {
+		value, ok := m.controlValue(param.Name)
+		if !ok {
+			continue
+		}
+		if last, seen := m.feedbackValues[param.Name]; seen && last == value && !force {
+			continue
+		}
+		m.feedbackValues[param.Name] = value
+		m.midiFeedback.Feedback(param.Name, value, force)
+	}
+}
+
+// controlParams maps parameter list controls to their parameter names.
+var controlParams = map[control]string{
+	ctrlMasterEnabled:        "master_enabled",
+	ctrlGain:                 "gain",
+	ctrlInputFreezeLen:       "input_freeze_length",
+	ctrlInputFreeze:          "input_frozen",
+	ctrlFilterEnabled:        "filter_enabled",
+	ctrlFilterAmount:         "filter_amount",
+	ctrlFilterCutoff:         "filter_cutoff",
+	ctrlFilterResonance:      "filter_resonance",
+	ctrlOverdriveEnabled:     "overdrive_enabled",
+	ctrlOverdriveDrive:       "overdrive_drive",
+	ctrlOverdriveTone:        "overdrive_tone",
+	ctrlOverdriveBias:        "overdrive_bias",
+	ctrlOverdriveMix:         "overdrive_mix",
+	ctrlBitcrushEnabled:      "bitcrush_enabled",
+	ctrlBitDepth:             "bit_depth",
+	ctrlBitcrushSampleRate:   "bitcrush_sample_rate",
+	ctrlBitcrushDrive:        "bitcrush_drive",
+	ctrlBitcrushMix:          "bitcrush_mix",
+	ctrlGranularEnabled:      "granular_enabled",
+	ctrlGranularDensity:      "granular_density",
+	ctrlGranularSize:         "granular_size",
+	ctrlGranularPitchScatter: "granular_pitch_scatter",
+	ctrlGranularPosScatter:   "granular_pos_scatter",
+	ctrlGranularMix:          "granular_mix",
+	ctrlGranularFreeze:       "granular_frozen",
+	ctrlGrainIntensity:       "grain_intensity",
+	ctrlReverbEnabled:        "reverb_enabled",
+	ctrlReverbDecayTime:      "reverb_decay_time",
+	ctrlReverbMix:            "reverb_mix",
+	ctrlDelayEnabled:         "delay_enabled",
+	ctrlDelayTime:            "delay_time",
+	ctrlDelayDecayTime:       "delay_decay_time",
+	ctrlModRate:              "mod_rate",
+	ctrlModDepth:             "mod_depth",
+	ctrlDelayMix:             "delay_mix",
+	ctrlBlendMode:            "blend_mode",
+	ctrlDryWet:               "dry_wet",
+	ctrlEffectsOrder:         "effects_order",
+	ctrlDelaySync:            "delay_sync",
+	ctrlModSync:              "mod_sync",
+}
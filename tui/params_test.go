@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+func TestApplyControl_Continuous(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	model.Update(midi.ControlMsg{Param: "filter_cutoff", Value: 1})
+	if model.FilterCutoff != 8000 {
+		t.Errorf("expected filter cutoff 8000, got %f", model.FilterCutoff)
+	}
+
+	model.Update(midi.ControlMsg{Param: "delay_decay_time", Value: 0})
+	if model.DelayDecayTime != 0.1 {
+		t.Errorf("expected delay decay time 0.1, got %f", model.DelayDecayTime)
+	}
+}
+
+func TestApplyControl_ToggleAndEnums(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	model.Update(midi.ControlMsg{Param: "reverb_enabled", Value: 1})
+	if !model.ReverbEnabled {
+		t.Error("expected reverb to be enabled")
+	}
+
+	model.Update(midi.ControlMsg{Param: "grain_intensity", Value: 1})
+	if model.GrainIntensity != "extreme" {
+		t.Errorf("expected grain intensity extreme, got %s", model.GrainIntensity)
+	}
+
+	model.Update(midi.ControlMsg{Param: "blend_mode_transform", Value: 1})
+	if model.BlendMode != 2 {
+		t.Errorf("expected blend mode 2, got %d", model.BlendMode)
+	}
+}
+
+func TestApplyControl_MoveEffect(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	model.Update(midi.ControlMsg{Param: "effects_order_overdrive_up", Value: 1})
+	if model.EffectsOrder[0] != "overdrive" || model.EffectsOrder[1] != "filter" {
+		t.Errorf("expected overdrive to move before filter, got %v", model.EffectsOrder)
+	}
+
+	// Moving the first effect further up is a no-op
+	model.Update(midi.ControlMsg{Param: "effects_order_overdrive_up", Value: 1})
+	if model.EffectsOrder[0] != "overdrive" {
+		t.Errorf("expected overdrive to stay first, got %v", model.EffectsOrder)
+	}
+}
+
+func TestApplyControl_UnknownParam(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+
+	if model.applyControl("not_a_param", 1) {
+		t.Error("expected unknown parameter to be rejected")
+	}
+}
+
+func TestToggleControl(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	// Toggle mode flips the current state on each note
+	model.Update(midi.ControlMsg{Param: "input_frozen", Value: 1, Toggle: true})
+	if !model.InputFrozen {
+		t.Error("expected input freeze on after first toggle")
+	}
+	model.Update(midi.ControlMsg{Param: "input_frozen", Value: 1, Toggle: true})
+	if model.InputFrozen {
+		t.Error("expected input freeze off after second toggle")
+	}
+
+	// Continuous parameters alternate between minimum and the note value
+	model.Update(midi.ControlMsg{Param: "granular_mix", Value: 0.5, Toggle: true})
+	if model.GranularMix != 0 {
+		t.Errorf("expected granular mix at minimum, got %f", model.GranularMix)
+	}
+	model.Update(midi.ControlMsg{Param: "granular_mix", Value: 0.5, Toggle: true})
+	if model.GranularMix != 0.5 {
+		t.Errorf("expected granular mix 0.5, got %f", model.GranularMix)
+	}
+
+	// Enums advance to the next option
+	model.Update(midi.ControlMsg{Param: "grain_intensity", Value: 1, Toggle: true})
+	if model.GrainIntensity != "pronounced" {
+		t.Errorf("expected grain intensity pronounced, got %s", model.GrainIntensity)
+	}
+}
+
+type recordedFeedback struct {
+	values map[string]float32
+	forced int
+}
+
+func (r *recordedFeedback) Feedback(param string, value float32, force bool) {
+	r.values[param] = value
+	if force {
+		r.forced++
+	}
+}
+
+func TestSyncFeedback(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	sink := &recordedFeedback{values: make(map[string]float32)}
+	model.SetMIDIFeedback(sink)
+
+	// First sync reports everything
+	model.syncFeedback(false)
+	if sink.values["gain"] != 0.5 {
+		t.Errorf("expected gain feedback 0.5, got %f", sink.values["gain"])
+	}
+	if sink.values["blend_mode_mirror"] != 1 || sink.values["blend_mode_complement"] != 0 {
+		t.Errorf("expected mirror option lit, got %v", sink.values)
+	}
+
+	// Changes from any source are reported after Update
+	sink.values = make(map[string]float32)
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if len(sink.values) != 3 {
+		t.Errorf("expected feedback for blend mode and its two changed options, got %v", sink.values)
+	}
+	if sink.values["blend_mode_complement"] != 1 || sink.values["blend_mode_mirror"] != 0 {
+		t.Errorf("expected complement option lit, got %v", sink.values)
+	}
+
+	// The feedback command resends everything
+	sink.forced = 0
+	cmdFeedback(&model, nil)
+	if sink.forced == 0 {
+		t.Error("expected feedback command to force a resend")
+	}
+}
+
+func TestPresetMsg_LoadsPreset(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	preset := config.DefaultPreset()
+	preset.Gain = 1.5
+	if err := config.SavePreset(preset, "loud"); err != nil {
+		t.Fatalf("failed to save preset: %v", err)
+	}
+
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	model.Update(midi.PresetMsg{Name: "loud"})
+	if model.Gain != 1.5 || model.currentPresetName != "loud" {
+		t.Errorf("expected preset loud to load, got gain %f name %q", model.Gain, model.currentPresetName)
+	}
+
+	// Missing presets leave the current state alone
+	model.Update(midi.PresetMsg{Name: "missing"})
+	if model.currentPresetName != "loud" {
+		t.Errorf("expected missing preset to be ignored, got %q", model.currentPresetName)
+	}
+}
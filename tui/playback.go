@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/renderorange/chroma/chroma-control/midi"
+)
+
+// playbackState holds MIDI file playback started with :play-midi.
+type playbackState struct {
+	player *midi.Player
+	msgs   chan tea.Msg // Messages from the player, read by waitPlayback
+	status midi.PlaybackMsg
+	err    string
+}
+
+// playbackMsg carries a message from the player, so the model keeps reading
+// them after each one.
+type playbackMsg struct {
+	msg tea.Msg
+}
+
+// PlayMIDI starts playing the CC and note events of a MIDI file through the
+// MIDI mappings. Before the program runs, Init starts delivering them.
+func (m *Model) PlayMIDI(path string, loop bool) error {
+	m.playMIDI(path, loop)
+	if m.playback.err != "" {
+		return errors.New(m.playback.err)
+	}
+	return nil
+}
+
+// playMIDI replaces the player with one for path and starts it. It returns
+// a command reading the player's messages if none is running yet.
+func (m *Model) playMIDI(path string, loop bool) tea.Cmd {
+	var cmd tea.Cmd
+	if m.playback.msgs == nil {
+		m.playback.msgs = make(chan tea.Msg, 256)
+		cmd = m.waitPlayback()
+	}
+	player, err := midi.NewPlayer(path, m.midiConfig, playbackSender(m.playback.msgs))
+	if err != nil {
+		m.playback.err = err.Error()
+		return cmd
+	}
+	if m.playback.player != nil {
+		m.playback.player.Stop()
+	}
+	m.playback.player = player
+	m.playback.err = ""
+	player.SetLoop(loop)
+	player.Start()
+	m.playback.status = player.Status()
+	return cmd
+}
+
+// playbackSender returns the function a player delivers its messages
+// through. Progress reports are dropped while the model is behind, as the
+// next one replaces them, but the report that playback stopped and resolved
+// MIDI messages wait until there is room.
+func playbackSender(msgs chan tea.Msg) func(tea.Msg) {
+	return func(msg tea.Msg) {
+		if status, ok := msg.(midi.PlaybackMsg); ok && status.Playing {
+			select {
+			case msgs <- msg:
+			default:
+			}
+			return
+		}
+		msgs <- msg
+	}
+}
+
+// waitPlayback returns a command delivering the next message from the
+// player.
+func (m *Model) waitPlayback() tea.Cmd {
+	msgs := m.playback.msgs
+	if msgs == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return playbackMsg{msg: <-msgs}
+	}
+}
+
+// applyPlayback handles a message from the player. Resolved MIDI messages
+// apply as if they came from a controller.
+func (m *Model) applyPlayback(msg playbackMsg) (tea.Model, tea.Cmd) {
+	wait := m.waitPlayback()
+	if _, ok := msg.msg.(midi.PlaybackMsg); ok {
+		// A replaced player may still report, so ask the current one
+		if m.playback.player != nil {
+			m.playback.status = m.playback.player.Status()
+		}
+		return m, wait
+	}
+	_, cmd := m.update(msg.msg)
+	return m, tea.Batch(cmd, wait)
+}
+
+// formatPlayback returns the playback state for the status bar, empty when
+// no file has been played.
+func (m Model) formatPlayback() string {
+	if m.playback.err != "" {
+		return lipgloss.NewStyle().Foreground(colorTextError).Render("play-midi: " + m.playback.err)
+	}
+	if m.playback.player == nil {
+		return ""
+	}
+	s := m.playback.status
+	var parts []string
+	if s.Playing {
+		parts = append(parts, "▶", s.File, formatPlaybackTime(s.Position)+"/"+formatPlaybackTime(s.Length))
+	} else {
+		parts = append(parts, "■", s.File)
+	}
+	if s.Loop {
+		parts = append(parts, "(loop)")
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatPlaybackTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// cmdPlayMIDI handles the play-midi command: a file name (and optionally
+// "loop") starts playing it, and start, stop and loop control the current
+// file.
+func cmdPlayMIDI(m *Model, args []string) tea.Cmd {
+	if len(args) == 0 {
+		return nil
+	}
+	player := m.playback.player
+	switch strings.ToLower(args[0]) {
+	case "start", "restart":
+		if player != nil {
+			player.Start()
+			m.playback.status = player.Status()
+		}
+		return nil
+	case "stop":
+		if player != nil {
+			player.Stop()
+		}
+		return nil
+	case "loop":
+		if player != nil && len(args) == 1 {
+			player.SetLoop(!player.Status().Loop)
+			m.playback.status = player.Status()
+			return nil
+		}
+	}
+
+	loop := false
+	if len(args) > 1 && strings.ToLower(args[len(args)-1]) == "loop" {
+		loop = true
+		args = args[:len(args)-1]
+	}
+	return m.playMIDI(strings.Join(args, " "), loop)
+}
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+// nextPlayback applies the next message from the player.
+func nextPlayback(t *testing.T, model *Model) playbackMsg {
+	t.Helper()
+	got := make(chan playbackMsg, 1)
+	go func() { got <- model.waitPlayback()().(playbackMsg) }()
+	select {
+	case msg := <-got:
+		model.Update(msg)
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for playback")
+	}
+	return playbackMsg{}
+}
+
+func TestPlayMIDI_AppliesThroughMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "take.txt")
+	os.WriteFile(path, []byte("0 B0 01 00\n0.05 B0 01 7F\n"), 0o644)
+
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(120, 40)
+	if cmd := model.executeCommand("play-midi " + path + " loop"); cmd == nil {
+		t.Fatal("expected a command reading the player")
+	}
+	if !model.playback.status.Playing || !model.playback.status.Loop {
+		t.Errorf("expected looped playback, got %+v", model.playback.status)
+	}
+	if bar := model.renderStatusBar(120); !strings.Contains(bar, "▶ take.txt") || !strings.Contains(bar, "(loop)") {
+		t.Errorf("expected playback in the status bar, got %q", bar)
+	}
+
+	for model.Gain != 0 {
+		nextPlayback(t, &model)
+	}
+	model.executeCommand("play-midi stop")
+	for {
+		if status, ok := nextPlayback(t, &model).msg.(midi.PlaybackMsg); ok && !status.Playing {
+			break
+		}
+	}
+	if model.playback.status.Playing || !strings.Contains(model.renderStatusBar(120), "■ take.txt") {
+		t.Errorf("expected playback to stop, got %+v", model.playback.status)
+	}
+}
+
+func TestPlayMIDI_ReportsErrors(t *testing.T) {
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	if err := model.PlayMIDI(filepath.Join(t.TempDir(), "missing.mid"), false); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	if bar := model.renderStatusBar(120); !strings.Contains(bar, "play-midi:") {
+		t.Errorf("expected the error in the status bar, got %q", bar)
+	}
+}
+
+func TestPlayMIDI_ControlsDontWaitForModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "take.txt")
+	os.WriteFile(path, []byte("0 B0 01 00\n10 B0 01 7F\n"), 0o644)
+
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(120, 40)
+	model.executeCommand("play-midi " + path)
+	// The model is busy, so nothing reads the player's messages
+	for len(model.playback.msgs) < cap(model.playback.msgs) {
+		model.playback.msgs <- midi.PlaybackMsg{Playing: true}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		model.executeCommand("play-midi loop")
+		model.executeCommand("play-midi start")
+		model.executeCommand("play-midi " + path)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected playback commands not to wait for the model")
+	}
+	if !model.playback.status.Playing || model.playback.status.Loop {
+		t.Errorf("expected the new file to play without looping, got %+v", model.playback.status)
+	}
+	model.executeCommand("play-midi stop")
+}
@@ -2,7 +2,9 @@ package tui
 
 import (
 	"fmt"
+	"path"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -17,6 +19,18 @@ func (m *Model) renderPresetBrowser() string {
 		return m.renderDeleteConfirmation()
 	case browserModeSaveAs:
 		return m.renderSaveAsDialog()
+	case browserModeReport:
+		return m.renderPresetReport()
+	case browserModeNewFolder:
+		return m.renderBrowserInput("New Folder", "enter:create  esc:cancel")
+	case browserModeRename:
+		return m.renderBrowserInput(fmt.Sprintf("Rename '%s'", m.presetBrowser.confirmName), "enter:rename  esc:cancel")
+	case browserModeMove:
+		return m.renderBrowserInput(fmt.Sprintf("Move '%s' To Folder", m.presetBrowser.confirmName), "enter:move  esc:cancel")
+	case browserModeTag:
+		return m.renderBrowserInput("Filter By Tag", "enter:filter (empty for all)  esc:cancel")
+	case browserModeDiff:
+		return m.renderPresetDiff()
 	}
 	return ""
 }
@@ -52,7 +66,13 @@ func (m *Model) renderPresetList() string {
 
 	var content strings.Builder
 
-	content.WriteString(titleStyle.Render("Load Preset"))
+	title := "Load Preset"
+	if m.presetBrowser.tag != "" {
+		title += " — tag: " + m.presetBrowser.tag
+	} else if m.presetBrowser.folder != "" {
+		title += " — " + m.presetBrowser.folder + "/"
+	}
+	content.WriteString(titleStyle.Render(title))
 	content.WriteString("\n\n")
 
 	if m.presetBrowser.errorMsg != "" {
@@ -60,29 +80,88 @@ func (m *Model) renderPresetList() string {
 		content.WriteString("\n\n")
 	}
 
-	if len(m.presetBrowser.presets) == 0 {
+	if m.presetBrowser.entryCount() == 0 && m.presetBrowser.tag != "" {
+		content.WriteString(mutedStyle.Render("No presets tagged " + m.presetBrowser.tag))
+	} else if m.presetBrowser.entryCount() == 0 {
 		content.WriteString(mutedStyle.Render("No presets saved yet"))
 	} else {
-		for i, preset := range m.presetBrowser.presets {
+		for i := 0; i < m.presetBrowser.entryCount(); i++ {
+			name, isFolder, up := m.presetBrowser.entry(i)
+			label := path.Base(name)
+			if m.presetBrowser.tag != "" {
+				label = name // Matches come from all folders
+			}
+			if up {
+				label = ".."
+			} else if isFolder {
+				label += "/"
+			}
 			style := itemStyle
 			prefix := "  "
 			if i == m.presetBrowser.selectedIdx {
 				style = selectedStyle
 				prefix = "> "
 			}
-			content.WriteString(style.Render(prefix + preset))
+			content.WriteString(style.Render(prefix + label))
 			content.WriteString("\n")
 		}
 	}
 
+	if details := m.renderPresetMeta(modalWidth - 4); details != "" {
+		content.WriteString("\n")
+		content.WriteString(details)
+	}
+
 	content.WriteString("\n")
 	content.WriteString(mutedStyle.Render(strings.Repeat("─", modalWidth-4)))
 	content.WriteString("\n")
-	content.WriteString(mutedStyle.Render("enter:load  d:delete  n:new  esc:back"))
+	content.WriteString(mutedStyle.Render("enter:open  bksp:up  n:new  f:folder  r:rename  m:move  d:delete  t:tag  esc:back"))
 
 	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
 }
 
+// renderPresetMeta shows the metadata of the selected preset, if it has
+// any.
+func (m *Model) renderPresetMeta(width int) string {
+	name, isFolder, _ := m.presetBrowser.entry(m.presetBrowser.selectedIdx)
+	meta, ok := m.presetBrowser.metas[name]
+	if isFolder || !ok {
+		return ""
+	}
+
+	textStyle := lipgloss.NewStyle().
+		Foreground(colorTextNormal).
+		Width(width)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted).
+		Width(width)
+
+	var lines []string
+	if meta.Description != "" {
+		lines = append(lines, textStyle.Render(meta.Description))
+	}
+	if len(meta.Tags) > 0 {
+		lines = append(lines, mutedStyle.Render("tags: "+strings.Join(meta.Tags, ", ")))
+	}
+	if meta.Author != "" {
+		lines = append(lines, mutedStyle.Render("by "+meta.Author))
+	}
+	if !meta.Created.IsZero() {
+		dates := "created " + meta.Created.Local().Format("2006-01-02")
+		if !meta.Modified.IsZero() {
+			dates += ", modified " + meta.Modified.Local().Format("2006-01-02 15:04")
+		}
+		lines = append(lines, mutedStyle.Render(dates))
+	}
+	if meta.Notes != "" {
+		lines = append(lines, textStyle.Render(meta.Notes))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
 func (m *Model) renderDeleteConfirmation() string {
 	modalWidth := 50
 
@@ -99,7 +178,11 @@ func (m *Model) renderDeleteConfirmation() string {
 		Padding(1, 2)
 
 	var content strings.Builder
-	content.WriteString(titleStyle.Render("Delete Preset?"))
+	title := "Delete Preset?"
+	if m.presetBrowser.confirmFolder {
+		title = "Delete Folder?"
+	}
+	content.WriteString(titleStyle.Render(title))
 	content.WriteString("\n\n")
 	content.WriteString(textStyle.Render(fmt.Sprintf("Delete '%s'?", m.presetBrowser.confirmName)))
 	content.WriteString("\n\n")
@@ -133,6 +216,150 @@ func (m *Model) renderSaveAsDialog() string {
 	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
 }
 
+// renderBrowserInput renders the name prompt for folder and rename/move
+// actions in the preset browser.
+func (m *Model) renderBrowserInput(title, hint string) string {
+	modalWidth := 50
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true)
+	inputStyle := lipgloss.NewStyle().
+		Foreground(colorTextHighlight)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(title))
+	content.WriteString("\n\n")
+	content.WriteString(inputStyle.Render(m.presetBrowser.inputBuffer + "_"))
+	content.WriteString("\n\n")
+	content.WriteString(mutedStyle.Render(hint))
+
+	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
+}
+
+// renderPresetReport shows what was changed upgrading a preset on load, or
+// the outcome of an import or export.
+func (m *Model) renderPresetReport() string {
+	modalWidth := 60
+	if m.width > 0 && m.width < modalWidth+4 {
+		modalWidth = m.width - 4
+	}
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(colorTextNormal).
+		Width(modalWidth - 4)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+
+	var content strings.Builder
+	title := m.presetBrowser.reportTitle
+	if title == "" {
+		title = fmt.Sprintf("Preset '%s' Upgraded", m.presetBrowser.reportName)
+	}
+	content.WriteString(titleStyle.Render(title))
+	content.WriteString("\n\n")
+	for _, change := range m.presetBrowser.report {
+		content.WriteString(itemStyle.Render("• " + change))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render("enter:continue"))
+
+	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
+}
+
+// renderPresetDiff shows the values that differ between two presets,
+// grouped by effect.
+func (m *Model) renderPresetDiff() string {
+	modalWidth := 70
+	if m.width > 0 && m.width < modalWidth+4 {
+		modalWidth = m.width - 4
+	}
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true)
+	groupStyle := lipgloss.NewStyle().
+		Foreground(colorTextHighlight).
+		Bold(true)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(colorTextNormal).
+		Width(modalWidth - 4)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(m.presetBrowser.diffTitle))
+	content.WriteString("\n\n")
+	if len(m.presetBrowser.diff) == 0 {
+		content.WriteString(mutedStyle.Render("No differences"))
+		content.WriteString("\n")
+	}
+	group := ""
+	for _, change := range m.presetBrowser.diff {
+		if change.Group != group {
+			group = change.Group
+			content.WriteString(groupStyle.Render(group))
+			content.WriteString("\n")
+		}
+		content.WriteString(itemStyle.Render(fmt.Sprintf("  %s: %s → %s", change.Key, change.Old, change.New)))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render("enter:close"))
+
+	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
+}
+
+// showPresetReport opens the report for the preset just loaded, if it had
+// to be upgraded or had problems fixed. It returns false when there is
+// nothing to show.
+func (m *Model) showPresetReport() bool {
+	if len(m.presetBrowser.report) == 0 {
+		return false
+	}
+	m.presetBrowser.mode = browserModeReport
+	if m.screen != screenPresetBrowser {
+		m.switchScreen(screenPresetBrowser)
+	}
+	return true
+}
+
+func (m *Model) handleReportKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "esc", "q", " ":
+		m.presetBrowser.report = nil
+		m.presetBrowser.reportTitle = ""
+		m.presetBrowser.diff = nil
+		m.presetBrowser.mode = browserModeList
+		m.switchScreen(screenMain)
+	}
+	return m, nil
+}
+
 func (m *Model) centerModal(content string) string {
 	// Simple centering based on terminal dimensions
 	if m.width == 0 || m.height == 0 {
@@ -182,6 +409,10 @@ func (m *Model) updatePresetBrowser(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleDeleteConfirmKeys(msg)
 		case browserModeSaveAs:
 			return m.handleSaveAsKeys(msg)
+		case browserModeReport, browserModeDiff:
+			return m.handleReportKeys(msg)
+		case browserModeNewFolder, browserModeRename, browserModeMove, browserModeTag:
+			return m.handleBrowserInputKeys(msg)
 		}
 
 	case tea.WindowSizeMsg:
@@ -193,6 +424,8 @@ func (m *Model) updatePresetBrowser(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) handlePresetListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	m.presetBrowser.errorMsg = "" // Clear error on any key
+	name, isFolder, up := m.presetBrowser.entry(m.presetBrowser.selectedIdx)
+	hasEntry := m.presetBrowser.selectedIdx < m.presetBrowser.entryCount() && !up
 
 	switch msg.String() {
 	case "esc", "q":
@@ -205,7 +438,7 @@ func (m *Model) handlePresetListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "j", "down":
-		if m.presetBrowser.selectedIdx < len(m.presetBrowser.presets)-1 {
+		if m.presetBrowser.selectedIdx < m.presetBrowser.entryCount()-1 {
 			m.presetBrowser.selectedIdx++
 		}
 		return m, nil
@@ -216,18 +449,31 @@ func (m *Model) handlePresetListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "enter":
-		if len(m.presetBrowser.presets) > 0 && m.presetBrowser.selectedIdx < len(m.presetBrowser.presets) {
-			name := m.presetBrowser.presets[m.presetBrowser.selectedIdx]
-			if preset, err := config.LoadPreset(name); err == nil {
-				// Initialize lists first if needed
-				if m.width > 0 && m.effectsList.Items() == nil {
-					m.InitLists(m.width, m.height)
+	case "backspace", "h", "left":
+		if m.presetBrowser.tag != "" {
+			m.presetBrowser.tag = ""
+			m.presetBrowser.selectedIdx = 0
+			m.refreshPresetList()
+		} else {
+			m.leavePresetFolder()
+		}
+		return m, nil
+
+	case "t":
+		m.presetBrowser.inputBuffer = m.presetBrowser.tag
+		m.presetBrowser.mode = browserModeTag
+		return m, nil
+
+	case "enter", "l", "right":
+		if up {
+			m.leavePresetFolder()
+		} else if isFolder {
+			m.openPresetFolder(name)
+		} else if hasEntry && msg.String() == "enter" {
+			if err := m.loadPreset(name); err == nil {
+				if !m.showPresetReport() {
+					m.switchScreen(screenMain)
 				}
-				m.applyPreset(preset)
-				m.currentPresetName = name
-				config.SaveLastPresetName(name)
-				m.switchScreen(screenMain)
 			} else {
 				m.presetBrowser.errorMsg = "Failed to load preset"
 			}
@@ -235,25 +481,141 @@ func (m *Model) handlePresetListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "d":
-		if len(m.presetBrowser.presets) > 0 {
-			m.presetBrowser.confirmName = m.presetBrowser.presets[m.presetBrowser.selectedIdx]
+		if hasEntry {
+			m.presetBrowser.confirmName = name
+			m.presetBrowser.confirmFolder = isFolder
 			m.presetBrowser.mode = browserModeConfirmDelete
 		}
 		return m, nil
 
 	case "n":
-		// New preset - enter save-as mode
+		// New preset - enter save-as mode, in the folder being browsed
 		m.presetBrowser.inputBuffer = ""
+		if m.presetBrowser.folder != "" {
+			m.presetBrowser.inputBuffer = m.presetBrowser.folder + "/"
+		}
 		m.presetBrowser.mode = browserModeSaveAs
 		return m, nil
+
+	case "f":
+		m.presetBrowser.inputBuffer = ""
+		m.presetBrowser.mode = browserModeNewFolder
+		return m, nil
+
+	case "r":
+		if hasEntry {
+			m.presetBrowser.confirmName = name
+			m.presetBrowser.confirmFolder = isFolder
+			m.presetBrowser.inputBuffer = path.Base(name)
+			m.presetBrowser.mode = browserModeRename
+		}
+		return m, nil
+
+	case "m":
+		if hasEntry {
+			m.presetBrowser.confirmName = name
+			m.presetBrowser.confirmFolder = isFolder
+			m.presetBrowser.inputBuffer = m.presetBrowser.folder
+			m.presetBrowser.mode = browserModeMove
+		}
+		return m, nil
 	}
 	return m, nil
 }
 
+// openPresetFolder browses into a folder.
+func (m *Model) openPresetFolder(folder string) {
+	m.presetBrowser.folder = folder
+	m.presetBrowser.selectedIdx = 0
+	m.refreshPresetList()
+}
+
+// leavePresetFolder browses up to the parent folder, selecting the folder
+// just left.
+func (m *Model) leavePresetFolder() {
+	folder := m.presetBrowser.folder
+	if folder == "" {
+		return
+	}
+	m.presetBrowser.folder = parentFolder(folder)
+	m.refreshPresetList()
+	for i := 0; i < m.presetBrowser.entryCount(); i++ {
+		if name, isFolder, _ := m.presetBrowser.entry(i); isFolder && name == folder {
+			m.presetBrowser.selectedIdx = i
+		}
+	}
+}
+
+// selectPresetEntry browses to the folder holding a preset and selects it.
+func (m *Model) selectPresetEntry(name string) {
+	if name == "" || m.presetBrowser.tag != "" {
+		return
+	}
+	m.presetBrowser.folder = parentFolder(name)
+	m.refreshPresetList()
+	for i := 0; i < m.presetBrowser.entryCount(); i++ {
+		if entry, isFolder, _ := m.presetBrowser.entry(i); !isFolder && entry == name {
+			m.presetBrowser.selectedIdx = i
+		}
+	}
+}
+
+// parentFolder returns the folder holding a preset or folder, "" for the
+// top level.
+func parentFolder(name string) string {
+	if dir := path.Dir(name); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// entryCount returns the number of rows in the browser list: the parent
+// folder when inside one, then folders, then presets. Filtering by tag
+// lists only matching presets.
+func (s *presetBrowserState) entryCount() int {
+	n := len(s.folders) + len(s.presets)
+	if s.hasUp() {
+		n++
+	}
+	return n
+}
+
+// hasUp reports whether the list starts with a row leading up a folder.
+func (s *presetBrowserState) hasUp() bool {
+	return s.folder != "" && s.tag == ""
+}
+
+// entry returns the full name of row i of the browser list, whether it is a
+// folder, and whether it is the row leading up to the parent folder.
+func (s *presetBrowserState) entry(i int) (name string, isFolder, up bool) {
+	if s.hasUp() {
+		if i == 0 {
+			return parentFolder(s.folder), true, true
+		}
+		i--
+	}
+	if i < 0 {
+		return "", false, false
+	}
+	if i < len(s.folders) {
+		return s.folders[i], true, false
+	}
+	if i -= len(s.folders); i < len(s.presets) {
+		return s.presets[i], false, false
+	}
+	return "", false, false
+}
+
 func (m *Model) handleDeleteConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y":
-		config.DeletePreset(m.presetBrowser.confirmName)
+		if m.presetBrowser.confirmFolder {
+			if err := config.DeletePresetFolder(m.presetBrowser.confirmName); err != nil {
+				m.presetBrowser.errorMsg = err.Error()
+			}
+		} else {
+			config.DeletePreset(m.presetBrowser.confirmName)
+		}
 		m.refreshPresetList()
 		m.presetBrowser.mode = browserModeList
 
@@ -272,6 +634,9 @@ func (m *Model) handleSaveAsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case tea.KeyEnter:
 		if m.presetBrowser.inputBuffer != "" {
 			preset := m.buildCurrentPreset()
+			if m.presetBrowser.inputBuffer != m.currentPresetName {
+				preset.Meta.Created = time.Time{} // A new preset, not a copy's history
+			}
 			if err := config.SavePreset(preset, m.presetBrowser.inputBuffer); err != nil {
 				m.presetBrowser.errorMsg = "Failed to save preset"
 				m.presetBrowser.mode = browserModeList
@@ -298,12 +663,127 @@ func (m *Model) handleSaveAsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleBrowserInputKeys edits the name for a new folder, rename or move,
+// and carries it out on enter. Errors are shown back in the list.
+func (m *Model) handleBrowserInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.presetBrowser.mode = browserModeList
+		return m, nil
+
+	case tea.KeyEnter:
+		input := strings.Trim(m.presetBrowser.inputBuffer, "/")
+		from := m.presetBrowser.confirmName
+		var err error
+		switch m.presetBrowser.mode {
+		case browserModeNewFolder:
+			err = config.CreatePresetFolder(path.Join(m.presetBrowser.folder, input))
+		case browserModeRename:
+			if input != "" {
+				err = m.movePresetEntry(from, path.Join(parentFolder(from), input))
+			}
+		case browserModeMove:
+			err = m.movePresetEntry(from, path.Join(input, path.Base(from)))
+		case browserModeTag:
+			m.presetBrowser.tag = strings.TrimSpace(input)
+			m.presetBrowser.selectedIdx = 0
+		}
+		if err != nil {
+			m.presetBrowser.errorMsg = err.Error()
+		}
+		m.presetBrowser.mode = browserModeList
+		m.refreshPresetList()
+		return m, nil
+
+	case tea.KeyBackspace:
+		if len(m.presetBrowser.inputBuffer) > 0 {
+			m.presetBrowser.inputBuffer = m.presetBrowser.inputBuffer[:len(m.presetBrowser.inputBuffer)-1]
+		}
+		return m, nil
+
+	case tea.KeyRunes:
+		m.presetBrowser.inputBuffer += string(msg.Runes)
+		return m, nil
+	}
+	return m, nil
+}
+
+// movePresetEntry moves or renames the selected preset or folder, keeping
+// the current preset name pointing at the same file.
+func (m *Model) movePresetEntry(from, to string) error {
+	if from == to {
+		return nil
+	}
+	if m.presetBrowser.confirmFolder {
+		if err := config.MovePresetFolder(from, to); err != nil {
+			return err
+		}
+		if strings.HasPrefix(m.currentPresetName, from+"/") {
+			m.currentPresetName = to + strings.TrimPrefix(m.currentPresetName, from)
+			config.SaveLastPresetName(m.currentPresetName)
+		}
+		return nil
+	}
+	if err := config.MovePreset(from, to); err != nil {
+		return err
+	}
+	if m.currentPresetName == from {
+		m.currentPresetName = to
+		config.SaveLastPresetName(to)
+	}
+	return nil
+}
+
 func (m *Model) refreshPresetList() {
-	m.presetBrowser.presets, _ = config.ListPresets()
-	if m.presetBrowser.selectedIdx >= len(m.presetBrowser.presets) {
-		m.presetBrowser.selectedIdx = len(m.presetBrowser.presets) - 1
+	folders, presets, err := config.ListPresetFolder(m.presetBrowser.folder)
+	if err != nil && m.presetBrowser.folder != "" {
+		// The folder is gone, start over from the top
+		m.presetBrowser.folder = ""
+		folders, presets, _ = config.ListPresetFolder("")
+	}
+	if m.presetBrowser.tag != "" {
+		folders = nil
+		presets, _ = config.ListPresets()
+	}
+	m.presetBrowser.folders, m.presetBrowser.presets = folders, nil
+	m.presetBrowser.metas = make(map[string]config.PresetMeta, len(presets))
+	for _, name := range presets {
+		meta, err := config.LoadPresetMeta(name)
+		if err == nil {
+			m.presetBrowser.metas[name] = meta
+		}
+		if m.presetBrowser.tag == "" || meta.HasTag(m.presetBrowser.tag) {
+			m.presetBrowser.presets = append(m.presetBrowser.presets, name)
+		}
+	}
+	if m.presetBrowser.selectedIdx >= m.presetBrowser.entryCount() {
+		m.presetBrowser.selectedIdx = m.presetBrowser.entryCount() - 1
 	}
 	if m.presetBrowser.selectedIdx < 0 {
 		m.presetBrowser.selectedIdx = 0
 	}
 }
+
+// loadPreset loads the named preset, applies it and remembers it as the last
+// loaded preset. It is shared by the browser, :load and MIDI program changes.
+func (m *Model) loadPreset(name string) error {
+	preset, err := config.LoadPreset(name)
+	if err != nil {
+		return err
+	}
+	// Initialize lists first if needed
+	if m.width > 0 && m.effectsList.Items() == nil {
+		m.InitLists(m.width, m.height)
+	}
+	m.labelHistory("load "+name, "", true)
+	m.currentPresetName = name
+	m.applyPreset(preset)
+	m.presetBrowser.report = append(preset.Migrated, preset.Warnings...)
+	m.presetBrowser.reportName = name
+	m.presetBrowser.reportTitle = ""
+	if len(preset.Warnings) > 0 {
+		m.presetBrowser.reportTitle = fmt.Sprintf("Preset '%s' Loaded With Warnings", name)
+	}
+	config.SaveLastPresetName(name)
+	return nil
+}
@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+func TestPresetBrowser_ShowsUpgradeReport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	dir := filepath.Join(home, "chroma-control", "presets")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "old.toml"), []byte("gain = 1.5\ninput_freeze_length = 0.25\n"), 0644)
+
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+	model.executeCommand("presets")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if model.InputFreezeLength != 0.25 {
+		t.Errorf("expected the value to load, got %v", model.InputFreezeLength)
+	}
+	if model.screen != screenPresetBrowser || model.presetBrowser.mode != browserModeReport {
+		t.Fatalf("expected the upgrade report, got screen %d mode %d", model.screen, model.presetBrowser.mode)
+	}
+	view := model.View()
+	if !strings.Contains(view, "upgraded from schema version 0") || !strings.Contains(view, "old.toml.v0.bak") {
+		t.Errorf("expected the changes and backup in the report:\n%s", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.screen != screenMain || model.presetBrowser.report != nil {
+		t.Errorf("expected enter to dismiss the report, got screen %d", model.screen)
+	}
+}
+
+func TestPresetBrowser_ShowsValidationWarnings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	dir := filepath.Join(home, "chroma-control", "presets")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "edited.toml"), []byte("schema_version = 1\ngain = 50\ngrain_intensity = \"loud\"\n"), 0644)
+
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+	model.executeCommand("presets")
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if model.Gain != 2 || model.GrainIntensity != "subtle" || model.FilterCutoff != config.DefaultPreset().FilterCutoff {
+		t.Errorf("expected checked values, got gain %v intensity %q cutoff %v", model.Gain, model.GrainIntensity, model.FilterCutoff)
+	}
+	if model.screen != screenPresetBrowser || model.presetBrowser.mode != browserModeReport {
+		t.Fatalf("expected the warning report, got screen %d mode %d", model.screen, model.presetBrowser.mode)
+	}
+	view := model.View()
+	for _, want := range []string{"Loaded With Warnings", "gain: 50 is outside 0 to 2", "grain_intensity"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the report:\n%s", want, view)
+		}
+	}
+}
+
+func TestRestoreCommand(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	model.Gain = 0.5
+	model.executeCommand("save wash")()
+	model.Gain = 1.5
+	model.executeCommand("save wash")()
+
+	model.executeCommand("restore")
+	if model.Gain != 0.5 || model.currentPresetName != "wash" || model.isDirty {
+		t.Errorf("expected the backup of wash loaded, got gain %v preset %q dirty %v", model.Gain, model.currentPresetName, model.isDirty)
+	}
+
+	model.executeCommand("restore wash 7")
+	if model.screen != screenPresetBrowser || !strings.Contains(model.presetBrowser.errorMsg, "no backup 7") {
+		t.Errorf("expected the error in the preset browser, got screen %d %q", model.screen, model.presetBrowser.errorMsg)
+	}
+}
+
+func TestPresetBrowser_Folders(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	model.Gain = 0.5
+	model.executeCommand("save show1/intro")()
+	model.executeCommand("save wash")()
+	model.executeCommand("load show1/intro")()
+	if model.currentPresetName != "show1/intro" {
+		t.Fatalf("expected bank/name addressing in :load, got %q", model.currentPresetName)
+	}
+
+	key := func(s string) {
+		switch s {
+		case "enter":
+			model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		case "backspace":
+			model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		default:
+			model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+		}
+	}
+
+	model.executeCommand("presets")
+	if name, isFolder, _ := model.presetBrowser.entry(0); name != "show1" || !isFolder {
+		t.Fatalf("expected the folder listed first, got %q", name)
+	}
+	key("enter")
+	if model.presetBrowser.folder != "show1" || !strings.Contains(model.View(), "show1/") {
+		t.Fatalf("expected to browse into show1, got %q", model.presetBrowser.folder)
+	}
+
+	// Rename the current preset, which follows it
+	key("j")
+	key("r")
+	for range "intro" {
+		key("backspace")
+	}
+	key("opening")
+	key("enter")
+	if model.presetBrowser.errorMsg != "" || model.currentPresetName != "show1/opening" {
+		t.Fatalf("expected the rename to follow the current preset, got %q (%s)", model.currentPresetName, model.presetBrowser.errorMsg)
+	}
+
+	// New folder, then move the preset into it
+	key("f")
+	key("act2")
+	key("enter")
+	model.presetBrowser.selectedIdx = 2
+	key("m")
+	key("/act2")
+	key("enter")
+	if names, _ := config.ListPresets(); strings.Join(names, ",") != "show1/act2/opening,wash" {
+		t.Fatalf("expected the preset moved, got %v", names)
+	}
+
+	// Back up to the top, landing on the folder just left
+	key("backspace")
+	if model.presetBrowser.folder != "" || model.presetBrowser.selectedIdx != 0 {
+		t.Errorf("expected to go up to show1, got %q at %d", model.presetBrowser.folder, model.presetBrowser.selectedIdx)
+	}
+	key("d")
+	key("y")
+	if !strings.Contains(model.presetBrowser.errorMsg, "not empty") {
+		t.Errorf("expected non-empty folders to be kept, got %q", model.presetBrowser.errorMsg)
+	}
+}
+
+func TestPresetBrowser_Metadata(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	model.executeCommand("meta tags live")
+	if !strings.Contains(model.presetBrowser.errorMsg, "save the preset") {
+		t.Errorf("expected metadata to need a saved preset, got %q", model.presetBrowser.errorMsg)
+	}
+
+	model.SetScreenForTesting(int(screenMain))
+	model.executeCommand("save show1/intro")()
+	model.executeCommand("meta description Quiet opening")
+	model.executeCommand("meta tags live, ambient")
+	if model.isDirty {
+		t.Error("expected metadata not to make the preset dirty")
+	}
+	model.executeCommand("save wash")()
+	model.executeCommand("meta tags studio")
+	if meta, _ := config.LoadPresetMeta("wash"); meta.Description != "Quiet opening" || !meta.Created.After(time.Time{}) {
+		t.Errorf("expected save-as to keep the description and stamp a created time, got %+v", meta)
+	}
+
+	// Filter by tag across folders
+	model.executeCommand("presets")
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Live")})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if strings.Join(model.presetBrowser.presets, ",") != "show1/intro" {
+		t.Fatalf("expected only the live preset, got %v", model.presetBrowser.presets)
+	}
+	view := model.View()
+	if !strings.Contains(view, "Quiet opening") || !strings.Contains(view, "tags: live, ambient") {
+		t.Errorf("expected the selected preset's metadata in the browser:\n%s", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if model.presetBrowser.tag != "" || model.presetBrowser.entryCount() != 2 {
+		t.Errorf("expected backspace to clear the filter, got %q with %d entries", model.presetBrowser.tag, model.presetBrowser.entryCount())
+	}
+}
+
+func TestExportImportCommands(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	model.Gain = 0.5
+	model.executeCommand("save show1/intro")()
+	path := filepath.Join(t.TempDir(), "show1.zip")
+	model.executeCommand("export " + path + " show1")
+	if model.presetBrowser.mode != browserModeReport || !strings.Contains(model.View(), "Exported 1 Preset(s) to show1.zip") {
+		t.Fatalf("expected the export listed:\n%s", model.View())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	model.executeCommand("import " + path)
+	if !strings.Contains(model.View(), "show1/intro: imported as show1/intro-2") {
+		t.Errorf("expected the renamed import listed:\n%s", model.View())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	model.executeCommand("import " + path + " skip")
+	if !strings.Contains(model.View(), "skipped") {
+		t.Errorf("expected the import skipped:\n%s", model.View())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	model.executeCommand("import " + filepath.Join(t.TempDir(), "missing.zip"))
+	if model.presetBrowser.errorMsg == "" {
+		t.Error("expected a missing bundle to show an error")
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	model := NewModel(osc.NewClient("127.0.0.1", 57120))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+
+	model.Gain = 0.5
+	model.executeCommand("save soft wash")()
+	model.Gain = 1.5
+	model.ReverbEnabled = true
+	model.executeCommand("save storm")()
+	model.ReverbMix = 0.75
+
+	model.executeCommand("diff soft wash storm")
+	view := model.View()
+	if model.presetBrowser.mode != browserModeDiff || !strings.Contains(view, "soft wash → storm") {
+		t.Fatalf("expected the diff of both presets:\n%s", view)
+	}
+	if !strings.Contains(view, "gain: 0.5 → 1.5") || !strings.Contains(view, "reverb_enabled: off → on") || strings.Contains(view, "reverb_mix") {
+		t.Errorf("expected only the saved differences:\n%s", view)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.screen != screenMain || model.presetBrowser.diff != nil {
+		t.Errorf("expected enter to close the diff, got screen %d", model.screen)
+	}
+
+	model.executeCommand("diff storm")
+	if view := model.View(); !strings.Contains(view, "storm → current") || !strings.Contains(view, "reverb_mix:") || strings.Contains(view, "gain:") {
+		t.Errorf("expected the unsaved changes against storm:\n%s", view)
+	}
+}
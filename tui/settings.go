@@ -141,8 +141,9 @@ func (m *Model) handleSettingsSelection() (tea.Model, tea.Cmd) {
 		m.switchScreen(screenPresetBrowser)
 
 	case settingsReset:
-		m.applyPreset(config.DefaultPreset())
+		m.labelHistory("reset", "", true)
 		m.currentPresetName = ""
+		m.applyPreset(config.DefaultPreset())
 		m.isDirty = true
 	}
 	return m, nil
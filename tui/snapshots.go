@@ -0,0 +1,312 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/renderorange/chroma/chroma-control/config"
+)
+
+// effectSnapshotState holds the effect snapshot screen, listing the
+// snapshots saved for one effect.
+type effectSnapshotState struct {
+	effect      string
+	names       []string
+	selectedIdx int
+	naming      bool // Typing a name to save under
+	inputBuffer string
+	confirmName string // Snapshot waiting for delete confirmation
+	errorMsg    string
+}
+
+// selectedEffect returns the id of the effect selected in the effects list.
+func (m *Model) selectedEffect() string {
+	if eff, ok := m.effectsList.SelectedItem().(effectItem); ok {
+		return eff.id
+	}
+	return ""
+}
+
+// openEffectSnapshots shows the snapshots of effect.
+func (m *Model) openEffectSnapshots(effect string) {
+	m.snapshots = effectSnapshotState{effect: effect}
+	m.refreshEffectSnapshots()
+	m.switchScreen(screenEffectSnapshots)
+}
+
+func (m *Model) refreshEffectSnapshots() {
+	names, err := config.ListEffectSnapshots(m.snapshots.effect)
+	if err != nil {
+		m.snapshots.errorMsg = err.Error()
+	}
+	m.snapshots.names = names
+	if m.snapshots.selectedIdx >= len(names) {
+		m.snapshots.selectedIdx = max(len(names)-1, 0)
+	}
+}
+
+// loadEffectSnapshot applies an effect snapshot over the current values.
+// The loaded preset stays the same, so it shows as modified. Problems fixed
+// in the snapshot's values are returned as warnings.
+func (m *Model) loadEffectSnapshot(effect, name string) ([]string, error) {
+	preset, err := config.LoadEffectSnapshot(effect, name, m.buildCurrentPreset())
+	if err != nil {
+		return nil, err
+	}
+	m.labelHistory(fmt.Sprintf("snapshot %s: %s", effect, name), "", false)
+	m.applyValues(preset)
+	return preset.Warnings, nil
+}
+
+// cmdSnapshot handles the snapshot command: ":snapshot delay" lists the delay
+// snapshots, ":snapshot delay dub echo" loads one and ":snapshot save delay
+// dub echo" saves the current delay settings as one. Without an effect it
+// uses the one selected in the effects list.
+func cmdSnapshot(m *Model, args []string) tea.Cmd {
+	save := len(args) > 0 && args[0] == "save"
+	if save {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		effect := m.selectedEffect()
+		if effect == "" {
+			effect = "master"
+		}
+		args = []string{effect}
+	}
+	effect, name := args[0], strings.Join(args[1:], " ")
+	if config.EffectSnapshotKeys(effect) == nil {
+		m.showPresetError(fmt.Errorf("unknown effect '%s', use master or one of %s", effect, strings.Join(config.Effects, ", ")))
+		return nil
+	}
+
+	var err error
+	switch {
+	case name == "":
+		m.openEffectSnapshots(effect)
+		if save {
+			m.snapshots.naming = true
+		}
+		return nil
+	case save:
+		err = config.SaveEffectSnapshot(effect, name, m.buildCurrentPreset())
+	default:
+		var warnings []string
+		warnings, err = m.loadEffectSnapshot(effect, name)
+		if err == nil && len(warnings) > 0 {
+			err = fmt.Errorf("loaded with warnings: %s", strings.Join(warnings, "; "))
+		}
+	}
+	if err != nil {
+		m.openEffectSnapshots(effect)
+		m.snapshots.errorMsg = err.Error()
+	}
+	return nil
+}
+
+// updateEffectSnapshots handles updates on the effect snapshot screen.
+func (m *Model) updateEffectSnapshots(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case m.snapshots.naming:
+			return m.handleSnapshotNameKeys(msg)
+		case m.snapshots.confirmName != "":
+			return m.handleSnapshotDeleteKeys(msg)
+		}
+		return m.handleSnapshotListKeys(msg)
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	}
+	return m, nil
+}
+
+func (m *Model) handleSnapshotListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.snapshots.errorMsg = "" // Clear error on any key
+	hasEntry := m.snapshots.selectedIdx < len(m.snapshots.names)
+
+	switch msg.String() {
+	case "esc", "q":
+		m.goBack()
+
+	case "up", "k":
+		if m.snapshots.selectedIdx > 0 {
+			m.snapshots.selectedIdx--
+		}
+
+	case "down", "j":
+		if m.snapshots.selectedIdx < len(m.snapshots.names)-1 {
+			m.snapshots.selectedIdx++
+		}
+
+	case "enter":
+		if !hasEntry {
+			break
+		}
+		warnings, err := m.loadEffectSnapshot(m.snapshots.effect, m.snapshots.names[m.snapshots.selectedIdx])
+		if err != nil {
+			m.snapshots.errorMsg = err.Error()
+			break
+		}
+		if len(warnings) > 0 {
+			// Stay, so the warnings can be read
+			m.snapshots.errorMsg = "loaded with warnings: " + strings.Join(warnings, "; ")
+			break
+		}
+		m.goBack()
+
+	case "n", "s":
+		m.snapshots.naming = true
+		m.snapshots.inputBuffer = ""
+		if hasEntry {
+			m.snapshots.inputBuffer = m.snapshots.names[m.snapshots.selectedIdx]
+		}
+
+	case "d":
+		if hasEntry {
+			m.snapshots.confirmName = m.snapshots.names[m.snapshots.selectedIdx]
+		}
+
+	case "?":
+		m.switchScreen(screenHelp)
+	}
+	return m, nil
+}
+
+// handleSnapshotNameKeys edits the name to save a snapshot under, and saves
+// it on enter.
+func (m *Model) handleSnapshotNameKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.snapshots.naming = false
+
+	case tea.KeyEnter:
+		name := strings.TrimSpace(m.snapshots.inputBuffer)
+		if name == "" {
+			break
+		}
+		m.snapshots.naming = false
+		if err := config.SaveEffectSnapshot(m.snapshots.effect, name, m.buildCurrentPreset()); err != nil {
+			m.snapshots.errorMsg = err.Error()
+			break
+		}
+		m.refreshEffectSnapshots()
+		for i, n := range m.snapshots.names {
+			if n == name {
+				m.snapshots.selectedIdx = i
+			}
+		}
+
+	case tea.KeyBackspace:
+		if len(m.snapshots.inputBuffer) > 0 {
+			m.snapshots.inputBuffer = m.snapshots.inputBuffer[:len(m.snapshots.inputBuffer)-1]
+		}
+
+	case tea.KeySpace:
+		m.snapshots.inputBuffer += " "
+
+	case tea.KeyRunes:
+		m.snapshots.inputBuffer += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m *Model) handleSnapshotDeleteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		if err := config.DeleteEffectSnapshot(m.snapshots.effect, m.snapshots.confirmName); err != nil {
+			m.snapshots.errorMsg = err.Error()
+		}
+		m.snapshots.confirmName = ""
+		m.refreshEffectSnapshots()
+
+	case "n", "esc":
+		m.snapshots.confirmName = ""
+	}
+	return m, nil
+}
+
+func (m *Model) renderEffectSnapshots() string {
+	modalWidth := 50
+	if m.width > 0 && m.width < modalWidth+4 {
+		modalWidth = m.width - 4
+	}
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(colorPrimary).
+		Bold(true).
+		Width(modalWidth - 4)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(colorTextNormal).
+		Width(modalWidth - 4)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(colorTextHighlight).
+		Bold(true).
+		Width(modalWidth - 4)
+	inputStyle := lipgloss.NewStyle().
+		Foreground(colorTextHighlight)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(colorTextMuted)
+	errorStyle := lipgloss.NewStyle().
+		Foreground(colorTextError)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(fmt.Sprintf("Effect Snapshots — %s", m.snapshots.effect)))
+	content.WriteString("\n\n")
+
+	if m.snapshots.errorMsg != "" {
+		content.WriteString(errorStyle.Render(m.snapshots.errorMsg))
+		content.WriteString("\n\n")
+	}
+
+	var hint string
+	switch {
+	case m.snapshots.naming:
+		content.WriteString(mutedStyle.Render("Save " + m.snapshots.effect + " settings as:"))
+		content.WriteString("\n")
+		content.WriteString(inputStyle.Render(m.snapshots.inputBuffer + "_"))
+		content.WriteString("\n")
+		hint = "enter:save  esc:cancel"
+
+	case m.snapshots.confirmName != "":
+		content.WriteString(fmt.Sprintf("Delete snapshot '%s'?", m.snapshots.confirmName))
+		content.WriteString("\n")
+		hint = "y:yes  n:no"
+
+	case len(m.snapshots.names) == 0:
+		content.WriteString(mutedStyle.Render("No " + m.snapshots.effect + " snapshots saved yet"))
+		content.WriteString("\n")
+		hint = "n:save current  esc:back"
+
+	default:
+		for i, name := range m.snapshots.names {
+			style := itemStyle
+			prefix := "  "
+			if i == m.snapshots.selectedIdx {
+				style = selectedStyle
+				prefix = "> "
+			}
+			content.WriteString(style.Render(prefix + name))
+			content.WriteString("\n")
+		}
+		hint = "enter:load  n:save current  d:delete  esc:back"
+	}
+
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render(strings.Repeat("─", modalWidth-4)))
+	content.WriteString("\n")
+	content.WriteString(mutedStyle.Render(hint))
+
+	return m.centerModal(modalStyle.Width(modalWidth).Render(content.String()))
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+func TestEffectSnapshots(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	engine := osc.NewServer("127.0.0.1", 0)
+	sent := make(chan string, 64)
+	engine.HandleFloat("/chroma/delayTime", func(float32) { sent <- "delayTime" })
+	engine.HandleFloat("/chroma/gain", func(float32) { sent <- "gain" })
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	model := NewModel(osc.NewClient("127.0.0.1", engine.Addr().(*net.UDPAddr).Port))
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(100, 40)
+	model.executeCommand("save base")()
+
+	// Select delay in the effects list and save its settings
+	for model.selectedEffect() != "delay" {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	}
+	model.DelayTime = 0.8
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if model.screen != screenEffectSnapshots || model.snapshots.effect != "delay" {
+		t.Fatalf("expected the delay snapshots, got screen %v effect %q", model.screen, model.snapshots.effect)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	for _, r := range "dub" {
+		model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeySpace})
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("echo")})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.Contains(model.View(), "dub echo") {
+		t.Fatalf("expected the saved snapshot listed:\n%s", model.View())
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	model.DelayTime = 0.1
+	model.Gain = 1.7
+	model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.screen != screenMain {
+		t.Errorf("expected loading to return to the main screen, got %v", model.screen)
+	}
+	if model.DelayTime != 0.8 || model.Gain != 1.7 {
+		t.Errorf("expected only the delay restored, got delay time %v gain %v", model.DelayTime, model.Gain)
+	}
+	if model.currentPresetName != "base" || !model.isDirty {
+		t.Errorf("expected base still loaded and modified, got %q dirty %v", model.currentPresetName, model.isDirty)
+	}
+
+	// Only the delay values that changed are sent
+	select {
+	case got := <-sent:
+		if got != "delayTime" {
+			t.Errorf("expected delay time sent, got %s", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected delay time sent")
+	}
+	select {
+	case got := <-sent:
+		t.Errorf("expected nothing else sent, got %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	model.executeCommand("snapshot delay nope")
+	if model.screen != screenEffectSnapshots || !strings.Contains(model.snapshots.errorMsg, "not found") {
+		t.Errorf("expected an error for a missing snapshot, got %q", model.snapshots.errorMsg)
+	}
+}
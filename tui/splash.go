@@ -138,12 +138,15 @@ func (m *Model) handleSplashSelection() (tea.Model, tea.Cmd) {
 			m.applyPreset(config.DefaultPreset())
 			m.currentPresetName = ""
 		}
+		// The session starts here, not from the built-in values
+		m.resetHistory()
 		m.switchScreen(screenMain)
 
 	case splashNew:
 		// Start with factory defaults
 		m.applyPreset(config.DefaultPreset())
 		m.currentPresetName = ""
+		m.resetHistory()
 		m.switchScreen(screenMain)
 
 	case splashLoad:
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
+)
+
+// takeoverTolerance is how close, in normalized units, a control must come
+// to the parameter value to pick it up. One 7-bit CC step.
+const takeoverTolerance = 1.0 / 127
+
+// takeoverState tracks a physical control whose position may disagree with
+// the parameter it drives.
+type takeoverState struct {
+	knob    float32 // Last position reported by the control
+	value   float32 // Normalized value last set by the control
+	engaged bool    // Control and parameter agree, so values apply directly
+}
+
+// applyBend applies a return-to-center pitch bend as an offset from the
+// value the parameter had when the bend began, restoring that value when the
+// wheel returns to center.
+func (m *Model) applyBend(msg midi.ControlMsg) {
+	if m.bendBase == nil {
+		m.bendBase = make(map[string]float32)
+	}
+	base, bending := m.bendBase[msg.Param]
+	if msg.Value == 0 {
+		if bending {
+			m.applyControl(msg.Param, base)
+			delete(m.bendBase, msg.Param)
+		}
+		return
+	}
+	if !bending {
+		current, ok := m.controlValue(msg.Param)
+		if !ok {
+			return
+		}
+		base = current
+		m.bendBase[msg.Param] = base
+	}
+	m.applyControl(msg.Param, clamp(base+msg.Value, 0, 1))
+}
+
+// applyTakeover applies a CC using its pickup or scaling takeover mode.
+// Parameters without a position (toggles, enums, options) always jump.
+func (m *Model) applyTakeover(msg midi.ControlMsg) {
+	param, ok := config.LookupParam(msg.Param)
+	if !ok || param.Kind != config.ParamContinuous {
+		m.applyControl(msg.Param, msg.Value)
+		return
+	}
+
+	if m.takeover == nil {
+		m.takeover = make(map[string]takeoverState)
+	}
+	current, _ := m.controlValue(param.Name)
+	knob := msg.Value
+	st, touched := m.takeover[param.Name]
+
+	// Something else moved the parameter since this control last set it
+	if st.engaged && absDiff(current, st.value) > takeoverTolerance/2 {
+		st.engaged = false
+	}
+
+	if !st.engaged {
+		switch {
+		case absDiff(knob, current) <= takeoverTolerance:
+			st.engaged = true
+		case msg.Takeover == config.TakeoverPickup:
+			// Crossing the value between two readings also picks it up
+			if touched && (st.knob-current)*(knob-current) < 0 {
+				st.engaged = true
+			}
+		case msg.Takeover == config.TakeoverScale:
+			if touched && knob != st.knob {
+				// Move the remaining distance in proportion to the knob's
+				// remaining travel, so both reach the end stop together
+				next := current
+				if knob > st.knob {
+					next += (knob - st.knob) * (1 - current) / (1 - st.knob)
+				} else {
+					next -= (st.knob - knob) * current / st.knob
+				}
+				m.applyControl(param.Name, next)
+				st.value = next
+				if absDiff(next, knob) <= takeoverTolerance {
+					st.engaged = true
+				}
+			}
+		}
+	}
+
+	st.knob = knob
+	if st.engaged {
+		m.applyControl(param.Name, knob)
+		st.value, _ = m.controlValue(param.Name)
+	}
+	m.takeover[param.Name] = st
+}
+
+// awaitingPickup reports whether a touched control disagrees with the
+// parameter it drives and is waiting to take it over.
+func (m *Model) awaitingPickup(name string) bool {
+	st, touched := m.takeover[name]
+	if !touched {
+		return false
+	}
+	if !st.engaged {
+		return true
+	}
+	current, _ := m.controlValue(name)
+	return absDiff(current, st.value) > takeoverTolerance/2
+}
+
+func absDiff(a, b float32) float32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
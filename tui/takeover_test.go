@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+func TestTakeover_Pickup(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+	model.DryWet = 0.5
+
+	pickup := func(value float32) {
+		model.Update(midi.ControlMsg{Param: "dry_wet", Value: value, Takeover: config.TakeoverPickup})
+	}
+
+	// Knob starts far below the value and is ignored
+	pickup(0.1)
+	pickup(0.2)
+	if model.DryWet != 0.5 {
+		t.Errorf("expected dry/wet to wait for pickup, got %f", model.DryWet)
+	}
+	if !model.awaitingPickup("dry_wet") {
+		t.Error("expected dry/wet to be awaiting pickup")
+	}
+
+	// Crossing the value picks it up
+	pickup(0.6)
+	if model.DryWet != 0.6 {
+		t.Errorf("expected dry/wet 0.6 after pickup, got %f", model.DryWet)
+	}
+	if model.awaitingPickup("dry_wet") {
+		t.Error("expected dry/wet to be picked up")
+	}
+
+	// Changing the value elsewhere drops the pickup again
+	model.DryWet = 0.9
+	if !model.awaitingPickup("dry_wet") {
+		t.Error("expected dry/wet to await pickup after a change elsewhere")
+	}
+	pickup(0.65)
+	if model.DryWet != 0.9 {
+		t.Errorf("expected dry/wet to stay at 0.9, got %f", model.DryWet)
+	}
+}
+
+func TestTakeover_Scale(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+	model.DryWet = 0.5
+
+	scale := func(value float32) {
+		model.Update(midi.ControlMsg{Param: "dry_wet", Value: value, Takeover: config.TakeoverScale})
+	}
+
+	// First reading only records the knob position
+	scale(0.2)
+	if model.DryWet != 0.5 {
+		t.Errorf("expected dry/wet to stay at 0.5, got %f", model.DryWet)
+	}
+
+	// Half the knob's travel covers half the remaining distance
+	scale(0.6)
+	if diff := model.DryWet - 0.75; diff > 0.001 || diff < -0.001 {
+		t.Errorf("expected dry/wet 0.75, got %f", model.DryWet)
+	}
+
+	// Both reach the end stop together and then move as one
+	scale(1)
+	if model.DryWet != 1 {
+		t.Errorf("expected dry/wet 1, got %f", model.DryWet)
+	}
+	scale(0.3)
+	if diff := model.DryWet - 0.3; diff > 0.001 || diff < -0.001 {
+		t.Errorf("expected dry/wet 0.3 once engaged, got %f", model.DryWet)
+	}
+}
+
+func TestTakeover_ParameterListMarker(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+	model.DryWet = 0.5
+
+	model.Update(midi.ControlMsg{Param: "dry_wet", Value: 0, Takeover: config.TakeoverPickup})
+
+	for _, item := range model.buildParameterList("master") {
+		pi := item.(parameterItem)
+		if pi.ctrl != ctrlDryWet {
+			continue
+		}
+		if !strings.HasSuffix(pi.Title(), "(pickup)") {
+			t.Errorf("expected dry/wet title to show pickup, got %q", pi.Title())
+		}
+		return
+	}
+	t.Error("dry/wet not found in master parameters")
+}
+
+func TestBend_ReturnsToCenter(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+	model.GranularPitchScatter = 0.4
+
+	bend := func(offset float32) {
+		model.Update(midi.ControlMsg{Param: "granular_pitch_scatter", Value: offset, Bend: true})
+	}
+
+	bend(0.25)
+	if model.GranularPitchScatter != 0.65 {
+		t.Errorf("expected bend up to 0.65, got %f", model.GranularPitchScatter)
+	}
+	bend(-0.5)
+	if model.GranularPitchScatter != 0 {
+		t.Errorf("expected bend down to clamp at 0, got %f", model.GranularPitchScatter)
+	}
+	bend(0)
+	if model.GranularPitchScatter != 0.4 {
+		t.Errorf("expected release to restore 0.4, got %f", model.GranularPitchScatter)
+	}
+}
@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
+)
+
+// syncField returns the model field holding a tempo sync setting, or nil.
+func (m *Model) syncField(name string) *string {
+	switch name {
+	case "delay_sync":
+		return &m.DelaySync
+	case "mod_sync":
+		return &m.ModSync
+	}
+	return nil
+}
+
+// setSync sets a tempo sync parameter to the option at idx and applies the
+// current tempo. Index 0 turns sync off.
+func (m *Model) setSync(name string, idx int) {
+	field := m.syncField(name)
+	if field == nil || idx < 0 || idx >= len(config.SyncOptions) {
+		return
+	}
+	*field = ""
+	if idx > 0 {
+		*field = config.SyncOptions[idx]
+	}
+	m.applyTempo()
+}
+
+// syncIndex returns the SyncOptions index of a tempo sync parameter.
+func (m *Model) syncIndex(name string) int {
+	field := m.syncField(name)
+	if field == nil {
+		return 0
+	}
+	for i, opt := range config.SyncOptions {
+		if i > 0 && opt == *field {
+			return i
+		}
+	}
+	return 0
+}
+
+// cycleSync moves a tempo sync parameter to the next or previous option.
+func (m *Model) cycleSync(name string, direction int) {
+	n := len(config.SyncOptions)
+	m.setSync(name, (m.syncIndex(name)+direction+n)%n)
+}
+
+// applyClock records the tempo from MIDI clock and retimes synced
+// parameters. Tempo changes in a row undo as one step.
+func (m *Model) applyClock(msg midi.ClockMsg) {
+	m.clock = msg
+	if m.applyTempo() {
+		if len(m.parameterList.Items()) > 0 {
+			m.refreshParameterList()
+		}
+		m.labelHistory(fmt.Sprintf("tempo: %.1f BPM", msg.BPM), "tempo", false)
+		m.checkDirty()
+	}
+}
+
+// applyTempo sets delay time and mod rate from their note divisions at the
+// current tempo, returning true if either changed. Without a tempo, or with
+// sync off, they are left alone. Callers refresh the lists and dirty state.
+func (m *Model) applyTempo() bool {
+	changed := false
+	if secs, ok := config.DivisionSeconds(m.DelaySync, m.clock.BPM); ok {
+		if t := clamp(float32(secs), 0.01, 2.0); t != m.DelayTime {
+			m.DelayTime = t
+			m.sendParam("delay_time")
+			changed = true
+		}
+	}
+	if secs, ok := config.DivisionSeconds(m.ModSync, m.clock.BPM); ok {
+		if rate := clamp(float32(1/secs), 0.1, 10.0); rate != m.ModRate {
+			m.ModRate = rate
+			m.sendParam("mod_rate")
+			changed = true
+		}
+	}
+	return changed
+}
+
+// unsync turns tempo sync off for a parameter that was set by hand.
+func (m *Model) unsync(name string) {
+	switch name {
+	case "delay_time":
+		m.DelaySync = ""
+	case "mod_rate":
+		m.ModSync = ""
+	}
+}
+
+// selectedSyncParam returns the tempo sync parameter selected in the
+// parameter list, if any.
+func (m *Model) selectedSyncParam() (string, bool) {
+	idx := m.parameterList.Index()
+	items := m.parameterList.Items()
+	if idx >= 0 && idx < len(items) {
+		if param, ok := items[idx].(parameterItem); ok {
+			switch param.ctrl {
+			case ctrlDelaySync, ctrlModSync:
+				return controlParams[param.ctrl], true
+			}
+		}
+	}
+	return "", false
+}
+
+func (m *Model) formatSync(sync string) string {
+	if sync == "" {
+		return "Off"
+	}
+	if m.clock.BPM <= 0 {
+		return sync + " (no clock)"
+	}
+	return fmt.Sprintf("%s @ %.1f BPM", sync, m.clock.BPM)
+}
+
+// formatClock returns the tempo and song position for the status bar, or
+// empty without MIDI clock.
+func (m *Model) formatClock() string {
+	if m.clock.BPM <= 0 {
+		return ""
+	}
+	transport := "■"
+	if m.clock.Running {
+		transport = "▶"
+	}
+	// Bars and beats assume 4/4
+	return fmt.Sprintf("%.1f BPM %s %d.%d", m.clock.BPM, transport, m.clock.Beat/4+1, m.clock.Beat%4+1)
+}
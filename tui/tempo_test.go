@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/renderorange/chroma/chroma-control/midi"
+	"github.com/renderorange/chroma/chroma-control/osc"
+)
+
+func TestTempoSync_FollowsClock(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	model.DelaySync = "1/8."
+	model.ModSync = "1/4"
+
+	model.Update(midi.ClockMsg{BPM: 120, Running: true})
+	if model.DelayTime != 0.375 {
+		t.Errorf("expected dotted eighth delay of 0.375s at 120 BPM, got %f", model.DelayTime)
+	}
+	if model.ModRate != 2 {
+		t.Errorf("expected quarter note mod rate of 2Hz at 120 BPM, got %f", model.ModRate)
+	}
+
+	// Tempo changes retime synced parameters
+	model.Update(midi.ClockMsg{BPM: 60, Running: true})
+	if model.DelayTime != 0.75 {
+		t.Errorf("expected delay of 0.75s at 60 BPM, got %f", model.DelayTime)
+	}
+
+	// Setting the time by hand turns sync off
+	model.Update(midi.ControlMsg{Param: "delay_time", Value: 0})
+	if model.DelaySync != "" {
+		t.Errorf("expected delay sync off after manual change, got %q", model.DelaySync)
+	}
+	model.Update(midi.ClockMsg{BPM: 120, Running: true})
+	if model.DelayTime != 0.01 {
+		t.Errorf("expected unsynced delay time to stay put, got %f", model.DelayTime)
+	}
+}
+
+func TestTempoSync_EnumAndStatus(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+	model.Update(midi.ClockMsg{BPM: 120, Running: true, Beat: 5})
+
+	// Sync settings are enums, index 0 is off
+	model.Update(midi.ControlMsg{Param: "delay_sync", Value: 1})
+	if model.DelaySync != "1/32" {
+		t.Errorf("expected the last division, got %q", model.DelaySync)
+	}
+	model.cycleSync("delay_sync", 1)
+	if model.DelaySync != "" {
+		t.Errorf("expected cycling past the last division to turn sync off, got %q", model.DelaySync)
+	}
+
+	status := model.renderStatusBar(100)
+	if !strings.Contains(status, "120.0 BPM") || !strings.Contains(status, "2.2") {
+		t.Errorf("expected status bar to show tempo and position, got %s", status)
+	}
+}
+
+func TestTempoSync_ClockRefreshesListAndDirty(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+	model.currentSection = "delay"
+	model.refreshParameterList()
+	model.DelaySync = "1/8."
+	p := model.buildCurrentPreset()
+	model.loadedPresetHash = p.Hash()
+	model.isDirty = false
+
+	model.Update(midi.ClockMsg{BPM: 120, Running: true})
+	var row float32
+	for _, item := range model.parameterList.Items() {
+		if param, ok := item.(parameterItem); ok && param.ctrl == ctrlDelayTime {
+			row = param.value
+		}
+	}
+	if row != 0.375 {
+		t.Errorf("expected the delay time row to show 0.375, got %f", row)
+	}
+	if !model.isDirty {
+		t.Error("expected a clock-synced change to mark the preset modified")
+	}
+}
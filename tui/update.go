@@ -5,13 +5,72 @@ import (
 	"math"
 
 	"github.com/renderorange/chroma/chroma-control/config"
+	"github.com/renderorange/chroma/chroma-control/midi"
 )
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	// Playback started from the command line
+	return m.waitPlayback()
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	// Keep controller feedback in step with every change, whatever its source
+	m.syncFeedback(false)
+	return model, cmd
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// MIDI input applies regardless of the current screen
+	if msg, ok := msg.(midi.ControlMsg); ok {
+		switch {
+		case msg.Bend:
+			m.applyBend(msg)
+		case msg.Toggle:
+			m.toggleControl(msg.Param, msg.Value)
+		case msg.Takeover == config.TakeoverPickup, msg.Takeover == config.TakeoverScale:
+			m.applyTakeover(msg)
+		default:
+			m.applyControl(msg.Param, msg.Value)
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(midi.StatusMsg); ok {
+		m.SetMIDIStatus(msg)
+		if msg.Connected {
+			// A reconnected controller has lost its display state
+			m.syncFeedback(true)
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(MorphMsg); ok {
+		m.setMorph(msg.Position)
+		return m, nil
+	}
+	if msg, ok := msg.(midi.ClockMsg); ok {
+		m.applyClock(msg)
+		return m, nil
+	}
+	if msg, ok := msg.(midi.PresetMsg); ok {
+		// Unknown presets are ignored, as with :load. Upgrade reports are
+		// not shown mid-performance.
+		if m.loadPreset(msg.Name) == nil {
+			m.presetBrowser.report = nil
+		}
+		return m, nil
+	}
+	if _, ok := msg.(presetLoadedMsg); ok {
+		m.showPresetReport()
+	}
+	if msg, ok := msg.(playbackMsg); ok {
+		return m.applyPlayback(msg)
+	}
+	if msg, ok := msg.(midi.MonitorMsg); ok {
+		m.monitor.log(msg)
+		m.learnMapping(msg)
+		return m, nil
+	}
+
 	// Handle quit confirmation first (overlays any screen)
 	if m.showQuitConfirm {
 		switch msg := msg.(type) {
@@ -31,6 +90,14 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateSettings(msg)
 	case screenHelp:
 		return m.updateHelp(msg)
+	case screenMIDIMonitor:
+		return m.updateMIDIMonitor(msg)
+	case screenMappingEditor:
+		return m.updateMappingEditor(msg)
+	case screenEffectSnapshots:
+		return m.updateEffectSnapshots(msg)
+	case screenHistory:
+		return m.updateHistory(msg)
 	}
 
 	switch msg := msg.(type) {
@@ -119,6 +186,20 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.setBlendMode(2)
 		return m, nil
 
+	case "u":
+		m.undo()
+		return m, nil
+	case "ctrl+r":
+		m.redo()
+		return m, nil
+
+	case "[":
+		m.setMorph(m.morph.position - morphStep)
+		return m, nil
+	case "]":
+		m.setMorph(m.morph.position + morphStep)
+		return m, nil
+
 	case ":":
 		m.toggleCommandPalette()
 		return m, nil
@@ -186,6 +267,8 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.cycleGrainIntensity(-1)
 			} else if m.isBlendModeSelected() {
 				m.cycleBlendModeWithDirection(-1)
+			} else if name, ok := m.selectedSyncParam(); ok {
+				m.cycleSync(name, -1)
 			} else {
 				m.adjustSelectedParameter(-0.05)
 			}
@@ -198,6 +281,8 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.cycleGrainIntensity(1)
 			} else if m.isBlendModeSelected() {
 				m.cycleBlendModeWithDirection(1)
+			} else if name, ok := m.selectedSyncParam(); ok {
+				m.cycleSync(name, 1)
 			} else {
 				m.adjustSelectedParameter(0.05)
 			}
@@ -252,6 +337,12 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Effects list navigation
 	if m.navigationMode == modeEffectsList {
 		switch msg.String() {
+		case "s":
+			if effect := m.selectedEffect(); effect != "" {
+				m.openEffectSnapshots(effect)
+			}
+			return m, nil
+
 		case "up", "k":
 			if m.effectsList.Index() > 0 {
 				m.effectsList.CursorUp()
@@ -327,6 +418,9 @@ func (m *Model) handleEnterKey() (tea.Model, tea.Cmd) {
 				} else if param.ctrl == ctrlBlendMode {
 					m.cycleBlendModeWithDirection(1)
 					m.checkDirty()
+				} else if name, ok := m.selectedSyncParam(); ok {
+					m.cycleSync(name, 1)
+					m.checkDirty()
 				} else if param.isToggle {
 					m.toggleByControl(param.ctrl)
 					m.refreshEffectsList()
@@ -436,7 +530,7 @@ func (m *Model) adjustFocused(delta float32) {
 		if err := m.client.SetGranularDensity(m.GranularDensity); err != nil {
 		}
 	case ctrlGranularSize:
-		m.GranularSize = adjustLogarithmic(m.GranularSize, delta*0.5, 0.01, 2.0)
+		m.GranularSize = adjustLogarithmic(m.GranularSize, delta*0.5, 0.01, 0.5)
 		if err := m.client.SetGranularSize(m.GranularSize); err != nil {
 		}
 	case ctrlGranularPitchScatter:
@@ -460,6 +554,7 @@ func (m *Model) adjustFocused(delta float32) {
 		if err := m.client.SetReverbMix(m.ReverbMix); err != nil {
 		}
 	case ctrlDelayTime:
+		m.DelaySync = ""
 		m.DelayTime = clamp(m.DelayTime+delta*1.99, 0.01, 2.0)
 		if err := m.client.SetDelayTime(m.DelayTime); err != nil {
 		}
@@ -468,6 +563,7 @@ func (m *Model) adjustFocused(delta float32) {
 		if err := m.client.SetDelayDecayTime(m.DelayDecayTime); err != nil {
 		}
 	case ctrlModRate:
+		m.ModSync = ""
 		m.ModRate = clamp(m.ModRate+delta*9.9, 0.1, 10.0)
 		if err := m.client.SetModRate(m.ModRate); err != nil {
 		}
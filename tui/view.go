@@ -32,6 +32,14 @@ func (m Model) View() string {
 		return m.renderSettings()
 	case screenHelp:
 		return m.renderHelp()
+	case screenMIDIMonitor:
+		return m.renderMIDIMonitor()
+	case screenMappingEditor:
+		return m.renderMappingEditor()
+	case screenEffectSnapshots:
+		return m.renderEffectSnapshots()
+	case screenHistory:
+		return m.renderHistory()
 	case screenMain:
 		return m.renderMain()
 	default:
@@ -195,9 +203,15 @@ func (m Model) renderStatusBar(width int) string {
 	}
 
 	// MIDI status
-	midiStatus := m.midiPort
-	if midiStatus == "" {
-		midiStatus = "No MIDI"
+	midiStatus := m.formatMIDIStatus()
+	if clock := m.formatClock(); clock != "" {
+		midiStatus += " " + clock
+	}
+	if playback := m.formatPlayback(); playback != "" {
+		midiStatus = playback + " | " + midiStatus
+	}
+	if morph := m.formatMorph(); morph != "" {
+		midiStatus = morph + " | " + midiStatus
 	}
 
 	// Preset name and dirty indicator
@@ -252,6 +266,35 @@ func (m Model) renderStatusBar(width int) string {
 	return statusBarStyle.Render(statusText)
 }
 
+// formatMIDIStatus returns the state of each MIDI input device, prefixed by
+// its profile name when there are several.
+func (m Model) formatMIDIStatus() string {
+	var parts []string
+	for _, status := range m.midiStatus {
+		text := status.Port
+		if status.Device != "" {
+			if text == "" {
+				text = status.Device
+			} else {
+				text = status.Device + ": " + text
+			}
+		}
+		if text == "" {
+			continue
+		}
+		if !status.Connected {
+			text = lipgloss.NewStyle().Foreground(colorTextError).Render(text + " (disconnected)")
+		} else if status.Suggest != "" {
+			text += lipgloss.NewStyle().Foreground(colorTextMuted).Render(" (profile: " + status.Suggest + "?)")
+		}
+		parts = append(parts, text)
+	}
+	if len(parts) == 0 {
+		return "No MIDI"
+	}
+	return strings.Join(parts, " | ")
+}
+
 func (m *Model) renderQuitConfirmation() string {
 	modalWidth := 50
 	if m.width > 0 && m.width < modalWidth+4 {
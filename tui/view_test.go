@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/renderorange/chroma/chroma-control/midi"
 	"github.com/renderorange/chroma/chroma-control/osc"
 )
 
@@ -237,3 +238,41 @@ func TestView_MinimumTerminalSize(t *testing.T) {
 		t.Errorf("expected warning for small terminal, got: %s", view)
 	}
 }
+
+func TestView_StatusBarMIDIDisconnected(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	model.Update(midi.StatusMsg{Port: "nanoKONTROL2"})
+	if statusBar := model.renderStatusBar(76); !strings.Contains(statusBar, "nanoKONTROL2 (disconnected)") {
+		t.Errorf("expected status bar to show the device as disconnected, got: %s", statusBar)
+	}
+
+	model.Update(midi.StatusMsg{Port: "nanoKONTROL2 MIDI 1", Connected: true})
+	statusBar := model.renderStatusBar(76)
+	if !strings.Contains(statusBar, "nanoKONTROL2 MIDI 1") || strings.Contains(statusBar, "disconnected") {
+		t.Errorf("expected status bar to show the connected device, got: %s", statusBar)
+	}
+}
+
+func TestView_StatusBarMultipleDevices(t *testing.T) {
+	client := osc.NewClient("127.0.0.1", 57120)
+	model := NewModel(client)
+	model.SetScreenForTesting(int(screenMain))
+	model.InitLists(80, 40)
+
+	model.Update(midi.StatusMsg{Device: "faders", Port: "nanoKONTROL2 MIDI 1", Connected: true})
+	model.Update(midi.StatusMsg{Device: "pads", Port: "MPD218"})
+
+	statusBar := model.renderStatusBar(120)
+	if !strings.Contains(statusBar, "faders: nanoKONTROL2 MIDI 1") || !strings.Contains(statusBar, "pads: MPD218 (disconnected)") {
+		t.Errorf("expected status bar to show each device, got: %s", statusBar)
+	}
+
+	model.Update(midi.StatusMsg{Device: "pads", Port: "MPD218 MIDI 1", Connected: true})
+	if statusBar := model.renderStatusBar(120); strings.Contains(statusBar, "disconnected") {
+		t.Errorf("expected both devices connected, got: %s", statusBar)
+	}
+}